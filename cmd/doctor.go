@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/denysvitali/llm-usage/internal/cache"
+	"github.com/denysvitali/llm-usage/internal/config"
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/doctor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorJSON    bool
+	doctorOffline bool
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the configuration, credentials and accounts",
+	Long: `Check the llm-usage installation and print how to fix the problems found:
+
+  - the config and cache directories exist, are writable and are private
+  - config.yaml and every credentials file parse, and credentials files have mode 0600
+    and use the current format
+  - Claude token expiry, and Claude logins found in more than one source
+  - the age of MiniMax session cookies
+  - every account's credentials are accepted by its provider (skipped with --offline)
+
+The command changes no files and exits with an error when a check fails.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runDoctor,
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "Output in JSON format")
+	doctorCmd.Flags().BoolVar(&doctorOffline, "offline", false, "Skip validating accounts with their providers")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(_ *cobra.Command, _ []string) error {
+	// An invalid config file is reported by the doctor, which then uses the defaults
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = &config.Config{}
+	}
+	if err := configureHTTP(cfg); err != nil {
+		return err
+	}
+
+	d := &doctor.Doctor{
+		Credentials: credentials.NewReadOnlyManager(),
+		ConfigPath:  config.DefaultPath(),
+		CacheDir:    cache.NewManager().CacheDir(),
+		Validate:    !doctorOffline,
+	}
+	report := d.Run()
+
+	if doctorJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+	} else {
+		report.WriteText(os.Stdout)
+	}
+
+	if report.Errors > 0 {
+		return fmt.Errorf("%d check(s) failed", report.Errors)
+	}
+	return nil
+}
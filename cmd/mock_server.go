@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/mockserver"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mockHost      string
+	mockPort      int
+	mockPeriod    time.Duration
+	mockScenarios []string
+)
+
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Start a server emulating provider usage endpoints",
+	Long: `Start an HTTP server that emulates the Claude OAuth usage, Kimi billing and subscription,
+and MiniMax coding plan endpoints, for demos and offline integration tests.
+
+Each provider plays a script of scenarios, one per usage request:
+
+  ramp         utilization climbs from 0% to 100% over --period and stays there
+  reset        utilization climbs to 100% and resets every --period (default)
+  expired      401, as if the credentials had expired
+  ratelimited  429 with Retry-After
+  malformed    200 with truncated JSON
+
+A script is a comma-separated list of scenarios, each optionally followed by the number
+of requests it lasts; the last scenario is kept. --scenario applies a script to all
+providers, or to one with PROVIDER=SCRIPT:
+
+  llm-usage mock-server --scenario ramp --scenario claude=reset:3,ratelimited:1,expired
+
+Scripts can be changed while the server runs:
+
+  curl -X POST 'http://localhost:8787/_mock/scenario?provider=kimi&script=expired'`,
+	RunE: runMockServer,
+}
+
+func init() {
+	mockServerCmd.Flags().StringVar(&mockHost, "host", "localhost", "Host to bind to")
+	mockServerCmd.Flags().IntVar(&mockPort, "port", 8787, "Port to listen on")
+	mockServerCmd.Flags().DurationVar(&mockPeriod, "period", mockserver.DefaultPeriod, "Time for usage to climb from 0% to 100%")
+	mockServerCmd.Flags().StringArrayVar(&mockScenarios, "scenario", nil, "Scenario script for all providers, or PROVIDER=SCRIPT (repeatable)")
+
+	rootCmd.AddCommand(mockServerCmd)
+}
+
+func runMockServer(_ *cobra.Command, _ []string) error {
+	cfg := &mockserver.Config{
+		Host:    mockHost,
+		Port:    mockPort,
+		Period:  mockPeriod,
+		Scripts: make(map[string]mockserver.Script),
+	}
+
+	for _, value := range mockScenarios {
+		id, spec, hasProvider := strings.Cut(value, "=")
+		if !hasProvider {
+			spec = value
+		}
+
+		script, err := mockserver.ParseScript(spec)
+		if err != nil {
+			return err
+		}
+
+		if !hasProvider {
+			cfg.Default = script
+			continue
+		}
+		if !slices.Contains(mockserver.Providers, id) {
+			return fmt.Errorf("unknown provider %q (valid: %s)", id, strings.Join(mockserver.Providers, ", "))
+		}
+		cfg.Scripts[id] = script
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	s := mockserver.NewServer(cfg)
+	if err := s.Start(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server error: %w", err)
+	}
+
+	return nil
+}
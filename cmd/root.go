@@ -2,10 +2,15 @@
 package cmd
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/denysvitali/llm-usage/internal/config"
 	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/httpclient"
 	"github.com/denysvitali/llm-usage/internal/usage"
 	"github.com/denysvitali/llm-usage/internal/version"
 	"github.com/spf13/cobra"
@@ -15,8 +20,21 @@ var (
 	providerFlag    string
 	accountFlag     string
 	allAccountsFlag bool
+	tagFlags        []string
+	thresholdFlag   float64
 	jsonOutput      bool
 	waybarOutput    bool
+	formatFlag      string
+	templateFlag    string
+	statusTextFlag  string
+	windowFlags     []string
+	warningFlag     float64
+	criticalFlag    float64
+	classFlags      []string
+	colorFlag       string
+	themeFlag       string
+	recordDir       string
+	replayDir       string
 )
 
 var rootCmd = &cobra.Command{
@@ -35,37 +53,129 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.Flags().StringVarP(&providerFlag, "provider", "p", "all", "Provider: claude, kimi, zai, minimax, or all")
+	rootCmd.Flags().StringVarP(&providerFlag, "provider", "p", "all", "Provider: claude, kimi, zai, minimax, openai, anthropic-api, openrouter, a configured provider ID, or all")
 	rootCmd.Flags().StringVarP(&accountFlag, "account", "a", "", "Account to use")
-	rootCmd.Flags().BoolVar(&allAccountsFlag, "all-accounts", false, "Aggregate usage across all accounts")
-	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
-	rootCmd.Flags().BoolVar(&waybarOutput, "waybar", false, "Output in waybar JSON format")
+	rootCmd.Flags().BoolVar(&allAccountsFlag, "all-accounts", false, "Show every account and pool the usage of each provider's accounts")
+	rootCmd.Flags().Float64Var(&thresholdFlag, "threshold", usage.DefaultThreshold, "Utilization at which an account no longer counts as available with --all-accounts")
+	rootCmd.Flags().StringSliceVarP(&tagFlags, "tag", "t", nil, "Only show accounts with this tag (repeatable; accounts with any of the tags are shown)")
+	rootCmd.Flags().StringVarP(&formatFlag, "format", "f", usage.FormatPretty, "Output format: "+strings.Join(usage.Formats(), ", "))
+	rootCmd.Flags().StringVar(&templateFlag, "template", "", "Go template for the template format, e.g. '{{range .Providers}}{{.Name}} {{end}}'")
+	rootCmd.Flags().StringVar(&statusTextFlag, "status-text", "", "Go template laying out the text of the status bar formats, e.g. 'AI {{pct .MaxUtilization}}'")
+	rootCmd.Flags().StringSliceVar(&windowFlags, "window", nil, "Window shown by the status bar formats: first, tightest or a label, optionally per provider as provider=window (repeatable)")
+	rootCmd.Flags().Float64Var(&warningFlag, "warning", usage.DefaultWarning, "Utilization from which status bars and bars in the pretty and table formats show a warning")
+	rootCmd.Flags().Float64Var(&criticalFlag, "critical", usage.DefaultCritical, "Utilization from which status bars and bars in the pretty and table formats show a critical state")
+	rootCmd.Flags().StringSliceVar(&classFlags, "class", nil, "Rename a waybar class as class=name, e.g. critical=urgent (repeatable)")
+	rootCmd.Flags().StringVar(&colorFlag, "color", "", "Color the pretty and table formats: auto (default), always or never")
+	rootCmd.Flags().StringVar(&themeFlag, "theme", "", "Theme of the pretty and table formats: "+usage.ThemeDefault+", "+usage.ThemeColorblind+" or a theme from the config file")
+	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format (same as --format json)")
+	rootCmd.Flags().BoolVar(&waybarOutput, "waybar", false, "Output in waybar JSON format (same as --format waybar)")
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "Save sanitized HTTP requests and responses to this directory")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "Serve HTTP responses recorded with --record from this directory instead of the network")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	rootCmd.MarkFlagsMutuallyExclusive("format", "json", "waybar")
 }
 
-func runUsage(_ *cobra.Command, _ []string) error {
+func runUsage(cmd *cobra.Command, _ []string) error {
+	windows, err := usage.ParseWindows(windowFlags)
+	if err != nil {
+		return err
+	}
+	classes, err := usage.ParseClasses(classFlags)
+	if err != nil {
+		return err
+	}
+	// The configuration sets the output defaults, but its errors are reported in the output
+	cfg, cfgErr := config.Load()
+	var output config.OutputConfig
+	if cfgErr == nil {
+		output = cfg.Output
+	}
+
+	renderer, err := usage.NewRenderer(outputFormat(cmd), usage.FormatOptions{
+		Template:   templateFlag,
+		StatusText: statusTextFlag,
+		Windows:    windows,
+		Warning:    warningFlag,
+		Critical:   criticalFlag,
+		Classes:    classes,
+		Color:      cmp.Or(colorFlag, output.Color),
+		Theme:      cmp.Or(themeFlag, output.Theme),
+		Themes:     output.Themes,
+		Module:     moduleMode(cmd),
+	})
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+
 	credsMgr := credentials.NewManager()
 
+	if cfgErr == nil {
+		cfgErr = configureHTTP(cfg)
+	}
+	if cfgErr != nil {
+		return usage.ReportError(renderer, out, cfgErr)
+	}
+
+	tags, err := credentials.NormalizeTags(tagFlags)
+	if err != nil {
+		return err
+	}
+	if thresholdFlag <= 0 || thresholdFlag > 100 {
+		return fmt.Errorf("--threshold must be between 0 and 100, got %g", thresholdFlag)
+	}
+
 	// Determine which providers to query
-	providers := usage.GetProviders(providerFlag, accountFlag, allAccountsFlag, credsMgr)
+	providers := usage.GetProviders(providerFlag, accountFlag, allAccountsFlag, credsMgr, cfg)
 	if len(providers) == 0 {
-		if waybarOutput {
-			usage.OutputWaybarError("No providers configured")
-			return nil
-		}
-		return fmt.Errorf("no providers configured. Run 'llm-usage setup' to configure providers")
+		return usage.ReportError(renderer, out, errors.New("no providers configured. Run 'llm-usage setup' to configure providers"))
+	}
+	if providers = usage.FilterByTags(providers, tags); len(providers) == 0 {
+		return usage.ReportError(renderer, out, fmt.Errorf("no accounts tagged %s. Tag accounts with 'llm-usage setup tag'", strings.Join(tags, " or ")))
 	}
 
 	// Fetch usage from all providers concurrently
 	stats := usage.FetchAllUsage(providers)
+	if allAccountsFlag {
+		stats.Aggregates = usage.Aggregate(stats, thresholdFlag)
+	}
+
+	return renderer.Render(out, stats)
+}
 
+// moduleMode reports whether the status bars show a single provider picked with --provider,
+// rather than every provider or the accounts pooled with --all-accounts
+func moduleMode(cmd *cobra.Command) bool {
+	return cmd.Flags().Changed("provider") && providerFlag != "all" && !allAccountsFlag
+}
+
+// outputFormat returns the format chosen with --format, or with its --json, --waybar and
+// --template shorthands
+func outputFormat(cmd *cobra.Command) string {
 	switch {
-	case waybarOutput:
-		usage.OutputWaybar(stats)
+	case cmd.Flags().Changed("format"):
+		return formatFlag
 	case jsonOutput:
-		usage.OutputJSON(stats)
+		return usage.FormatJSON
+	case waybarOutput:
+		return usage.FormatWaybar
+	case templateFlag != "":
+		return usage.FormatTemplate
 	default:
-		usage.OutputPretty(stats)
+		return formatFlag
 	}
+}
 
-	return nil
+// configureHTTP applies the HTTP settings from the configuration file and the
+// --record/--replay flags to the shared client
+func configureHTTP(cfg *config.Config) error {
+	return httpclient.Configure(httpclient.Options{
+		Proxy:        cfg.HTTP.Proxy,
+		Timeout:      cfg.HTTP.Timeout,
+		MaxRetries:   cfg.HTTP.MaxRetries,
+		MaxPerHost:   cfg.HTTP.MaxPerHost,
+		MaxBodyBytes: cfg.HTTP.MaxBodyBytes,
+		Record:       recordDir,
+		Replay:       replayDir,
+	})
 }
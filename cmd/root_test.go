@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestModuleMode(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"--waybar"}, false},
+		{[]string{"--provider", "all"}, false},
+		{[]string{"--provider", "kimi", "--all-accounts"}, false},
+		{[]string{"--provider", "kimi"}, true},
+		{[]string{"--waybar", "-p", "kimi", "--account", "work"}, true},
+	} {
+		providerFlag, allAccountsFlag = "all", false
+		cmd := &cobra.Command{}
+		cmd.Flags().StringVarP(&providerFlag, "provider", "p", "all", "")
+		cmd.Flags().BoolVar(&allAccountsFlag, "all-accounts", false, "")
+		cmd.Flags().Bool("waybar", false, "")
+		cmd.Flags().String("account", "", "")
+		if err := cmd.ParseFlags(tt.args); err != nil {
+			t.Fatalf("ParseFlags(%q) error = %v", tt.args, err)
+		}
+		if got := moduleMode(cmd); got != tt.want {
+			t.Errorf("moduleMode(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+	providerFlag, allAccountsFlag = "all", false
+}
@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/denysvitali/llm-usage/internal/config"
 	"github.com/denysvitali/llm-usage/internal/serve"
 	"github.com/spf13/cobra"
 )
@@ -44,10 +45,19 @@ func runServe(_ *cobra.Command, _ []string) error {
 		cancel()
 	}()
 
+	appCfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := configureHTTP(appCfg); err != nil {
+		return err
+	}
+
 	cfg := &serve.Config{
-		Host:   serveHost,
-		Port:   servePort,
-		WebDir: serveWebDir,
+		Host:      serveHost,
+		Port:      servePort,
+		WebDir:    serveWebDir,
+		AppConfig: appCfg,
 	}
 
 	// Auto-detect web directory if not specified
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/denysvitali/llm-usage/internal/credentials"
@@ -21,7 +22,7 @@ func init() {
 }
 
 func runSetupWizard(_ *cobra.Command, _ []string) error {
-	mgr := credentials.NewManager()
+	mgr := getCredentialsManager()
 	p := tea.NewProgram(setuptui.NewModel(mgr))
 	if _, err := p.Run(); err != nil {
 		return fmt.Errorf("TUI error: %w", err)
@@ -29,7 +30,16 @@ func runSetupWizard(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
-// getCredentialsManager returns a new credentials manager (used by subcommands)
+// getCredentialsManager returns a new credentials manager (used by subcommands), after
+// migrating credentials files in an older format
 func getCredentialsManager() *credentials.Manager {
-	return credentials.NewManager()
+	mgr := credentials.NewManager()
+	migrated, err := mgr.MigrateFiles()
+	for _, providerID := range migrated {
+		fmt.Fprintf(os.Stderr, "Migrated %s credentials to format version %d\n", providerID, credentials.FormatVersion)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to migrate credentials: %v\n", err)
+	}
+	return mgr
 }
@@ -1,27 +1,174 @@
 package cmd
 
 import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/config"
+	"github.com/denysvitali/llm-usage/internal/credentials"
 	"github.com/denysvitali/llm-usage/internal/setup"
 	"github.com/spf13/cobra"
 )
 
-var setupAddAccountName string
+var (
+	setupAddAccountName string
+	setupAddAPIKeyStdin bool
+	setupAddAPIKeyFile  string
+	setupAddCookieFile  string
+	setupAddGroupID     string
+	setupAddValidate    bool
+	setupAddForce       bool
+	setupAddJSON        bool
+	setupAddLabel       string
+	setupAddTags        []string
+)
 
 var setupAddCmd = &cobra.Command{
 	Use:   "add <provider>",
 	Short: "Add an account for a provider",
-	Long:  `Add a new account for a provider (claude, kimi, zai, or minimax).`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runSetupAdd,
+	Long: `Add a new account for a provider (claude, kimi, zai, minimax, openai, anthropic-api, or openrouter).
+
+Claude accounts are added by logging in with OAuth in the browser. The login is received
+on a local callback; when the browser runs on another machine, paste the URL it was
+redirected to instead.
+
+Other accounts are added without prompts when their credentials are given with
+--api-key-stdin or --api-key-file (the admin key for openai and anthropic-api), or with
+--cookie-file and --group-id for minimax. Existing accounts are only replaced with --force.`,
+	Example: `  llm-usage setup add kimi --account work --api-key-file ~/.secrets/kimi
+  pass show kimi | llm-usage setup add kimi --api-key-stdin --validate --json
+  llm-usage setup add minimax --cookie-file cookie.txt --group-id 1234 --force
+  llm-usage setup add openrouter --account acme --tag work --api-key-file key.txt`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runSetupAdd,
 }
 
 func init() {
 	setupAddCmd.Flags().StringVar(&setupAddAccountName, "account", "", "Account name (default: prompt interactively)")
+	setupAddCmd.Flags().BoolVar(&setupAddAPIKeyStdin, "api-key-stdin", false, "Read the API key from standard input")
+	setupAddCmd.Flags().StringVar(&setupAddAPIKeyFile, "api-key-file", "", "Read the API key from a file")
+	setupAddCmd.Flags().StringVar(&setupAddCookieFile, "cookie-file", "", "Read the MiniMax cookie from a file")
+	setupAddCmd.Flags().StringVar(&setupAddGroupID, "group-id", "", "MiniMax group ID")
+	setupAddCmd.Flags().BoolVar(&setupAddValidate, "validate", false, "Fetch usage with the credentials and only save them if that succeeds")
+	setupAddCmd.Flags().BoolVar(&setupAddForce, "force", false, "Replace an existing account")
+	setupAddCmd.Flags().StringVar(&setupAddLabel, "label", "", "Display name of the account")
+	setupAddCmd.Flags().StringSliceVar(&setupAddTags, "tag", nil, "Tag grouping the account, such as work (repeatable)")
+	setupAddCmd.Flags().BoolVar(&setupAddJSON, "json", false, "Print the result as JSON (requires credentials given as flags)")
+	setupAddCmd.MarkFlagsMutuallyExclusive("api-key-stdin", "api-key-file")
 	setupCmd.AddCommand(setupAddCmd)
 }
 
 func runSetupAdd(_ *cobra.Command, args []string) error {
 	providerID := args[0]
 	mgr := getCredentialsManager()
-	return setup.AddAccount(mgr, providerID, setupAddAccountName)
+	tags, err := credentials.NormalizeTags(setupAddTags)
+	if err != nil {
+		return err
+	}
+	opts := setup.AddOptions{Force: setupAddForce, Validate: setupAddValidate, Label: setupAddLabel, Tags: tags}
+
+	if setupAddValidate {
+		cfg, err := config.Load()
+		if err == nil {
+			err = configureHTTP(cfg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	scripted := setupAddAPIKeyStdin || setupAddAPIKeyFile != "" || setupAddCookieFile != "" || setupAddGroupID != ""
+	if !scripted {
+		if setupAddJSON {
+			return errors.New("--json requires the credentials to be given with --api-key-stdin, --api-key-file or --cookie-file")
+		}
+		return setup.AddAccount(mgr, providerID, setupAddAccountName, opts)
+	}
+
+	creds, err := readSetupAddCredentials()
+	if err == nil {
+		var result *setup.Result
+		if result, err = setup.SaveAccount(mgr, providerID, setupAddAccountName, creds, opts); err == nil {
+			return printSetupAddResult(result)
+		}
+	}
+
+	if errors.Is(err, setup.ErrAccountExists) {
+		err = fmt.Errorf("%w; use --force to replace it", err)
+	}
+	if setupAddJSON {
+		_ = json.NewEncoder(os.Stdout).Encode(map[string]string{
+			"provider": providerID,
+			"account":  cmp.Or(setupAddAccountName, "default"),
+			"error":    err.Error(),
+		})
+	}
+	return err
+}
+
+// readSetupAddCredentials reads the credentials given with the setup add flags
+func readSetupAddCredentials() (setup.Credentials, error) {
+	creds := setup.Credentials{GroupID: setupAddGroupID}
+
+	var err error
+	switch {
+	case setupAddAPIKeyStdin:
+		creds.APIKey, err = readSecret("standard input", os.Stdin)
+	case setupAddAPIKeyFile != "":
+		creds.APIKey, err = readSecretFile(setupAddAPIKeyFile)
+	}
+	if err != nil {
+		return creds, err
+	}
+
+	if setupAddCookieFile != "" {
+		creds.Cookie, err = readSecretFile(setupAddCookieFile)
+	}
+	return creds, err
+}
+
+// readSecretFile reads a secret from a file, ignoring surrounding whitespace
+func readSecretFile(path string) (string, error) {
+	f, err := os.Open(path) //nolint:gosec // The user chooses the file
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	return readSecret(path, f)
+}
+
+// readSecret reads a secret, ignoring surrounding whitespace
+func readSecret(name string, r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("%s is empty", name)
+	}
+	return secret, nil
+}
+
+// printSetupAddResult reports an account added by setup add
+func printSetupAddResult(result *setup.Result) error {
+	if setupAddJSON {
+		return json.NewEncoder(os.Stdout).Encode(result)
+	}
+
+	verb := "added"
+	if result.Replaced {
+		verb = "replaced"
+	}
+	if result.Validated {
+		verb = "validated and " + verb
+	}
+	fmt.Printf("Successfully %s %s account '%s'\n", verb, result.Provider, result.Account)
+	return nil
 }
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/denysvitali/llm-usage/internal/setup"
+	"github.com/spf13/cobra"
+)
+
+var setupDefaultCmd = &cobra.Command{
+	Use:   "default <provider> <account>",
+	Short: "Set the default account of a provider",
+	Long: `Set the account a provider uses when no account is named, as with --account.
+
+Without a default, the account named "default" is used, then the first account by name.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(_ *cobra.Command, args []string) error {
+		return setup.SetDefaultAccount(getCredentialsManager(), args[0], args[1])
+	},
+}
+
+func init() {
+	setupCmd.AddCommand(setupDefaultCmd)
+}
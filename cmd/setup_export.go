@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/denysvitali/llm-usage/internal/setup"
+	"github.com/spf13/cobra"
+)
+
+var setupExportOutput string
+
+var setupExportCmd = &cobra.Command{
+	Use:   "export [provider[/account]...]",
+	Short: "Export accounts to an encrypted bundle",
+	Long: `Export accounts with their secrets to a single bundle encrypted with a passphrase,
+to move them to another machine with 'setup import'.
+
+Without arguments, all accounts in the credentials files are exported. Secrets kept in
+secret stores are read and included. The passphrase is read from ` + setup.BundlePassphraseEnv + `
+or prompted for.`,
+	Example: `  llm-usage setup export -o accounts.age
+  llm-usage setup export claude kimi/work -o work.age`,
+	SilenceUsage: true,
+	RunE: func(_ *cobra.Command, args []string) error {
+		return setup.Export(getCredentialsManager(), args, setupExportOutput, os.Stdout)
+	},
+}
+
+func init() {
+	setupExportCmd.Flags().StringVarP(&setupExportOutput, "output", "o", "", "Write the bundle to a file instead of standard output")
+	setupCmd.AddCommand(setupExportCmd)
+}
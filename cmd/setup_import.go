@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/setup"
+	"github.com/spf13/cobra"
+)
+
+var (
+	setupImportConflict    string
+	setupImportSecretStore string
+	setupImportJSON        bool
+)
+
+var setupImportCmd = &cobra.Command{
+	Use:   "import <bundle|-> [provider[/account]...]",
+	Short: "Import accounts from an encrypted bundle",
+	Long: `Import accounts from a bundle written by 'setup export', merging them into the
+credentials files. Selectors limit the import to some providers or accounts.
+
+Accounts that already exist are handled with --on-conflict:
+  skip       keep the existing account (default)
+  overwrite  replace it, keeping its secret store
+  rename     import under a new name, such as work-2
+
+The passphrase is read from ` + setup.BundlePassphraseEnv + ` or prompted for.`,
+	Example: `  llm-usage setup import accounts.age
+  llm-usage setup import accounts.age claude --on-conflict rename
+  ssh old-host llm-usage setup export | llm-usage setup import - --secret-store pass`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runSetupImport,
+}
+
+func init() {
+	setupImportCmd.Flags().StringVar(&setupImportConflict, "on-conflict", credentials.ConflictSkip,
+		"How to handle existing accounts ("+strings.Join(credentials.ConflictPolicies, ", ")+")")
+	setupImportCmd.Flags().StringVar(&setupImportSecretStore, "secret-store", "",
+		"Keep the imported secrets in a secret store ("+strings.Join(credentials.SecretStores, ", ")+")")
+	setupImportCmd.Flags().BoolVar(&setupImportJSON, "json", false, "Print the imported accounts as JSON")
+	setupCmd.AddCommand(setupImportCmd)
+}
+
+func runSetupImport(_ *cobra.Command, args []string) error {
+	var data []byte
+	var err error
+	if args[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	imported, err := setup.Import(getCredentialsManager(), data, credentials.ImportOptions{
+		Selectors:   args[1:],
+		Conflict:    strings.ToLower(setupImportConflict),
+		SecretStore: strings.ToLower(setupImportSecretStore),
+	})
+	if err != nil && len(imported) == 0 {
+		return err
+	}
+	if setupImportJSON {
+		if imported == nil {
+			imported = []credentials.ImportedAccount{}
+		}
+		_ = json.NewEncoder(os.Stdout).Encode(imported)
+	} else {
+		setup.PrintImported(imported)
+	}
+	return err
+}
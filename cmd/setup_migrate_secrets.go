@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/setup"
+	"github.com/spf13/cobra"
+)
+
+var setupMigrateSecretsCmd = &cobra.Command{
+	Use:   "migrate-secrets <store> [provider] [account]",
+	Short: "Move account secrets to another secret store",
+	Long: `Move the secrets (tokens, API keys and cookies) of accounts to another secret store.
+Other account settings stay in the credentials files.
+
+Stores:
+  file            plain text in the provider's credentials file (default)
+  age             secrets.age in the config directory, encrypted with a passphrase
+                  (` + credentials.AgePassphraseEnv + ` or prompted) or the identities
+                  in ` + credentials.AgeIdentityEnv + `
+  pass            the standard Unix password manager, under llm-usage/<provider>/<account>
+  secret-service  the freedesktop Secret Service (GNOME Keyring, KWallet, KeePassXC)
+
+Without a provider, all accounts of all providers are moved.`,
+	Example: `  llm-usage setup migrate-secrets pass
+  llm-usage setup migrate-secrets secret-service claude work`,
+	Args:      cobra.RangeArgs(1, 3),
+	ValidArgs: credentials.SecretStores,
+	RunE:      runSetupMigrateSecrets,
+}
+
+func init() {
+	setupCmd.AddCommand(setupMigrateSecretsCmd)
+}
+
+func runSetupMigrateSecrets(_ *cobra.Command, args []string) error {
+	storeName := strings.ToLower(args[0])
+	providerID := ""
+	if len(args) > 1 {
+		providerID = args[1]
+	}
+	accountName := ""
+	if len(args) > 2 {
+		accountName = args[2]
+	}
+
+	mgr := getCredentialsManager()
+	return setup.MigrateSecrets(mgr, storeName, providerID, accountName)
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/setup"
+	"github.com/spf13/cobra"
+)
+
+var setupTagCmd = &cobra.Command{
+	Use:   "tag <provider> <account> [tag...]",
+	Short: "Set the tags of an account",
+	Long: `Replace the tags of an account with the given ones; without tags, remove them.
+
+Tags group accounts across providers, for example work and personal ones. Show only the
+accounts with a tag with 'llm-usage --tag work', or /api/v1/usage?tag=work with serve.`,
+	Example: `  llm-usage setup tag openai acme work team-a
+  llm-usage setup tag kimi default`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(_ *cobra.Command, args []string) error {
+		tags, err := credentials.NormalizeTags(args[2:])
+		if err != nil {
+			return err
+		}
+		return setup.SetAccountTags(getCredentialsManager(), args[0], args[1], tags)
+	},
+}
+
+func init() {
+	setupCmd.AddCommand(setupTagCmd)
+}
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/adrg/xdg"
+
+	"github.com/denysvitali/llm-usage/internal/fsutil"
 )
 
 // Manager handles file-based caching with TTL support.
@@ -47,7 +49,8 @@ func (m *Manager) Get(key string, target any) (bool, error) {
 
 	var entry Entry
 	if err := json.Unmarshal(data, &entry); err != nil {
-		// Invalid cache file, treat as miss (not an error)
+		// Invalid cache file, such as one truncated by a crash: remove it and treat as miss
+		_ = os.Remove(path)
 		return false, nil //nolint:nilerr // intentionally treat corrupt cache as miss
 	}
 
@@ -66,7 +69,8 @@ func (m *Manager) Get(key string, target any) (bool, error) {
 	return true, nil
 }
 
-// Set stores a value in the cache with the given TTL.
+// Set stores a value in the cache with the given TTL. The cache file is replaced
+// atomically, so concurrent readers never see a partial entry.
 func (m *Manager) Set(key string, data any, ttl time.Duration) error {
 	if err := m.ensureCacheDir(); err != nil {
 		return err
@@ -90,7 +94,7 @@ func (m *Manager) Set(key string, data any, ttl time.Duration) error {
 	}
 
 	path := m.keyPath(key)
-	if err := os.WriteFile(path, entryData, 0600); err != nil {
+	if err := fsutil.WriteFile(path, entryData, 0600); err != nil {
 		return fmt.Errorf("failed to write cache file: %w", err)
 	}
 
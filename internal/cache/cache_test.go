@@ -83,6 +83,28 @@ func TestManager_Expiry(t *testing.T) {
 	}
 }
 
+func TestManager_CorruptEntry(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := &Manager{cacheDir: tmpDir}
+
+	path := filepath.Join(tmpDir, "corrupt.json")
+	if err := os.WriteFile(path, []byte(`{"data":{"key"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var retrieved map[string]string
+	found, err := m.Get("corrupt", &retrieved)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if found {
+		t.Error("Expected cache miss for a corrupt entry, got hit")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Corrupt cache file should be removed")
+	}
+}
+
 func TestHashKey(t *testing.T) {
 	key1 := HashKey("prefix", "value1")
 	key2 := HashKey("prefix", "value2")
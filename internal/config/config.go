@@ -0,0 +1,358 @@
+// Package config loads the optional llm-usage configuration file.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/denysvitali/llm-usage/internal/httpclient"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	// FileName is the name of the configuration file inside the config directory
+	FileName = "config.yaml"
+
+	// TypeGeneric identifies a declarative HTTP/JSON provider
+	TypeGeneric = "generic"
+
+	// TypeExec identifies a provider implemented by an external command
+	TypeExec = "exec"
+
+	// CredentialsEnv passes credentials to exec providers as environment variables
+	CredentialsEnv = "env"
+
+	// CredentialsStdin passes credentials to exec providers as JSON on stdin
+	CredentialsStdin = "stdin"
+)
+
+// builtinProviders lists provider IDs implemented in Go, which configured
+// providers may not shadow
+var builtinProviders = map[string]bool{
+	"claude":        true,
+	"kimi":          true,
+	"zai":           true,
+	"minimax":       true,
+	"openai":        true,
+	"anthropic-api": true,
+	"openrouter":    true,
+}
+
+// endpointProviders lists the built-in providers whose API base URL can be overridden
+var endpointProviders = map[string]bool{
+	"claude":        true,
+	"kimi":          true,
+	"minimax":       true,
+	"openai":        true,
+	"anthropic-api": true,
+	"openrouter":    true,
+}
+
+// Config represents the contents of $XDG_CONFIG_HOME/llm-usage/config.yaml
+type Config struct {
+	// Providers defined in configuration rather than code
+	Providers []ProviderConfig `yaml:"providers"`
+
+	// HTTP client settings shared by all providers
+	HTTP HTTPConfig `yaml:"http"`
+
+	// Endpoints overrides the API base URL of built-in providers, keyed by provider ID.
+	// A base URL set on an account takes precedence.
+	Endpoints map[string]string `yaml:"endpoints"`
+
+	// ClaudeOAuth overrides the OAuth server used by `setup add claude`
+	ClaudeOAuth OAuthConfig `yaml:"claude_oauth"`
+
+	// Output sets the defaults of the pretty and table output formats
+	Output OutputConfig `yaml:"output"`
+}
+
+// Color modes of the pretty and table output formats
+const (
+	ColorAuto   = "auto"   // Color terminals, unless NO_COLOR is set
+	ColorAlways = "always" // Color any output
+	ColorNever  = "never"  // Plain text
+)
+
+// OutputConfig sets the defaults of the pretty and table output formats
+type OutputConfig struct {
+	Color  string           `yaml:"color"`  // ColorAuto (default), ColorAlways or ColorNever
+	Theme  string           `yaml:"theme"`  // Name of a built-in or user theme
+	Themes map[string]Theme `yaml:"themes"` // User themes by name
+}
+
+// Theme holds the colors of the pretty and table output, as ANSI 256 color numbers such as
+// "70" or hex colors such as "#5faf00". Empty colors are taken from the default theme.
+type Theme struct {
+	Normal   string `yaml:"normal"`   // Usage below the warning threshold
+	Warning  string `yaml:"warning"`  // Usage from the warning threshold, 75% by default
+	Critical string `yaml:"critical"` // Usage from the critical threshold, 90% by default, and errors
+	Title    string `yaml:"title"`    // Headings
+	Accent   string `yaml:"accent"`   // Names within sections, such as subscription features
+	Dim      string `yaml:"dim"`      // Secondary details
+}
+
+// OAuthConfig overrides an OAuth authorization server, for example with a local stand-in.
+// Empty fields keep the provider's defaults.
+type OAuthConfig struct {
+	ClientID     string `yaml:"client_id"`
+	AuthorizeURL string `yaml:"authorize_url"`
+	TokenURL     string `yaml:"token_url"`
+}
+
+// HTTPConfig configures the HTTP client shared by all providers.
+// Zero values select the built-in defaults.
+type HTTPConfig struct {
+	Proxy        string        `yaml:"proxy"`          // Proxy URL; defaults to HTTP_PROXY/HTTPS_PROXY
+	Timeout      time.Duration `yaml:"timeout"`        // Timeout of a single attempt
+	MaxRetries   *int          `yaml:"max_retries"`    // Retries for 429 and 5xx responses; 0 disables retries
+	MaxPerHost   int           `yaml:"max_per_host"`   // Concurrent requests per host
+	MaxBodyBytes int64         `yaml:"max_body_bytes"` // Response body size limit
+}
+
+// ProviderConfig describes a provider defined in the configuration file
+type ProviderConfig struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+
+	// Generic provider settings (type: generic)
+	Request RequestConfig     `yaml:"request"`
+	Windows []WindowConfig    `yaml:"windows"`
+	Extra   map[string]string `yaml:"extra"` // Extra key -> response expression
+
+	// Probe, if set, is a cheap request whose rate-limit response headers are
+	// reported as additional windows (type: generic)
+	Probe *RequestConfig `yaml:"probe"`
+
+	// External command settings (type: exec)
+	Exec ExecConfig `yaml:"exec"`
+}
+
+// RequestConfig describes the HTTP request issued by a generic provider.
+// URL, header values and body are Go templates rendered with the account's credentials.
+type RequestConfig struct {
+	URL     string            `yaml:"url"`
+	Method  string            `yaml:"method"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+	Timeout time.Duration     `yaml:"timeout"`
+}
+
+// ExecConfig describes the external command run by an exec provider.
+// The command must print a JSON usage document on stdout.
+type ExecConfig struct {
+	Command     string            `yaml:"command"`
+	Args        []string          `yaml:"args"`
+	Env         map[string]string `yaml:"env"`
+	Timeout     time.Duration     `yaml:"timeout"`
+	Credentials string            `yaml:"credentials"` // env (default) or stdin
+}
+
+// WindowConfig maps parts of a JSON response to a provider.UsageWindow.
+// All fields except Label and ResetFormat are jq-style path expressions such as
+// ".data.quota[0].used".
+type WindowConfig struct {
+	// Each, if set, selects an array; one window is produced per element and
+	// the remaining expressions are evaluated relative to that element
+	Each string `yaml:"each"`
+
+	Label       string  `yaml:"label"`      // Literal label
+	LabelExpr   string  `yaml:"label_expr"` // Label read from the response
+	Used        string  `yaml:"used"`
+	Limit       string  `yaml:"limit"`
+	Remaining   string  `yaml:"remaining"`
+	Utilization string  `yaml:"utilization"`
+	Scale       float64 `yaml:"scale"` // Multiplier applied to utilization (e.g. 100 for 0-1 ratios)
+	ResetsAt    string  `yaml:"resets_at"`
+	ResetFormat string  `yaml:"reset_format"` // rfc3339 (default), unix, unix_ms or a Go time layout
+}
+
+// Load reads the configuration file from the default location.
+// A missing file is not an error and yields an empty configuration.
+func Load() (*Config, error) {
+	return LoadFromPath(DefaultPath())
+}
+
+// DefaultPath returns the default configuration file path
+func DefaultPath() string {
+	return filepath.Join(xdg.ConfigHome, "llm-usage", FileName)
+}
+
+// LoadFromPath reads the configuration from a specific file path
+func LoadFromPath(path string) (*Config, error) {
+	data, err := os.ReadFile(filepath.Clean(path)) //nolint:gosec // path is the user's config file
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// Validate checks the configuration for structural errors
+func (c *Config) Validate() error {
+	if c.HTTP.Timeout < 0 || c.HTTP.MaxPerHost < 0 || c.HTTP.MaxBodyBytes < 0 {
+		return fmt.Errorf("http: timeout, max_per_host and max_body_bytes must not be negative")
+	}
+	if c.HTTP.MaxRetries != nil && *c.HTTP.MaxRetries < 0 {
+		return fmt.Errorf("http: max_retries must not be negative")
+	}
+
+	switch c.Output.Color {
+	case "", ColorAuto, ColorAlways, ColorNever:
+	default:
+		return fmt.Errorf("output: color must be %s, %s or %s, got %q", ColorAuto, ColorAlways, ColorNever, c.Output.Color)
+	}
+
+	for id, baseURL := range c.Endpoints {
+		if !endpointProviders[id] {
+			return fmt.Errorf("endpoints: %q is not a built-in provider with an API endpoint", id)
+		}
+		if err := httpclient.ValidateBaseURL(baseURL); err != nil {
+			return fmt.Errorf("endpoints: %s: %w", id, err)
+		}
+	}
+
+	for name, u := range map[string]string{"authorize_url": c.ClaudeOAuth.AuthorizeURL, "token_url": c.ClaudeOAuth.TokenURL} {
+		if u == "" {
+			continue
+		}
+		if err := httpclient.ValidateBaseURL(u); err != nil {
+			return fmt.Errorf("claude_oauth: %s: %w", name, err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := range c.Providers {
+		p := &c.Providers[i]
+		if p.ID == "" {
+			return fmt.Errorf("providers[%d]: id is required", i)
+		}
+		if builtinProviders[p.ID] {
+			return fmt.Errorf("provider %q: id conflicts with a built-in provider", p.ID)
+		}
+		if seen[p.ID] {
+			return fmt.Errorf("provider %q: duplicate id", p.ID)
+		}
+		seen[p.ID] = true
+
+		switch p.Type {
+		case TypeGeneric:
+			if err := p.validateGeneric(); err != nil {
+				return fmt.Errorf("provider %q: %w", p.ID, err)
+			}
+		case TypeExec:
+			if err := p.validateExec(); err != nil {
+				return fmt.Errorf("provider %q: %w", p.ID, err)
+			}
+		case "":
+			return fmt.Errorf("provider %q: type is required", p.ID)
+		default:
+			return fmt.Errorf("provider %q: unknown type %q", p.ID, p.Type)
+		}
+	}
+	return nil
+}
+
+// validateGeneric checks the settings of a generic provider
+func (p *ProviderConfig) validateGeneric() error {
+	if p.Probe != nil && p.Probe.URL == "" {
+		return fmt.Errorf("probe.url is required")
+	}
+	if p.Request.URL == "" {
+		// A provider may rely on rate-limit headers alone
+		if p.Probe != nil && len(p.Windows) == 0 {
+			return nil
+		}
+		return fmt.Errorf("request.url is required")
+	}
+	if len(p.Windows) == 0 {
+		return fmt.Errorf("at least one window is required")
+	}
+	for i, w := range p.Windows {
+		if w.Label == "" && w.LabelExpr == "" {
+			return fmt.Errorf("windows[%d]: label or label_expr is required", i)
+		}
+		if w.Utilization == "" && (w.Limit == "" || (w.Used == "" && w.Remaining == "")) {
+			return fmt.Errorf("windows[%d]: utilization, or limit with used/remaining, is required", i)
+		}
+	}
+	return nil
+}
+
+// validateExec checks the settings of an exec provider
+func (p *ProviderConfig) validateExec() error {
+	if p.Exec.Command == "" {
+		return fmt.Errorf("exec.command is required")
+	}
+	switch p.Exec.Credentials {
+	case "", CredentialsEnv, CredentialsStdin:
+	default:
+		return fmt.Errorf("exec.credentials must be %q or %q", CredentialsEnv, CredentialsStdin)
+	}
+	if p.Exec.Timeout < 0 {
+		return fmt.Errorf("exec.timeout must not be negative")
+	}
+	return nil
+}
+
+// IsBuiltin reports whether id is a provider implemented in Go
+func IsBuiltin(id string) bool {
+	return builtinProviders[id]
+}
+
+// Provider returns the configured provider with the given ID, or nil
+func (c *Config) Provider(id string) *ProviderConfig {
+	if c == nil {
+		return nil
+	}
+	for i := range c.Providers {
+		if c.Providers[i].ID == id {
+			return &c.Providers[i]
+		}
+	}
+	return nil
+}
+
+// BaseURL returns the base URL override for a built-in provider, or "" if there is none
+func (c *Config) BaseURL(providerID string) string {
+	if c == nil {
+		return ""
+	}
+	return c.Endpoints[providerID]
+}
+
+// ProviderIDs returns the IDs of all configured providers
+func (c *Config) ProviderIDs() []string {
+	if c == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(c.Providers))
+	for _, p := range c.Providers {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+// DisplayName returns the provider's configured name, falling back to its ID
+func (p *ProviderConfig) DisplayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.ID
+}
@@ -0,0 +1,355 @@
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// FormatVersion is the version of the credentials file format written by SaveProvider.
+// Version 2 files keep every account under "accounts". Unversioned files, including legacy
+// single-account files, are upgraded in memory when they are read, and rewritten by
+// MigrateFiles or the next save.
+const FormatVersion = 2
+
+// DefaultAccount is the name of the account of migrated legacy single-account files
+const DefaultAccount = "default"
+
+// AccountMeta holds the metadata kept for the accounts of every provider
+type AccountMeta struct {
+	Label         string     `json:"label,omitempty"` // Display name
+	Tags          []string   `json:"tags,omitempty"`
+	CreatedAt     *time.Time `json:"createdAt,omitempty"`
+	LastValidated *time.Time `json:"lastValidated,omitempty"` // When setup add --validate last accepted the credentials
+}
+
+// Meta returns the account's metadata
+func (a *AccountMeta) Meta() *AccountMeta {
+	return a
+}
+
+// HasAnyTag reports whether the account has one of the tags. Every account matches no tags.
+func (a *AccountMeta) HasAnyTag(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	return a != nil && slices.ContainsFunc(a.Tags, func(tag string) bool {
+		return slices.Contains(tags, tag)
+	})
+}
+
+// NormalizeTags checks that tags are single words without commas, as used to group accounts
+// such as "work" or "team-a", and removes duplicates. Comma-separated lists are split.
+func NormalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	for _, list := range tags {
+		for _, tag := range strings.Split(list, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "" || strings.ContainsFunc(tag, unicode.IsSpace) || !validName(tag) {
+				return nil, fmt.Errorf("invalid tag %q", tag)
+			}
+			if !slices.Contains(normalized, tag) {
+				normalized = append(normalized, tag)
+			}
+		}
+	}
+	return normalized, nil
+}
+
+// Account is implemented by the account types of all providers, which embed AccountMeta
+type Account interface {
+	Meta() *AccountMeta
+}
+
+// AccountSet holds the accounts of a provider's credentials file
+type AccountSet[A any] struct {
+	Version  int           `json:"version,omitempty"`
+	Default  string        `json:"default,omitempty"` // Account used when none is named
+	Accounts map[string]*A `json:"accounts,omitempty"`
+}
+
+// DefaultAccountName returns the account used when none is named: the file's default
+// account, then the account named "default", then the first account by name
+func (s *AccountSet[A]) DefaultAccountName() string {
+	if s.Accounts[s.Default] != nil {
+		return s.Default
+	}
+	if s.Accounts[DefaultAccount] != nil {
+		return DefaultAccount
+	}
+	if names := s.ListAccounts(); len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
+
+// GetAccount returns the named account, or the default account if accountName is empty.
+// It returns nil for unknown accounts.
+func (s *AccountSet[A]) GetAccount(accountName string) *A {
+	if accountName == "" {
+		accountName = s.DefaultAccountName()
+	}
+	return s.Accounts[accountName]
+}
+
+// ListAccounts returns the account names in sorted order
+func (s *AccountSet[A]) ListAccounts() []string {
+	return sortedNames(s.Accounts)
+}
+
+// Meta returns the metadata of an account, or nil for unknown accounts
+func (s *AccountSet[A]) Meta(accountName string) *AccountMeta {
+	acc := s.Accounts[accountName]
+	if acc == nil {
+		return nil
+	}
+	return any(acc).(Account).Meta()
+}
+
+// validate checks every account, in sorted order, with a provider's check
+func (s *AccountSet[A]) validate(check func(acc *A) error) error {
+	if len(s.Accounts) == 0 {
+		return errors.New("no accounts found")
+	}
+	for _, name := range s.ListAccounts() {
+		acc := s.Accounts[name]
+		if acc == nil {
+			return fmt.Errorf("account %q is empty", name)
+		}
+		if err := check(acc); err != nil {
+			return fmt.Errorf("account %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// fileVersion returns the format version of a decoded credentials file
+func fileVersion(file map[string]json.RawMessage) int {
+	var version int
+	if raw, ok := file["version"]; ok {
+		_ = json.Unmarshal(raw, &version)
+	}
+	return version
+}
+
+// fileDefault returns the default account named in a decoded credentials file
+func fileDefault(file map[string]json.RawMessage) string {
+	var name string
+	if raw, ok := file["default"]; ok {
+		_ = json.Unmarshal(raw, &name)
+	}
+	return name
+}
+
+// upgradeFile converts a credentials file to the current format, turning a legacy
+// single-account file into its "default" account. It reports whether the file changed.
+func upgradeFile(data []byte) ([]byte, bool, error) {
+	file, accounts, err := splitAccounts(data)
+	if err != nil {
+		return nil, false, err
+	}
+	switch version := fileVersion(file); {
+	case version == FormatVersion:
+		return data, false, nil
+	case version > FormatVersion:
+		return nil, false, fmt.Errorf("credentials file version %d is newer than supported (%d); upgrade llm-usage", version, FormatVersion)
+	}
+
+	if _, ok := file["accounts"]; !ok && len(file) > 0 {
+		file, accounts = legacyRawAccount(file)
+	}
+	upgraded, err := marshalAccounts(file, accounts)
+	return upgraded, err == nil, err
+}
+
+// marshalAccounts encodes a credentials file with the given accounts in the current format
+func marshalAccounts(file map[string]json.RawMessage, accounts rawAccounts) ([]byte, error) {
+	var err error
+	if file["accounts"], err = json.Marshal(accounts); err != nil {
+		return nil, err
+	}
+	file["version"] = json.RawMessage(strconv.Itoa(FormatVersion))
+	return json.MarshalIndent(file, "", "  ")
+}
+
+// NeedsMigration reports whether a provider's credentials file uses an older format,
+// which MigrateFiles rewrites
+func (m *Manager) NeedsMigration(providerID string) (bool, error) {
+	data, err := m.readProviderFile(providerID)
+	if err != nil {
+		return false, err
+	}
+	_, changed, err := upgradeFile(data)
+	return changed, err
+}
+
+// MigrateFiles rewrites the credentials files that use an older format, including legacy
+// single-account files, in the current format. It returns the IDs of the migrated providers.
+func (m *Manager) MigrateFiles() ([]string, error) {
+	entries, err := os.ReadDir(m.configDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config directory: %w", err)
+	}
+
+	var migrated []string
+	var errs []error
+	for _, entry := range entries {
+		providerID, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		err := m.Update(func() error {
+			data, err := m.readProviderFile(providerID)
+			if err != nil {
+				return err
+			}
+			upgraded, changed, err := upgradeFile(data)
+			if err != nil || !changed {
+				return err
+			}
+			if err := m.writeProviderFile(providerID, upgraded); err != nil {
+				return fmt.Errorf("failed to write credentials file: %w", err)
+			}
+			migrated = append(migrated, providerID)
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", providerID, err))
+		}
+	}
+	return migrated, errors.Join(errs...)
+}
+
+// saveAccounts saves a credentials file with the given accounts
+func (m *Manager) saveAccounts(providerID string, file map[string]json.RawMessage, accounts rawAccounts) error {
+	var err error
+	if file["accounts"], err = json.Marshal(accounts); err != nil {
+		return err
+	}
+	return m.SaveProvider(providerID, file)
+}
+
+// RemoveAccount removes an account from a provider's credentials file, deleting the file
+// with its last account
+func (m *Manager) RemoveAccount(providerID, accountName string) error {
+	return m.Update(func() error {
+		file, accounts, err := m.readAccounts(providerID)
+		if err != nil {
+			return err
+		}
+		if accounts[accountName] == nil {
+			return fmt.Errorf("account '%s' not found", accountName)
+		}
+
+		delete(accounts, accountName)
+		if len(accounts) == 0 {
+			return m.DeleteProvider(providerID)
+		}
+		if fileDefault(file) == accountName {
+			delete(file, "default")
+		}
+		return m.saveAccounts(providerID, file, accounts)
+	})
+}
+
+// RenameAccount renames an account in a provider's credentials file. Its secrets move to
+// the new name in their store, and the file's default account follows the rename.
+func (m *Manager) RenameAccount(providerID, oldName, newName string) error {
+	if err := ValidateAccountName(newName); err != nil {
+		return err
+	}
+	return m.Update(func() error {
+		file, accounts, err := m.readAccounts(providerID)
+		if err != nil {
+			return err
+		}
+		if accounts[oldName] == nil {
+			return fmt.Errorf("account '%s' not found", oldName)
+		}
+		if accounts[newName] != nil {
+			return fmt.Errorf("account '%s' already exists", newName)
+		}
+
+		accounts[newName] = accounts[oldName]
+		delete(accounts, oldName)
+		if fileDefault(file) == oldName {
+			file["default"], _ = json.Marshal(newName)
+		}
+		return m.saveAccounts(providerID, file, accounts)
+	})
+}
+
+// SetDefaultAccount sets the account a provider uses when none is named
+func (m *Manager) SetDefaultAccount(providerID, accountName string) error {
+	return m.Update(func() error {
+		file, accounts, err := m.readAccounts(providerID)
+		if err != nil {
+			return err
+		}
+		if accounts[accountName] == nil {
+			return fmt.Errorf("account '%s' not found (available: %s)", accountName, strings.Join(sortedNames(accounts), ", "))
+		}
+		file["default"], _ = json.Marshal(accountName)
+		return m.saveAccounts(providerID, file, accounts)
+	})
+}
+
+// SetAccountTags replaces the tags of an account; no tags removes them
+func (m *Manager) SetAccountTags(providerID, accountName string, tags []string) error {
+	tags, err := NormalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	return m.Update(func() error {
+		file, accounts, err := m.readAccounts(providerID)
+		if err != nil {
+			return err
+		}
+		acc := accounts[accountName]
+		if acc == nil {
+			return fmt.Errorf("account '%s' not found", accountName)
+		}
+		if len(tags) == 0 {
+			delete(acc, "tags")
+		} else {
+			acc["tags"], _ = json.Marshal(tags)
+		}
+		return m.saveAccounts(providerID, file, accounts)
+	})
+}
+
+// stampVersion sets the format version of an encoded credentials file
+func stampVersion(data []byte) ([]byte, error) {
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	file["version"] = json.RawMessage(strconv.Itoa(FormatVersion))
+	return json.MarshalIndent(file, "", "  ")
+}
+
+// ReadAccountsMeta reads the default account and the metadata of the accounts in a provider's
+// credentials file, without reading secrets from their stores
+func (m *Manager) ReadAccountsMeta(providerID string) (*AccountSet[AccountMeta], error) {
+	data, err := m.readProviderFile(providerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	if data, _, err = upgradeFile(data); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	var set AccountSet[AccountMeta]
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	return &set, nil
+}
@@ -0,0 +1,191 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAccountSet_DefaultAccountName(t *testing.T) {
+	tests := []struct {
+		name       string
+		defaultAcc string
+		accounts   []string
+		want       string
+	}{
+		{"explicit default", "work", []string{"default", "personal", "work"}, "work"},
+		{"named default", "", []string{"work", "default"}, "default"},
+		{"first by name", "", []string{"work", "personal", "zeta"}, "personal"},
+		{"stale explicit default", "removed", []string{"work", "personal"}, "personal"},
+		{"no accounts", "", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := AccountSet[KimiAccount]{Default: tt.defaultAcc, Accounts: make(map[string]*KimiAccount)}
+			for _, name := range tt.accounts {
+				set.Accounts[name] = &KimiAccount{APIKey: "sk-" + name}
+			}
+			if got := set.DefaultAccountName(); got != tt.want {
+				t.Errorf("DefaultAccountName() = %q, want %q", got, tt.want)
+			}
+			// The default account is the same on every call
+			for range 10 {
+				if acc := set.GetAccount(""); tt.want != "" && (acc == nil || acc.APIKey != "sk-"+tt.want) {
+					t.Fatalf("GetAccount(\"\") = %+v", acc)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad_UpgradesLegacyFile(t *testing.T) {
+	m := newEnvManager(t)
+	// Claude CLI settings next to claudeAiOauth are not credentials
+	writeCredentials(t, m, "claude", `{"claudeAiOauth":{"accessToken":"sk-ant-oat","refreshToken":"sk-ant-ort","expiresAt":1700000000000,"scopes":["user:inference"]},"mcpOAuth":{"server|1":{"accessToken":"mcp"}}}`)
+	writeCredentials(t, m, "kimi", `{"accounts":{"work":{"apiKey":"sk-work","label":"Work","tags":["work"]}}}`)
+
+	creds, err := m.LoadClaude()
+	if err != nil {
+		t.Fatalf("LoadClaude() error = %v", err)
+	}
+	if acc := creds.GetAccount(""); acc == nil || acc.AccessToken != "sk-ant-oat" || acc.ExpiresAt != 1700000000000 {
+		t.Errorf("default account = %+v", acc)
+	}
+	// Reading leaves the files as they are
+	if _, err := m.LoadKimi(); err != nil {
+		t.Fatalf("LoadKimi() error = %v", err)
+	}
+	if file := readCredentials(t, m, "claude"); !strings.HasPrefix(file, `{"claudeAiOauth":`) {
+		t.Errorf("legacy file rewritten on read:\n%s", file)
+	}
+	if _, err := os.Stat(filepath.Join(m.ConfigDir(), ".lock")); !os.IsNotExist(err) {
+		t.Errorf("reading created a lock file: %v", err)
+	}
+
+	migrated, err := m.MigrateFiles()
+	if err != nil {
+		t.Fatalf("MigrateFiles() error = %v", err)
+	}
+	if !slices.Equal(migrated, []string{"claude", "kimi"}) {
+		t.Errorf("migrated = %v, want [claude kimi]", migrated)
+	}
+	file := readCredentials(t, m, "claude")
+	if strings.Contains(file, "claudeAiOauth") || strings.Contains(file, "mcp") || !strings.Contains(file, `"version": 2`) || !strings.Contains(file, `"default": {`) {
+		t.Errorf("legacy file not migrated:\n%s", file)
+	}
+	if migrated, _ := m.MigrateFiles(); len(migrated) != 0 {
+		t.Errorf("second MigrateFiles() migrated %v", migrated)
+	}
+
+	kimi, err := m.LoadKimi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta := kimi.Meta("work"); meta == nil || meta.Label != "Work" || !slices.Equal(meta.Tags, []string{"work"}) {
+		t.Errorf("work metadata = %+v", meta)
+	}
+
+	writeCredentials(t, m, "zai", `{"version":3,"accounts":{}}`)
+	if _, err := m.LoadZAi(); err == nil || !strings.Contains(err.Error(), "newer than supported") {
+		t.Errorf("LoadZAi() error = %v, want unsupported version", err)
+	}
+}
+
+func TestManager_RenameRemoveDefault(t *testing.T) {
+	m := newEnvManager(t)
+	writeCredentials(t, m, "openrouter", `{"apiKey":"sk-or-legacy"}`)
+
+	if err := m.RenameAccount("openrouter", "default", "personal"); err != nil {
+		t.Fatalf("RenameAccount() error = %v", err)
+	}
+	if err := m.RenameAccount("openrouter", "personal", "../work"); err == nil {
+		t.Error("RenameAccount() expected error for an invalid name")
+	}
+
+	creds, err := m.LoadOpenRouter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds.Accounts["work"] = &OpenRouterAccount{APIKey: "sk-or-work"}
+	if err := m.SaveProvider("openrouter", creds); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.SetDefaultAccount("openrouter", "missing"); err == nil {
+		t.Error("SetDefaultAccount() expected error for an unknown account")
+	}
+	if err := m.SetDefaultAccount("openrouter", "work"); err != nil {
+		t.Fatalf("SetDefaultAccount() error = %v", err)
+	}
+	if err := m.RenameAccount("openrouter", "work", "team"); err != nil {
+		t.Fatalf("RenameAccount() error = %v", err)
+	}
+
+	meta, err := m.ReadAccountsMeta("openrouter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Default != "team" || !slices.Equal(meta.ListAccounts(), []string{"personal", "team"}) {
+		t.Errorf("default = %q, accounts = %v", meta.Default, meta.ListAccounts())
+	}
+
+	if err := m.RemoveAccount("openrouter", "team"); err != nil {
+		t.Fatalf("RemoveAccount() error = %v", err)
+	}
+	if file := readCredentials(t, m, "openrouter"); strings.Contains(file, `"default"`) {
+		t.Errorf("default account not cleared:\n%s", file)
+	}
+	if err := m.RemoveAccount("openrouter", "personal"); err != nil {
+		t.Fatalf("RemoveAccount() error = %v", err)
+	}
+	if m.ProviderExists("openrouter") {
+		t.Error("credentials file kept after removing the last account")
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := NormalizeTags([]string{"work", "team-a, work", " personal "})
+	if err != nil {
+		t.Fatalf("NormalizeTags() error = %v", err)
+	}
+	if want := []string{"work", "team-a", "personal"}; !slices.Equal(got, want) {
+		t.Errorf("NormalizeTags() = %v, want %v", got, want)
+	}
+	for _, invalid := range []string{"", "two words", "a/b", "work,"} {
+		if _, err := NormalizeTags([]string{invalid}); err == nil {
+			t.Errorf("NormalizeTags(%q) expected error", invalid)
+		}
+	}
+}
+
+func TestManager_SetAccountTags(t *testing.T) {
+	m := newEnvManager(t)
+	writeCredentials(t, m, "kimi", `{"version":2,"accounts":{"work":{"apiKey":"sk-work"},"home":{"apiKey":"sk-home"}}}`)
+
+	if err := m.SetAccountTags("kimi", "work", []string{"work", "team-a"}); err != nil {
+		t.Fatalf("SetAccountTags() error = %v", err)
+	}
+	if err := m.SetAccountTags("kimi", "missing", []string{"work"}); err == nil {
+		t.Error("SetAccountTags() expected error for an unknown account")
+	}
+
+	meta, err := m.ReadAccountsMeta("kimi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags := meta.Meta("work").Tags; !slices.Equal(tags, []string{"work", "team-a"}) {
+		t.Errorf("work tags = %v", tags)
+	}
+	if !meta.Meta("work").HasAnyTag([]string{"personal", "team-a"}) || meta.Meta("home").HasAnyTag([]string{"work"}) {
+		t.Error("HasAnyTag() mismatch")
+	}
+
+	if err := m.SetAccountTags("kimi", "work", nil); err != nil {
+		t.Fatal(err)
+	}
+	if file := readCredentials(t, m, "kimi"); strings.Contains(file, "tags") {
+		t.Errorf("tags not removed:\n%s", file)
+	}
+}
@@ -0,0 +1,350 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"github.com/denysvitali/llm-usage/internal/fsutil"
+)
+
+// BundleVersion is the format version of export bundles
+const BundleVersion = 1
+
+// Bundle holds accounts exported from one machine to be imported on another. Accounts are
+// kept as in the multi-account credentials files, with their secrets and without secretStore.
+type Bundle struct {
+	Version   int                    `json:"version"`
+	Created   time.Time              `json:"created"`
+	Providers map[string]rawAccounts `json:"providers"`
+}
+
+// Conflict policies for imported accounts that already exist
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictRename    = "rename"
+)
+
+// ConflictPolicies lists the valid ImportOptions.Conflict values
+var ConflictPolicies = []string{ConflictSkip, ConflictOverwrite, ConflictRename}
+
+// Import actions reported for each account of a bundle
+const (
+	ImportAdded       = "added"
+	ImportOverwritten = "overwritten"
+	ImportRenamed     = "renamed"
+	ImportSkipped     = "skipped"
+)
+
+// ImportOptions control how a bundle is merged into the credentials files
+type ImportOptions struct {
+	// Selectors are provider IDs or provider/account pairs; none imports every account
+	Selectors []string
+	// Conflict is the policy for existing accounts: ConflictSkip (default), ConflictOverwrite
+	// or ConflictRename
+	Conflict string
+	// SecretStore keeps the secrets of imported accounts in a secret store. Empty keeps them
+	// in the credentials file, or in the store of the account they overwrite.
+	SecretStore string
+}
+
+// ImportedAccount reports what happened to one account of a bundle
+type ImportedAccount struct {
+	Provider string `json:"provider"`
+	Account  string `json:"account"`          // Name on this machine
+	Source   string `json:"source,omitempty"` // Name in the bundle, if renamed
+	Action   string `json:"action"`           // ImportAdded, ImportOverwritten, ImportRenamed or ImportSkipped
+}
+
+// selection matches accounts against provider and provider/account selectors
+type selection map[string][]string // Provider ID to account names; empty selects all accounts
+
+func parseSelectors(selectors []string) (selection, error) {
+	sel := make(selection)
+	for _, s := range selectors {
+		providerID, accountName, hasAccount := strings.Cut(s, "/")
+		if !validName(providerID) || (hasAccount && !validName(accountName)) {
+			return nil, fmt.Errorf("invalid selector %q: want provider or provider/account", s)
+		}
+		if !hasAccount {
+			sel[providerID] = nil
+		} else if accounts, ok := sel[providerID]; !ok || accounts != nil {
+			sel[providerID] = append(accounts, accountName)
+		}
+	}
+	return sel, nil
+}
+
+func (sel selection) match(providerID, accountName string) bool {
+	if len(sel) == 0 {
+		return true
+	}
+	accounts, ok := sel[providerID]
+	return ok && (accounts == nil || slices.Contains(accounts, accountName))
+}
+
+// validName reports whether a provider ID or account name is safe to use in file names
+// and secret references
+func validName(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\`) &&
+		!strings.ContainsFunc(s, func(r rune) bool { return r < ' ' || r == 0x7f })
+}
+
+// ValidateAccountName checks that an account name is safe to use in file names and secret
+// store paths, such as pass entries
+func ValidateAccountName(name string) error {
+	if !validName(name) {
+		return fmt.Errorf("invalid account name %q", name)
+	}
+	return nil
+}
+
+// Export collects accounts from the credentials files into a bundle. selectors are provider
+// IDs or provider/account pairs; none selects every account. Secrets are read from the
+// accounts' secret stores, and legacy single-account files are exported as "default".
+// Accounts defined only in the environment or the Claude CLI are not exported.
+func (m *Manager) Export(selectors []string) (*Bundle, error) {
+	sel, err := parseSelectors(selectors)
+	if err != nil {
+		return nil, err
+	}
+
+	var providerIDs []string
+	if len(sel) == 0 {
+		entries, err := os.ReadDir(m.configDir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read config directory: %w", err)
+		}
+		for _, entry := range entries {
+			if id, ok := strings.CutSuffix(entry.Name(), ".json"); ok && !entry.IsDir() {
+				providerIDs = append(providerIDs, id)
+			}
+		}
+	} else {
+		providerIDs = sortedNames(sel)
+	}
+
+	bundle := &Bundle{Version: BundleVersion, Created: time.Now().UTC(), Providers: make(map[string]rawAccounts)}
+	for _, providerID := range providerIDs {
+		_, accounts, err := m.readAccounts(providerID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", providerID, err)
+		}
+		for _, name := range sel[providerID] {
+			if accounts[name] == nil {
+				return nil, fmt.Errorf("%s: account '%s' not found", providerID, name)
+			}
+		}
+
+		for name, acc := range accounts {
+			if !sel.match(providerID, name) || len(acc) == 0 {
+				continue
+			}
+			delete(acc, "secretStore")
+			if bundle.Providers[providerID] == nil {
+				bundle.Providers[providerID] = make(rawAccounts)
+			}
+			bundle.Providers[providerID][name] = acc
+		}
+	}
+
+	if len(bundle.Providers) == 0 {
+		return nil, errors.New("no accounts to export")
+	}
+	return bundle, nil
+}
+
+// Import merges the accounts of a bundle into the credentials files, handling accounts that
+// already exist according to opts.Conflict. Legacy single-account files are converted to
+// the multi-account format when accounts are added to them.
+func (m *Manager) Import(bundle *Bundle, opts ImportOptions) ([]ImportedAccount, error) {
+	if bundle.Version != BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+	conflict := opts.Conflict
+	if conflict == "" {
+		conflict = ConflictSkip
+	}
+	if !slices.Contains(ConflictPolicies, conflict) {
+		return nil, fmt.Errorf("unknown conflict policy %q (valid: %s)", conflict, strings.Join(ConflictPolicies, ", "))
+	}
+	if opts.SecretStore != "" && !slices.Contains(SecretStores, opts.SecretStore) {
+		return nil, fmt.Errorf("unknown secret store %q (valid: %s)", opts.SecretStore, strings.Join(SecretStores, ", "))
+	}
+	sel, err := parseSelectors(opts.Selectors)
+	if err != nil {
+		return nil, err
+	}
+
+	var imported []ImportedAccount
+	err = m.Update(func() error {
+		imported, err = m.importBundle(bundle, sel, conflict, opts.SecretStore)
+		return err
+	})
+	return imported, err
+}
+
+// importBundle does the work of Import while the credentials are locked
+func (m *Manager) importBundle(bundle *Bundle, sel selection, conflict, secretStore string) ([]ImportedAccount, error) {
+	var imported []ImportedAccount
+	var err error
+	for _, providerID := range sortedNames(bundle.Providers) {
+		if !validName(providerID) {
+			return imported, fmt.Errorf("invalid provider ID %q in bundle", providerID)
+		}
+
+		file, accounts := make(map[string]json.RawMessage), make(rawAccounts)
+		if m.ProviderExists(providerID) {
+			if file, accounts, err = m.readAccounts(providerID); err != nil {
+				return imported, fmt.Errorf("%s: %w", providerID, err)
+			}
+		}
+
+		changed := false
+		for _, name := range sortedNames(bundle.Providers[providerID]) {
+			if !sel.match(providerID, name) {
+				continue
+			}
+			if !validName(name) {
+				return imported, fmt.Errorf("%s: invalid account name %q in bundle", providerID, name)
+			}
+
+			result := ImportedAccount{Provider: providerID, Account: name, Action: ImportAdded}
+			acc := cloneRawAccount(bundle.Providers[providerID][name])
+			store := secretStore
+			if existing, ok := accounts[name]; ok {
+				switch conflict {
+				case ConflictSkip:
+					result.Action = ImportSkipped
+					imported = append(imported, result)
+					continue
+				case ConflictOverwrite:
+					result.Action = ImportOverwritten
+					if store == "" {
+						store = accountStore(existing)
+					}
+				case ConflictRename:
+					result.Action, result.Source = ImportRenamed, name
+					result.Account = uniqueAccountName(accounts, name)
+				}
+			}
+
+			if normalizeStore(store) != "" {
+				acc["secretStore"], _ = json.Marshal(store)
+			}
+			accounts[result.Account] = acc
+			imported = append(imported, result)
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+
+		if err := m.saveAccounts(providerID, file, accounts); err != nil {
+			return imported, fmt.Errorf("%s: %w", providerID, err)
+		}
+	}
+	return imported, nil
+}
+
+// cloneRawAccount copies an account so that imports do not modify the bundle
+func cloneRawAccount(acc map[string]json.RawMessage) map[string]json.RawMessage {
+	clone := make(map[string]json.RawMessage, len(acc))
+	for k, v := range acc {
+		clone[k] = v
+	}
+	return clone
+}
+
+// uniqueAccountName returns name with the lowest numeric suffix not used by accounts
+func uniqueAccountName(accounts rawAccounts, name string) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if _, ok := accounts[candidate]; !ok {
+			return candidate
+		}
+	}
+}
+
+// EncryptBundle serializes a bundle and encrypts it with a passphrase, as ASCII-armored age
+func EncryptBundle(bundle *Bundle, passphrase string) ([]byte, error) {
+	return encryptBundle(bundle, passphrase, 0)
+}
+
+// encryptBundle encrypts a bundle with the given scrypt work factor; zero uses age's default
+func encryptBundle(bundle *Bundle, passphrase string, workFactor int) ([]byte, error) {
+	plaintext, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if workFactor > 0 {
+		recipient.SetWorkFactor(workFactor)
+	}
+
+	var buf bytes.Buffer
+	armored := armor.NewWriter(&buf)
+	w, err := age.Encrypt(armored, recipient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+	if err := armored.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecryptBundle decrypts a bundle written by EncryptBundle, armored or binary
+func DecryptBundle(data []byte, passphrase string) (*Bundle, error) {
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	var src io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header)) {
+		src = armor.NewReader(bytes.NewReader(bytes.TrimSpace(data)))
+	}
+	r, err := age.Decrypt(src, identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt bundle: %w", err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt bundle: %w", err)
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(plaintext, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	return &bundle, nil
+}
+
+// WriteBundleFile writes an encrypted bundle readable only by the user
+func WriteBundleFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return fsutil.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,107 @@
+package credentials
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBundle_ExportImport(t *testing.T) {
+	command, _ := fakePass(t)
+	src := newEnvManager(t)
+	src.stores = map[string]SecretStore{StorePass: &passStore{command: command}}
+
+	writeCredentials(t, src, "kimi", `{"apiKey":"sk-kimi-legacy"}`)
+	writeCredentials(t, src, "zai", `{"accounts":{"default":{"apiKey":"sk-zai-default"},"work":{"apiKey":"sk-zai-work"}}}`)
+	if _, err := src.MigrateSecrets("zai", "work", StorePass); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := src.Export([]string{"zai/personal"}); err == nil {
+		t.Error("Export() expected error for an unknown account")
+	}
+	bundle, err := src.Export([]string{"kimi", "zai/work"})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if got := sortedNames(bundle.Providers["zai"]); !slices.Equal(got, []string{"work"}) {
+		t.Errorf("exported zai accounts = %v", got)
+	}
+
+	data, err := encryptBundle(bundle, "correct horse", 10)
+	if err != nil {
+		t.Fatalf("encryptBundle() error = %v", err)
+	}
+	if strings.Contains(string(data), "sk-") {
+		t.Fatal("bundle holds plain-text secrets")
+	}
+	if _, err := DecryptBundle(data, "battery staple"); err == nil {
+		t.Error("DecryptBundle() with a wrong passphrase expected error")
+	}
+	bundle, err = DecryptBundle(data, "correct horse")
+	if err != nil {
+		t.Fatalf("DecryptBundle() error = %v", err)
+	}
+
+	dst := newEnvManager(t)
+	writeCredentials(t, dst, "zai", `{"apiKey":"sk-zai-other"}`)
+	writeCredentials(t, dst, "kimi", `{"accounts":{"default":{"apiKey":"sk-kimi-old","baseUrl":"https://old.example"}}}`)
+
+	if _, err := dst.Import(bundle, ImportOptions{Conflict: "merge"}); err == nil {
+		t.Error("Import() expected error for an unknown conflict policy")
+	}
+
+	imported, err := dst.Import(bundle, ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	want := []ImportedAccount{
+		{Provider: "kimi", Account: "default", Action: ImportSkipped},
+		{Provider: "zai", Account: "work", Action: ImportAdded},
+	}
+	if !slices.Equal(imported, want) {
+		t.Errorf("imported = %+v, want %+v", imported, want)
+	}
+
+	// The legacy zai file keeps its account as "default" next to the imported one
+	zai, err := dst.LoadZAi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc := zai.GetAccount("default"); acc == nil || acc.APIKey != "sk-zai-other" {
+		t.Errorf("zai default account = %+v", acc)
+	}
+	if acc := zai.GetAccount("work"); acc == nil || acc.APIKey != "sk-zai-work" {
+		t.Errorf("zai work account = %+v", acc)
+	}
+	if strings.Contains(readCredentials(t, dst, "zai"), "secretStore") {
+		t.Error("imported account kept the source secret store")
+	}
+
+	imported, err = dst.Import(bundle, ImportOptions{Selectors: []string{"kimi"}, Conflict: ConflictRename})
+	if err != nil {
+		t.Fatalf("Import(rename) error = %v", err)
+	}
+	if want := []ImportedAccount{{Provider: "kimi", Account: "default-2", Source: "default", Action: ImportRenamed}}; !slices.Equal(imported, want) {
+		t.Errorf("imported = %+v, want %+v", imported, want)
+	}
+
+	if _, err := dst.Import(bundle, ImportOptions{Selectors: []string{"kimi"}, Conflict: ConflictOverwrite}); err != nil {
+		t.Fatalf("Import(overwrite) error = %v", err)
+	}
+	kimi, err := dst.LoadKimi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc := kimi.GetAccount("default"); acc == nil || acc.APIKey != "sk-kimi-legacy" || acc.BaseURL != "" {
+		t.Errorf("kimi default account = %+v", acc)
+	}
+	if acc := kimi.GetAccount("default-2"); acc == nil || acc.APIKey != "sk-kimi-legacy" {
+		t.Errorf("kimi default-2 account = %+v", acc)
+	}
+
+	bundle.Providers["zai"]["../escape"] = bundle.Providers["zai"]["work"]
+	if _, err := dst.Import(bundle, ImportOptions{Conflict: ConflictOverwrite}); err == nil {
+		t.Error("Import() expected error for an unsafe account name")
+	}
+}
@@ -0,0 +1,205 @@
+package credentials
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/keychain"
+)
+
+// Sources of Claude accounts discovered outside llm-usage, reported by Manager.AccountSource
+const (
+	// SourceClaudeCLI is the Claude CLI credentials file, ~/.claude/.credentials.json
+	SourceClaudeCLI = "claude-cli"
+	// SourceClaudeConfigDir is a Claude CLI configuration directory listed in CLAUDE_CONFIG_DIR
+	SourceClaudeConfigDir = "claude-config-dir"
+	// SourceKeychain is the Claude CLI entry in the macOS keychain
+	SourceKeychain = "keychain"
+)
+
+// ClaudeConfigDirEnv lists Claude CLI configuration directories, separated like PATH
+const ClaudeConfigDirEnv = "CLAUDE_CONFIG_DIR"
+
+// ClaudeSource is a place a Claude account was found
+type ClaudeSource struct {
+	Kind string // SourceFile, SourceEnv, SourceFileEnv, SourceClaudeCLI, SourceClaudeConfigDir or SourceKeychain
+	Path string // Credentials file, or the keychain item
+}
+
+// ClaudeDiscovery is a Claude account found in one or more sources
+type ClaudeDiscovery struct {
+	Name        string
+	Credentials *OAuthCredentials // Freshest credentials among the sources
+	Sources     []ClaudeSource    // In order of precedence
+}
+
+// Source returns the kinds of the account's sources joined by "+", as in file+claude-cli
+func (d *ClaudeDiscovery) Source() string {
+	kinds := make([]string, 0, len(d.Sources))
+	for _, src := range d.Sources {
+		if !slices.Contains(kinds, src.Kind) {
+			kinds = append(kinds, src.Kind)
+		}
+	}
+	return strings.Join(kinds, "+")
+}
+
+// DiscoverClaude enumerates Claude accounts from llm-usage's credentials and environment,
+// the CLAUDE_CONFIG_DIR directories, ~/.claude/.credentials.json and the macOS keychain,
+// in that order. Accounts sharing an access or refresh token are reported once, with the
+// freshest token and the name of the first source. Unreadable sources are skipped.
+//
+// The accounts are read once and shared until Refresh or a change of the credentials
+// files through the manager; callers must not modify them.
+func (m *Manager) DiscoverClaude() []*ClaudeDiscovery {
+	m.claudeMu.Lock()
+	defer m.claudeMu.Unlock()
+	if !m.claudeCached {
+		m.claude = m.discoverClaude()
+		m.claudeCached = true
+	}
+	return m.claude
+}
+
+// Refresh drops the Claude accounts cached by DiscoverClaude, so that long-running
+// processes see logins and token refreshes of the Claude CLI
+func (m *Manager) Refresh() {
+	m.claudeMu.Lock()
+	defer m.claudeMu.Unlock()
+	m.claude, m.claudeCached = nil, false
+}
+
+// discoverClaude reads the Claude accounts of all sources for DiscoverClaude
+func (m *Manager) discoverClaude() []*ClaudeDiscovery {
+	var found []*ClaudeDiscovery
+
+	add := func(name string, creds *OAuthCredentials, src ClaudeSource) {
+		if creds == nil || creds.AccessToken == "" {
+			return
+		}
+		for _, d := range found {
+			if sameClaudeToken(d.Credentials, creds) {
+				d.Sources = append(d.Sources, src)
+				if creds.ExpiresAt > d.Credentials.ExpiresAt {
+					fresher := *creds
+					fresher.BaseURL = cmp.Or(d.Credentials.BaseURL, creds.BaseURL)
+					d.Credentials = &fresher
+				}
+				return
+			}
+		}
+		found = append(found, &ClaudeDiscovery{
+			Name:        uniqueClaudeName(found, name, src.Kind),
+			Credentials: creds,
+			Sources:     []ClaudeSource{src},
+		})
+	}
+
+	if creds, err := m.LoadClaude(); err == nil {
+		names := creds.ListAccounts()
+		slices.Sort(names)
+		for _, name := range names {
+			src := ClaudeSource{Kind: m.fileAccountSource("claude", name)}
+			if src.Kind != SourceEnv {
+				src.Path = m.ProviderPath("claude")
+			}
+			add(name, creds.GetAccount(name), src)
+		}
+	}
+
+	cliDir := ""
+	if home, err := m.userHomeDir(); err == nil {
+		cliDir = filepath.Join(home, ".claude")
+	}
+	for _, dir := range m.claudeConfigDirs() {
+		if dir == cliDir {
+			continue
+		}
+		path := filepath.Join(dir, ".credentials.json")
+		if creds, err := LoadFromPath(path); err == nil {
+			add(claudeConfigDirName(dir), creds.ClaudeAiOauth, ClaudeSource{Kind: SourceClaudeConfigDir, Path: path})
+		}
+	}
+
+	if cliDir != "" {
+		path := filepath.Join(cliDir, ".credentials.json")
+		if creds, err := LoadFromPath(path); err == nil {
+			add("default", creds.ClaudeAiOauth, ClaudeSource{Kind: SourceClaudeCLI, Path: path})
+		}
+	}
+
+	if data, err := m.loadKeychain(); err == nil {
+		if creds, err := parseCredentials(data); err == nil {
+			add("default", creds.ClaudeAiOauth, ClaudeSource{Kind: SourceKeychain, Path: keychain.Service})
+		}
+	}
+
+	return found
+}
+
+// claudeConfigDirs returns the directories listed in CLAUDE_CONFIG_DIR
+func (m *Manager) claudeConfigDirs() []string {
+	var dirs []string
+	for _, kv := range m.getenv() {
+		key, value, _ := strings.Cut(kv, "=")
+		if key != ClaudeConfigDirEnv {
+			continue
+		}
+		for _, dir := range filepath.SplitList(value) {
+			if dir != "" && !slices.Contains(dirs, filepath.Clean(dir)) {
+				dirs = append(dirs, filepath.Clean(dir))
+			}
+		}
+	}
+	return dirs
+}
+
+// userHomeDir returns the home directory holding the Claude CLI configuration
+func (m *Manager) userHomeDir() (string, error) {
+	if m.homeDir != "" {
+		return m.homeDir, nil
+	}
+	return os.UserHomeDir()
+}
+
+// loadKeychain reads the Claude CLI credentials from the keychain
+func (m *Manager) loadKeychain() ([]byte, error) {
+	if m.keychain != nil {
+		return m.keychain()
+	}
+	return keychain.Load()
+}
+
+// claudeConfigDirName names an account after its configuration directory,
+// so ~/.claude-work becomes "claude-work"
+func claudeConfigDirName(dir string) string {
+	return cmp.Or(strings.TrimLeft(filepath.Base(dir), "."), "default")
+}
+
+// sameClaudeToken reports whether two credentials belong to the same login
+func sameClaudeToken(a, b *OAuthCredentials) bool {
+	return a.AccessToken == b.AccessToken || (a.RefreshToken != "" && a.RefreshToken == b.RefreshToken)
+}
+
+// uniqueClaudeName returns name, or a name qualified by the source kind if it is taken
+func uniqueClaudeName(found []*ClaudeDiscovery, name, kind string) string {
+	taken := func(n string) bool {
+		return slices.ContainsFunc(found, func(d *ClaudeDiscovery) bool { return d.Name == n })
+	}
+	if !taken(name) {
+		return name
+	}
+	candidate := kind
+	if name != "default" {
+		candidate = name + "-" + kind
+	}
+	base := candidate
+	for i := 2; taken(candidate); i++ {
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+	return candidate
+}
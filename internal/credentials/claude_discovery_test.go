@@ -0,0 +1,90 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeClaudeCLI(t *testing.T, dir, accessToken, refreshToken string, expiresAt int64) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	data := fmt.Sprintf(`{"claudeAiOauth":{"accessToken":%q,"refreshToken":%q,"expiresAt":%d}}`, accessToken, refreshToken, expiresAt)
+	if err := os.WriteFile(filepath.Join(dir, ".credentials.json"), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscoverClaude(t *testing.T) {
+	workDir := filepath.Join(t.TempDir(), ".claude-work")
+	m := newEnvManager(t)
+	m.environ = func() []string {
+		return []string{ClaudeConfigDirEnv + "=" + workDir + string(os.PathListSeparator) + filepath.Join(m.homeDir, ".claude")}
+	}
+	m.keychain = func() ([]byte, error) {
+		return []byte(`{"claudeAiOauth":{"accessToken":"at-keychain","expiresAt":3000}}`), nil
+	}
+
+	writeCredentials(t, m, "claude", `{"accounts":{"default":{"accessToken":"at-old","refreshToken":"rt-1","expiresAt":1000,"baseUrl":"http://localhost:8787"}}}`)
+	writeClaudeCLI(t, filepath.Join(m.homeDir, ".claude"), "at-new", "rt-1", 2000)
+	writeClaudeCLI(t, workDir, "at-work", "rt-work", 2000)
+
+	discovered := m.DiscoverClaude()
+	var names []string
+	for _, d := range discovered {
+		names = append(names, d.Name)
+	}
+	if want := []string{"default", "claude-work", "keychain"}; !slices.Equal(names, want) {
+		t.Fatalf("names = %v, want %v", names, want)
+	}
+
+	// The llm-usage account and the CLI file share a refresh token: the CLI's token is fresher
+	def := discovered[0]
+	if def.Credentials.AccessToken != "at-new" || def.Credentials.BaseURL != "http://localhost:8787" {
+		t.Errorf("default credentials = %+v", def.Credentials)
+	}
+	if def.Source() != "file+claude-cli" {
+		t.Errorf("default source = %q", def.Source())
+	}
+
+	for account, want := range map[string]string{"claude-work": SourceClaudeConfigDir, "keychain": SourceKeychain} {
+		if got := m.AccountSource("claude", account); got != want {
+			t.Errorf("AccountSource(%q) = %q, want %q", account, got, want)
+		}
+	}
+	if got := m.ListAvailable(); !slices.Equal(got, []string{"claude"}) {
+		t.Errorf("ListAvailable() = %v", got)
+	}
+}
+
+func TestDiscoverClaude_CLIOnly(t *testing.T) {
+	m := newEnvManager(t)
+	if got := m.ListAvailable(); len(got) != 0 {
+		t.Errorf("ListAvailable() = %v, want none", got)
+	}
+
+	writeClaudeCLI(t, filepath.Join(m.homeDir, ".claude"), "at-cli", "", 2000)
+	m.keychain = func() ([]byte, error) {
+		return []byte(`{"claudeAiOauth":{"accessToken":"at-cli","expiresAt":2000}}`), nil
+	}
+	// Discovered accounts are cached until Refresh
+	if got := m.ListAvailable(); len(got) != 0 {
+		t.Errorf("ListAvailable() before Refresh = %v, want the cached result", got)
+	}
+	m.Refresh()
+
+	accounts, err := m.ListAccounts("claude")
+	if err != nil || !slices.Equal(accounts, []string{"default"}) {
+		t.Fatalf("ListAccounts() = %v, %v", accounts, err)
+	}
+	if got := m.AccountSource("claude", "default"); got != SourceClaudeCLI+"+"+SourceKeychain {
+		t.Errorf("AccountSource() = %q", got)
+	}
+	if got := m.ListAvailable(); !slices.Equal(got, []string{"claude"}) {
+		t.Errorf("ListAvailable() = %v", got)
+	}
+}
@@ -0,0 +1,289 @@
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/config"
+)
+
+// EnvPrefix is the prefix of environment variables holding credentials.
+//
+// LLM_USAGE_<PROVIDER>_<FIELD> sets a field of the provider's "default" account and
+// LLM_USAGE_<PROVIDER>__<ACCOUNT>__<FIELD> one of a named account, for example
+// LLM_USAGE_KIMI_API_KEY or LLM_USAGE_MINIMAX__WORK__GROUP_ID. Provider IDs and fields
+// are upper-cased with dashes replaced by underscores; account names are lower-cased.
+const EnvPrefix = "LLM_USAGE_"
+
+// Account sources reported by Manager.AccountSource
+const (
+	SourceFile = "file"
+	SourceEnv  = "env"
+	// SourceFileEnv is a file account with fields overridden by environment variables
+	SourceFileEnv = "file+env"
+)
+
+// envProviders lists the built-in providers that can be configured through the environment.
+// Providers defined in the configuration file are resolved by ID when loaded.
+var envProviders = []string{"claude", "kimi", "zai", "minimax", "openai", "anthropic-api", "openrouter"}
+
+// providerIDs returns the built-in providers and the providers defined in the configuration
+// file. An unreadable configuration file only contributes no providers.
+func (m *Manager) providerIDs() []string {
+	m.providersOnce.Do(func() {
+		m.providers = slices.Clone(envProviders)
+		if cfg, err := config.LoadFromPath(filepath.Join(m.configDir, config.FileName)); err == nil {
+			m.providers = append(m.providers, cfg.ProviderIDs()...)
+		}
+	})
+	return m.providers
+}
+
+// envProviderName returns the environment variable form of a provider ID
+func envProviderName(providerID string) string {
+	return strings.ToUpper(strings.ReplaceAll(providerID, "-", "_"))
+}
+
+// getenv returns the environment the manager reads credentials from
+func (m *Manager) getenv() []string {
+	if m.environ != nil {
+		return m.environ()
+	}
+	return os.Environ()
+}
+
+// envAccounts returns the non-empty credential fields set in the environment for a provider,
+// keyed by account name and field name (as in API_KEY)
+func (m *Manager) envAccounts(providerID string) map[string]map[string]string {
+	prefix := EnvPrefix + envProviderName(providerID) + "_"
+
+	// Variables of a provider whose name extends this one's (ANTHROPIC_API vs ANTHROPIC, or a
+	// configured KIMI_CN vs KIMI) are not ours
+	var longer []string
+	for _, id := range m.providerIDs() {
+		if name := EnvPrefix + envProviderName(id) + "_"; len(name) > len(prefix) && strings.HasPrefix(name, prefix) {
+			longer = append(longer, name)
+		}
+	}
+
+	accounts := make(map[string]map[string]string)
+	for _, kv := range m.getenv() {
+		key, value, _ := strings.Cut(kv, "=")
+		if value == "" || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if slices.ContainsFunc(longer, func(p string) bool { return strings.HasPrefix(key, p) }) {
+			continue
+		}
+
+		account, field := "default", strings.TrimPrefix(key, prefix)
+		if named, ok := strings.CutPrefix(field, "_"); ok {
+			var found bool
+			account, field, found = strings.Cut(named, "__")
+			if !found || account == "" {
+				continue
+			}
+			account = strings.ToLower(account)
+		}
+		if field == "" {
+			continue
+		}
+
+		if accounts[account] == nil {
+			accounts[account] = make(map[string]string)
+		}
+		accounts[account][field] = value
+	}
+	return accounts
+}
+
+// load reads a provider's credentials file and merges accounts defined in the environment.
+// Environment variables take precedence over fields from the file; other file accounts are kept.
+// Either source alone is enough.
+func (m *Manager) load(providerID string, config ProviderConfig) error {
+	env := m.envAccounts(providerID)
+	if len(env) == 0 || m.ProviderExists(providerID) {
+		if err := m.LoadProvider(providerID, config); err != nil {
+			return err
+		}
+	}
+	if len(env) == 0 {
+		return nil
+	}
+
+	if err := applyEnvAccounts(config, env); err != nil {
+		return fmt.Errorf("invalid credentials in environment: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid credentials in environment: %w", err)
+	}
+	return nil
+}
+
+// AccountSource reports where an account's credentials come from: SourceFile, SourceEnv
+// or SourceFileEnv. Legacy single-account files define the "default" account.
+// Claude accounts also report the Claude CLI sources they were discovered in.
+func (m *Manager) AccountSource(providerID, accountName string) string {
+	if providerID == "claude" {
+		for _, d := range m.DiscoverClaude() {
+			if d.Name == accountName {
+				return d.Source()
+			}
+		}
+	}
+	return m.fileAccountSource(providerID, accountName)
+}
+
+// fileAccountSource reports whether an account comes from the credentials file, the environment or both
+func (m *Manager) fileAccountSource(providerID, accountName string) string {
+	if _, ok := m.envAccounts(providerID)[accountName]; !ok {
+		return SourceFile
+	}
+
+	data, err := os.ReadFile(m.ProviderPath(providerID)) //nolint:gosec
+	if err != nil {
+		return SourceEnv
+	}
+	var file struct {
+		Accounts map[string]json.RawMessage `json:"accounts"`
+	}
+	if json.Unmarshal(data, &file) != nil {
+		return SourceEnv
+	}
+	if _, ok := file.Accounts[accountName]; ok || (len(file.Accounts) == 0 && accountName == "default") {
+		return SourceFileEnv
+	}
+	return SourceEnv
+}
+
+// envProviderIDs returns the built-in providers with at least one account in the environment
+func (m *Manager) envProviderIDs() []string {
+	var ids []string
+	for _, id := range envProviders {
+		if len(m.envAccounts(id)) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// applyEnvAccounts merges environment accounts into a credentials struct with an Accounts map
+func applyEnvAccounts(config any, env map[string]map[string]string) error {
+	v := reflect.ValueOf(config).Elem()
+	accounts := v.FieldByName("Accounts")
+	if !accounts.IsValid() || accounts.Kind() != reflect.Map {
+		return fmt.Errorf("%s does not support multiple accounts", v.Type().Name())
+	}
+	accountType := accounts.Type().Elem().Elem()
+
+	if accounts.IsNil() {
+		accounts.Set(reflect.MakeMap(accounts.Type()))
+	}
+
+	for _, name := range sortedNames(env) {
+		acc := accounts.MapIndex(reflect.ValueOf(name))
+		if !acc.IsValid() || acc.IsNil() {
+			acc = reflect.New(accountType)
+		}
+		for field, value := range env[name] {
+			if err := setField(acc.Elem(), field, value); err != nil {
+				return fmt.Errorf("account %q: %w", name, err)
+			}
+		}
+		accounts.SetMapIndex(reflect.ValueOf(name), acc)
+	}
+	return nil
+}
+
+// setField sets the field matching an environment field name such as API_KEY (apiKey).
+// Unknown fields are stored in a Fields map if the account has one.
+func setField(acc reflect.Value, field, value string) error {
+	target, ok := fieldByJSONName(acc, camelCase(field))
+	if !ok {
+		fields, ok := fieldByJSONName(acc, "fields")
+		if !ok || fields.Kind() != reflect.Map {
+			return fmt.Errorf("unknown field %s", field)
+		}
+		if fields.IsNil() {
+			fields.Set(reflect.MakeMap(fields.Type()))
+		}
+		fields.SetMapIndex(reflect.ValueOf(strings.ToLower(field)), reflect.ValueOf(value))
+		return nil
+	}
+
+	switch {
+	case target.Kind() == reflect.String:
+		target.SetString(value)
+	case target.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", field, err)
+		}
+		target.SetInt(n)
+	case target.Kind() == reflect.Pointer && target.Type().Elem().Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", field, err)
+		}
+		target.Set(reflect.ValueOf(&f))
+	case target.Kind() == reflect.Slice && target.Type().Elem().Kind() == reflect.String:
+		target.Set(reflect.ValueOf(strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })))
+	default:
+		return fmt.Errorf("field %s cannot be set from the environment", field)
+	}
+	return nil
+}
+
+// fieldByJSONName returns the struct field with the given JSON name, including the fields
+// of embedded structs such as AccountMeta
+func fieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if field, ok := fieldByJSONName(v.Field(i), name); ok {
+				return field, true
+			}
+			continue
+		}
+		if jsonName(f) == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// jsonName returns the JSON name of a struct field
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// camelCase converts an environment field name such as GROUP_ID to its JSON name (groupId)
+func camelCase(s string) string {
+	parts := strings.Split(strings.ToLower(s), "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// sortedNames returns the keys of m in sorted order
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,165 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// newEnvManager returns a manager reading files from a temporary directory and the given
+// environment, with an empty home directory and no keychain
+func newEnvManager(t *testing.T, env ...string) *Manager {
+	t.Helper()
+	return &Manager{
+		configDir: t.TempDir(),
+		environ:   func() []string { return env },
+		homeDir:   t.TempDir(),
+		keychain:  func() ([]byte, error) { return nil, errors.New("no keychain") },
+	}
+}
+
+func writeCredentials(t *testing.T, m *Manager, providerID, data string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(m.configDir, providerID+".json"), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_EnvOnly(t *testing.T) {
+	m := newEnvManager(t,
+		"LLM_USAGE_MINIMAX_COOKIE=session=abc",
+		"LLM_USAGE_MINIMAX_GROUP_ID=42",
+		"LLM_USAGE_MINIMAX__CN__COOKIE=session=def",
+		"LLM_USAGE_MINIMAX__CN__GROUP_ID=7",
+		"LLM_USAGE_MINIMAX__CN__BASE_URL=https://platform.minimaxi.com",
+		"LLM_USAGE_KIMI_API_KEY=",
+	)
+
+	creds, err := m.LoadMiniMax()
+	if err != nil {
+		t.Fatalf("LoadMiniMax() error = %v", err)
+	}
+	if acc := creds.GetAccount("default"); acc == nil || acc.Cookie != "session=abc" || acc.GroupID != "42" {
+		t.Errorf("default account = %+v", acc)
+	}
+	if acc := creds.GetAccount("cn"); acc == nil || acc.BaseURL != "https://platform.minimaxi.com" {
+		t.Errorf("cn account = %+v", acc)
+	}
+
+	// Empty variables are ignored
+	if _, err := m.LoadKimi(); err == nil {
+		t.Error("LoadKimi() expected error without credentials")
+	}
+	if got := m.ListAvailable(); !slices.Equal(got, []string{"minimax"}) {
+		t.Errorf("ListAvailable() = %v, want [minimax]", got)
+	}
+}
+
+func TestLoad_EnvMergesWithFile(t *testing.T) {
+	m := newEnvManager(t,
+		"LLM_USAGE_OPENAI_ADMIN_KEY=sk-admin-env",
+		"LLM_USAGE_OPENAI__CI__ADMIN_KEY=sk-admin-ci",
+		"LLM_USAGE_OPENAI__CI__MONTHLY_BUDGET=250",
+	)
+	writeCredentials(t, m, "openai", `{"accounts":{"default":{"adminKey":"sk-admin-file","dailyBudget":10},"work":{"adminKey":"sk-admin-work"}}}`)
+
+	creds, err := m.LoadOpenAI()
+	if err != nil {
+		t.Fatalf("LoadOpenAI() error = %v", err)
+	}
+	if accounts := creds.ListAccounts(); len(accounts) != 3 {
+		t.Errorf("ListAccounts() = %v, want 3 accounts", accounts)
+	}
+
+	// The environment overrides single fields of a file account
+	def := creds.GetAccount("default")
+	if def.AdminKey != "sk-admin-env" || def.DailyBudget == nil || *def.DailyBudget != 10 {
+		t.Errorf("default account = %+v", def)
+	}
+	if ci := creds.GetAccount("ci"); ci == nil || ci.MonthlyBudget == nil || *ci.MonthlyBudget != 250 {
+		t.Errorf("ci account = %+v", ci)
+	}
+
+	for account, want := range map[string]string{"default": SourceFileEnv, "work": SourceFile, "ci": SourceEnv} {
+		if got := m.AccountSource("openai", account); got != want {
+			t.Errorf("AccountSource(%q) = %q, want %q", account, got, want)
+		}
+	}
+}
+
+func TestLoad_EnvLegacyFile(t *testing.T) {
+	m := newEnvManager(t, "LLM_USAGE_CLAUDE__WORK__ACCESS_TOKEN=sk-ant-oat-work")
+	writeCredentials(t, m, "claude", `{"claudeAiOauth":{"accessToken":"sk-ant-oat-file","expiresAt":1700000000000}}`)
+
+	creds, err := m.LoadClaude()
+	if err != nil {
+		t.Fatalf("LoadClaude() error = %v", err)
+	}
+	if def := creds.GetAccount("default"); def == nil || def.AccessToken != "sk-ant-oat-file" || def.ExpiresAt != 1700000000000 {
+		t.Errorf("default account = %+v", def)
+	}
+	if work := creds.GetAccount("work"); work == nil || work.AccessToken != "sk-ant-oat-work" {
+		t.Errorf("work account = %+v", work)
+	}
+	if got := m.AccountSource("claude", "default"); got != SourceFile {
+		t.Errorf("AccountSource(default) = %q, want %q", got, SourceFile)
+	}
+}
+
+func TestLoad_EnvGenericAndErrors(t *testing.T) {
+	m := newEnvManager(t,
+		"LLM_USAGE_ACME_API_KEY=key",
+		"LLM_USAGE_ACME_ORG_ID=org-1",
+		"LLM_USAGE_ANTHROPIC_API_ADMIN_KEY=sk-ant-admin",
+		"LLM_USAGE_KIMI_TOKEN=oops",
+	)
+
+	generic, err := m.LoadGeneric("acme")
+	if err != nil {
+		t.Fatalf("LoadGeneric() error = %v", err)
+	}
+	if acc := generic.GetAccount("default"); acc == nil || acc.APIKey != "key" || acc.Fields["org_id"] != "org-1" {
+		t.Errorf("acme account = %+v", acc)
+	}
+
+	// ANTHROPIC_API variables belong to anthropic-api, not to a provider named anthropic
+	if _, err := m.LoadGeneric("anthropic"); err == nil {
+		t.Error("LoadGeneric(anthropic) expected error")
+	}
+	if _, err := m.LoadAnthropicAPI(); err != nil {
+		t.Errorf("LoadAnthropicAPI() error = %v", err)
+	}
+
+	if _, err := m.LoadKimi(); err == nil {
+		t.Error("LoadKimi() expected error for an unknown field")
+	}
+}
+
+func TestLoad_EnvConfiguredProviderPrefix(t *testing.T) {
+	m := newEnvManager(t,
+		"LLM_USAGE_KIMI_API_KEY=sk-kimi",
+		"LLM_USAGE_KIMI_CN_API_KEY=sk-kimi-cn",
+	)
+	config := "providers:\n  - id: kimi-cn\n    type: exec\n    exec:\n      command: kimi-cn-usage\n"
+	if err := os.WriteFile(filepath.Join(m.configDir, "config.yaml"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// KIMI_CN variables belong to the configured kimi-cn provider, not to kimi
+	creds, err := m.LoadKimi()
+	if err != nil {
+		t.Fatalf("LoadKimi() error = %v", err)
+	}
+	if acc := creds.GetAccount("default"); acc == nil || acc.APIKey != "sk-kimi" || len(creds.Accounts) != 1 {
+		t.Errorf("kimi accounts = %+v", creds.Accounts)
+	}
+	generic, err := m.LoadGeneric("kimi-cn")
+	if err != nil {
+		t.Fatalf("LoadGeneric(kimi-cn) error = %v", err)
+	}
+	if acc := generic.GetAccount("default"); acc == nil || acc.APIKey != "sk-kimi-cn" {
+		t.Errorf("kimi-cn account = %+v", acc)
+	}
+}
@@ -0,0 +1,160 @@
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/fsutil"
+)
+
+// lockTimeout is how long Update waits for another process updating the credentials files
+const lockTimeout = 10 * time.Second
+
+// Suffixes of the files kept next to a provider's credentials file
+const (
+	// BackupSuffix names the copy of the previous credentials file made each time it is saved
+	BackupSuffix = ".bak"
+	// CorruptSuffix names a corrupted credentials file that was replaced by its backup
+	CorruptSuffix = ".corrupt"
+)
+
+// ErrReadOnly is returned when a manager created with NewReadOnlyManager would write
+var ErrReadOnly = errors.New("credentials are opened read-only")
+
+// lockPath returns the lock file serializing updates of the credentials files
+func (m *Manager) lockPath() string {
+	return filepath.Join(m.configDir, ".lock")
+}
+
+// Update runs fn, which reads and rewrites credentials files, while holding the lock on the
+// configuration directory, so that concurrent setup commands and servers do not overwrite
+// each other's changes. fn must not call Update.
+func (m *Manager) Update(fn func() error) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+	m.lockMu.Lock()
+	defer m.lockMu.Unlock()
+
+	if err := m.EnsureConfigDir(); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	lock, err := fsutil.LockTimeout(m.lockPath(), lockTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to lock credentials: %w", err)
+	}
+	defer func() { _ = lock.Unlock() }()
+	return fn()
+}
+
+// tryUpdate runs fn like Update if the lock is free, and reports whether it ran. It is used
+// for optional rewrites on read paths, which may run inside an Update.
+func (m *Manager) tryUpdate(fn func() error) bool {
+	if !m.lockMu.TryLock() {
+		return false
+	}
+	defer m.lockMu.Unlock()
+
+	lock, err := fsutil.TryLock(m.lockPath())
+	if err != nil {
+		return false
+	}
+	defer func() { _ = lock.Unlock() }()
+	return fn() == nil
+}
+
+// writeProviderFile atomically replaces a provider's credentials file, after copying the
+// previous file to the backup used if the file gets corrupted. A missing or corrupted previous
+// file leaves the backup as it is.
+func (m *Manager) writeProviderFile(providerID string, data []byte) error {
+	defer m.Refresh()
+	path := m.ProviderPath(providerID)
+	if previous, err := os.ReadFile(path); err == nil && json.Valid(previous) { //nolint:gosec
+		if err := fsutil.WriteFile(path+BackupSuffix, backupOf(previous, data), 0600); err != nil {
+			return fmt.Errorf("failed to back up credentials file: %w", err)
+		}
+	}
+	return fsutil.WriteFile(path, data, 0600)
+}
+
+// backupOf returns the backup of previous, the credentials file replaced by data. The
+// accounts data removes are left out, and so are the secrets of accounts data keeps in a
+// secret store, which the backup then points to instead: the backup must not keep secrets
+// that were deleted or moved out of the credentials files.
+func backupOf(previous, data []byte) []byte {
+	upgraded, _, err := upgradeFile(previous)
+	if err != nil {
+		return previous
+	}
+	file, accounts, err := splitAccounts(upgraded)
+	if err != nil {
+		return previous
+	}
+	_, current, err := splitAccounts(data)
+	if err != nil {
+		return previous
+	}
+
+	changed := false
+	for name, acc := range accounts {
+		cur, ok := current[name]
+		if !ok {
+			delete(accounts, name)
+			changed = true
+			continue
+		}
+		if accountStore(cur) == "" {
+			continue
+		}
+		for _, key := range secretFields {
+			if _, ok := acc[key]; ok {
+				delete(acc, key)
+				changed = true
+			}
+		}
+		acc["secretStore"] = cur["secretStore"]
+	}
+	if !changed {
+		return previous
+	}
+
+	backup, err := marshalAccounts(file, accounts)
+	if err != nil {
+		return previous
+	}
+	return backup
+}
+
+// readProviderFile reads a provider's credentials file. A corrupted file, such as one
+// truncated by a crash, is replaced by its backup when there is a valid one, and kept with
+// CorruptSuffix for inspection.
+func (m *Manager) readProviderFile(providerID string) ([]byte, error) {
+	path := m.ProviderPath(providerID)
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil || json.Valid(data) {
+		return data, err
+	}
+
+	backup, err := os.ReadFile(path + BackupSuffix) //nolint:gosec
+	if err != nil || !json.Valid(backup) {
+		return nil, fmt.Errorf("credentials file %s is corrupted and has no valid backup", path)
+	}
+	if m.readOnly {
+		return backup, nil
+	}
+	m.tryUpdate(func() error {
+		// Another process may have repaired or rewritten the file in the meantime
+		if current, err := os.ReadFile(path); err == nil && json.Valid(current) { //nolint:gosec
+			return nil
+		}
+		if err := fsutil.WriteFile(path+CorruptSuffix, data, 0600); err != nil {
+			return err
+		}
+		return fsutil.WriteFile(path, backup, 0600)
+	})
+	return backup, nil
+}
@@ -0,0 +1,144 @@
+package credentials
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLoad_RecoversCorruptedFile(t *testing.T) {
+	m := newEnvManager(t)
+	creds := &KimiCredentials{AccountSet: AccountSet[KimiAccount]{Accounts: map[string]*KimiAccount{"work": {APIKey: "sk-work"}}}}
+	if err := m.SaveProvider("kimi", creds); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(m.ProviderPath("kimi") + BackupSuffix); !os.IsNotExist(err) {
+		t.Errorf("backup made without a previous file: %v", err)
+	}
+	creds.Accounts["home"] = &KimiAccount{APIKey: "sk-home"}
+	if err := m.SaveProvider("kimi", creds); err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash in the middle of a write by an older version truncates the file
+	writeCredentials(t, m, "kimi", `{"version":2,"accounts":{"wo`)
+
+	loaded, err := m.LoadKimi()
+	if err != nil {
+		t.Fatalf("LoadKimi() error = %v", err)
+	}
+	if acc := loaded.GetAccount("work"); acc == nil || acc.APIKey != "sk-work" {
+		t.Errorf("work account = %+v", acc)
+	}
+	if loaded.GetAccount("home") != nil {
+		t.Errorf("restored the file being written instead of the previous one")
+	}
+	if file := readCredentials(t, m, "kimi"); !strings.Contains(file, "sk-work") {
+		t.Errorf("file not restored from backup:\n%s", file)
+	}
+	corrupt, err := os.ReadFile(m.ProviderPath("kimi") + CorruptSuffix)
+	if err != nil || !strings.Contains(string(corrupt), `"wo`) {
+		t.Errorf("corrupted copy = %q, %v", corrupt, err)
+	}
+
+	// Without a valid backup the file is reported instead of silently emptied
+	writeCredentials(t, m, "zai", `{"accounts":`)
+	if _, err := m.LoadZAi(); err == nil || !strings.Contains(err.Error(), "no valid backup") {
+		t.Errorf("LoadZAi() error = %v, want corrupted file", err)
+	}
+
+	if err := m.DeleteProvider("kimi"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(m.ProviderPath("kimi") + BackupSuffix); !os.IsNotExist(err) {
+		t.Errorf("backup kept after deleting the provider: %v", err)
+	}
+}
+
+func TestMigrateFiles_BacksUpLegacyFile(t *testing.T) {
+	m := newEnvManager(t)
+	legacy := `{"apiKey":"sk-legacy"}`
+	writeCredentials(t, m, "kimi", legacy)
+
+	if _, err := m.MigrateFiles(); err != nil {
+		t.Fatalf("MigrateFiles() error = %v", err)
+	}
+	backup, err := os.ReadFile(m.ProviderPath("kimi") + BackupSuffix)
+	if err != nil || string(backup) != legacy {
+		t.Errorf("backup = %q, %v, want the legacy file", backup, err)
+	}
+}
+
+func TestSaveProvider_BackupDropsMovedAndRemovedSecrets(t *testing.T) {
+	m := newEnvManager(t, AgePassphraseEnv+"=correct horse")
+	store := m.newAgeStore()
+	store.workFactor = 10
+	m.stores = map[string]SecretStore{StoreAge: store}
+
+	creds := &KimiCredentials{AccountSet: AccountSet[KimiAccount]{Accounts: map[string]*KimiAccount{
+		"work": {APIKey: "sk-work"},
+		"home": {APIKey: "sk-home"},
+	}}}
+	if err := m.SaveProvider("kimi", creds); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RemoveAccount("kimi", "home"); err != nil {
+		t.Fatalf("RemoveAccount() error = %v", err)
+	}
+	backup, err := os.ReadFile(m.ProviderPath("kimi") + BackupSuffix)
+	if err != nil || strings.Contains(string(backup), "sk-home") || !strings.Contains(string(backup), "sk-work") {
+		t.Errorf("backup after removing an account = %q, %v", backup, err)
+	}
+
+	if _, err := m.MigrateSecrets("kimi", "", StoreAge); err != nil {
+		t.Fatalf("MigrateSecrets() error = %v", err)
+	}
+	backup, err = os.ReadFile(m.ProviderPath("kimi") + BackupSuffix)
+	if err != nil || strings.Contains(string(backup), "sk-") || !strings.Contains(string(backup), `"secretStore": "age"`) {
+		t.Errorf("backup after migrating to age = %q, %v", backup, err)
+	}
+
+	// The backup still restores the account, with its secrets from the store
+	writeCredentials(t, m, "kimi", `{"version":2,"accounts":{"wo`)
+	loaded, err := m.LoadKimi()
+	if err != nil {
+		t.Fatalf("LoadKimi() error = %v", err)
+	}
+	if acc := loaded.GetAccount("work"); acc == nil || acc.APIKey != "sk-work" {
+		t.Errorf("work account restored from backup = %+v", acc)
+	}
+}
+
+func TestUpdate_Concurrent(t *testing.T) {
+	m := newEnvManager(t)
+	writeCredentials(t, m, "openrouter", `{"version":2,"accounts":{"seed":{"apiKey":"sk-or-seed"}}}`)
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := m.Update(func() error {
+				var creds OpenRouterCredentials
+				if err := m.LoadProvider("openrouter", &creds); err != nil {
+					return err
+				}
+				creds.Accounts[name] = &OpenRouterAccount{APIKey: "sk-or-" + name}
+				return m.SaveProvider("openrouter", &creds)
+			})
+			if err != nil {
+				t.Errorf("Update() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	meta, err := m.ReadAccountsMeta("openrouter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := meta.ListAccounts(); len(got) != 9 {
+		t.Errorf("accounts = %v, want all 8 concurrent additions", got)
+	}
+}
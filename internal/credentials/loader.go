@@ -7,8 +7,6 @@ import (
 	"os"
 	"path/filepath"
 	"time"
-
-	"github.com/denysvitali/llm-usage/internal/keychain"
 )
 
 // Credentials represents the structure of ~/.claude/.credentials.json
@@ -23,6 +21,7 @@ type OAuthCredentials struct {
 	ExpiresAt     int64    `json:"expiresAt"`
 	Scopes        []string `json:"scopes"`
 	RateLimitTier string   `json:"rateLimitTier"`
+	BaseURL       string   `json:"baseUrl,omitempty"` // Overrides the API base URL (e.g. a gateway)
 }
 
 // IsExpired checks if the access token has expired
@@ -37,26 +36,6 @@ func (o *OAuthCredentials) ExpiresIn() time.Duration {
 	return time.Until(expiresAt)
 }
 
-// Load reads credentials from the platform-specific storage.
-// On macOS, it attempts to use the keychain first, then falls back to the file.
-// On other platforms, it reads from the credentials file.
-func Load() (*Credentials, error) {
-	// Try keychain first on all platforms
-	data, err := keychain.Load()
-	if err == nil {
-		return parseCredentials(data)
-	}
-	// If keychain load fails (not supported or not found), fall through to file-based loading
-
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
-	}
-
-	credPath := filepath.Join(homeDir, ".claude", ".credentials.json")
-	return LoadFromPath(credPath)
-}
-
 // parseCredentials parses credentials from JSON data
 func parseCredentials(data []byte) (*Credentials, error) {
 	var creds Credentials
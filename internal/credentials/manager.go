@@ -2,11 +2,15 @@ package credentials
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 
 	"github.com/adrg/xdg"
+	"github.com/denysvitali/llm-usage/internal/httpclient"
 )
 
 // ProviderConfig is the interface for provider-specific credential configs
@@ -17,7 +21,23 @@ type ProviderConfig interface {
 
 // Manager handles loading credentials for multiple providers
 type Manager struct {
-	configDir string // $XDG_CONFIG_HOME/llm-usage (defaults to ~/.config/llm-usage)
+	configDir string                 // $XDG_CONFIG_HOME/llm-usage (defaults to ~/.config/llm-usage)
+	environ   func() []string        // Environment to read credentials from; defaults to os.Environ
+	homeDir   string                 // Home directory of the Claude CLI configuration; defaults to the user's
+	keychain  func() ([]byte, error) // Reads the Claude CLI keychain entry; defaults to keychain.Load
+
+	readOnly bool       // Never writes: corrupted files are not repaired and Update fails
+	lockMu   sync.Mutex // Serializes Update within the process
+
+	providersOnce sync.Once
+	providers     []string // Built-in and configured provider IDs, read on first use
+
+	claudeMu     sync.Mutex
+	claude       []*ClaudeDiscovery // Accounts found by DiscoverClaude, until Refresh or a write
+	claudeCached bool
+
+	storesMu sync.Mutex
+	stores   map[string]SecretStore // Secret store backends by name, created on first use
 }
 
 // NewManager creates a new credential manager
@@ -27,6 +47,15 @@ func NewManager() *Manager {
 	}
 }
 
+// NewReadOnlyManager creates a credential manager that never writes, for diagnostics.
+// Files in an older format are only upgraded in memory and corrupted files are read from
+// their backup without being repaired.
+func NewReadOnlyManager() *Manager {
+	m := NewManager()
+	m.readOnly = true
+	return m
+}
+
 // ConfigDir returns the configuration directory path
 func (m *Manager) ConfigDir() string {
 	return m.configDir
@@ -39,9 +68,9 @@ func (m *Manager) EnsureConfigDir() error {
 
 // LoadProvider loads credentials for a specific provider
 func (m *Manager) LoadProvider(providerID string, config ProviderConfig) error {
-	configPath := m.providerPath(providerID)
+	configPath := m.ProviderPath(providerID)
 
-	data, err := os.ReadFile(configPath) //nolint:gosec
+	data, err := m.readProviderFile(providerID)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return fmt.Errorf("credentials file not found at %s", configPath)
@@ -49,6 +78,17 @@ func (m *Manager) LoadProvider(providerID string, config ProviderConfig) error {
 		return fmt.Errorf("failed to read credentials file: %w", err)
 	}
 
+	// Files in an older format are upgraded in memory; MigrateFiles and saves rewrite them
+	data, _, err = upgradeFile(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+
+	data, err = m.resolveSecrets(providerID, data)
+	if err != nil {
+		return fmt.Errorf("failed to load credentials: %w", err)
+	}
+
 	if err := json.Unmarshal(data, config); err != nil {
 		return fmt.Errorf("failed to parse credentials file: %w", err)
 	}
@@ -60,25 +100,24 @@ func (m *Manager) LoadProvider(providerID string, config ProviderConfig) error {
 	return nil
 }
 
-// providerPath returns the path to a provider's credential file
-func (m *Manager) providerPath(providerID string) string {
+// ProviderPath returns the path to a provider's credentials file
+func (m *Manager) ProviderPath(providerID string) string {
 	return filepath.Join(m.configDir, providerID+".json")
 }
 
 // ProviderExists checks if a provider's credential file exists
 func (m *Manager) ProviderExists(providerID string) bool {
-	_, err := os.Stat(m.providerPath(providerID))
+	_, err := os.Stat(m.ProviderPath(providerID))
 	return err == nil
 }
 
 // ListAvailable returns a list of providers that have credential files
+// or accounts defined in the environment. Claude is listed when accounts
+// are discovered in the Claude CLI configuration too.
 func (m *Manager) ListAvailable() []string {
-	entries, err := os.ReadDir(m.configDir)
-	if err != nil {
-		return nil
-	}
+	providers := m.envProviderIDs()
 
-	var providers []string
+	entries, _ := os.ReadDir(m.configDir)
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
@@ -88,43 +127,85 @@ func (m *Manager) ListAvailable() []string {
 		if filepath.Ext(name) == ".json" {
 			// Remove .json extension to get provider ID
 			providerID := name[:len(name)-5]
-			providers = append(providers, providerID)
+			if !slices.Contains(providers, providerID) {
+				providers = append(providers, providerID)
+			}
 		}
 	}
+
+	if !slices.Contains(providers, "claude") && len(m.DiscoverClaude()) > 0 {
+		providers = append(providers, "claude")
+	}
 	return providers
 }
 
-// LoadClaude loads Claude credentials from the config file
+// LoadClaude loads Claude credentials from the config file and the environment
 func (m *Manager) LoadClaude() (*ClaudeCredentials, error) {
 	var creds ClaudeCredentials
-	if err := m.LoadProvider("claude", &creds); err != nil {
+	if err := m.load("claude", &creds); err != nil {
 		return nil, err
 	}
 	return &creds, nil
 }
 
-// LoadKimi loads Kimi credentials from the config file
+// LoadKimi loads Kimi credentials from the config file and the environment
 func (m *Manager) LoadKimi() (*KimiCredentials, error) {
 	var creds KimiCredentials
-	if err := m.LoadProvider("kimi", &creds); err != nil {
+	if err := m.load("kimi", &creds); err != nil {
 		return nil, err
 	}
 	return &creds, nil
 }
 
-// LoadZAi loads Z.AI credentials from the config file
+// LoadZAi loads Z.AI credentials from the config file and the environment
 func (m *Manager) LoadZAi() (*ZAiCredentials, error) {
 	var creds ZAiCredentials
-	if err := m.LoadProvider("zai", &creds); err != nil {
+	if err := m.load("zai", &creds); err != nil {
 		return nil, err
 	}
 	return &creds, nil
 }
 
-// LoadMiniMax loads MiniMax credentials from the config file
+// LoadMiniMax loads MiniMax credentials from the config file and the environment
 func (m *Manager) LoadMiniMax() (*MiniMaxCredentials, error) {
 	var creds MiniMaxCredentials
-	if err := m.LoadProvider("minimax", &creds); err != nil {
+	if err := m.load("minimax", &creds); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+// LoadOpenAI loads OpenAI credentials from the config file and the environment
+func (m *Manager) LoadOpenAI() (*OpenAICredentials, error) {
+	var creds OpenAICredentials
+	if err := m.load("openai", &creds); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+// LoadAnthropicAPI loads Anthropic Admin API credentials from the config file and the environment
+func (m *Manager) LoadAnthropicAPI() (*AnthropicAPICredentials, error) {
+	var creds AnthropicAPICredentials
+	if err := m.load("anthropic-api", &creds); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+// LoadOpenRouter loads OpenRouter credentials from the config file and the environment
+func (m *Manager) LoadOpenRouter() (*OpenRouterCredentials, error) {
+	var creds OpenRouterCredentials
+	if err := m.load("openrouter", &creds); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+// LoadGeneric loads credentials for a provider defined in the configuration file
+func (m *Manager) LoadGeneric(providerID string) (*GenericCredentials, error) {
+	var creds GenericCredentials
+	if err := m.load(providerID, &creds); err != nil {
 		return nil, err
 	}
 	return &creds, nil
@@ -132,8 +213,7 @@ func (m *Manager) LoadMiniMax() (*MiniMaxCredentials, error) {
 
 // ClaudeCredentials represents Claude OAuth credentials with multi-account support
 type ClaudeCredentials struct {
-	ClaudeAiOauth *OAuthCredentials         `json:"claudeAiOauth,omitempty"` // Legacy single-account format
-	Accounts      map[string]*ClaudeAccount `json:"accounts,omitempty"`      // Multi-account format
+	AccountSet[ClaudeAccount]
 }
 
 // ClaudeAccount represents a single Claude account's credentials
@@ -142,6 +222,9 @@ type ClaudeAccount struct {
 	RefreshToken string   `json:"refreshToken"`
 	ExpiresAt    int64    `json:"expiresAt"`
 	Scopes       []string `json:"scopes"`
+	BaseURL      string   `json:"baseUrl,omitempty"`     // Overrides the API base URL (e.g. a gateway)
+	SecretStore  string   `json:"secretStore,omitempty"` // Backend holding the account's secrets; empty keeps them in this file
+	AccountMeta
 }
 
 // ToOAuthCredentials converts a ClaudeAccount to OAuthCredentials
@@ -154,319 +237,270 @@ func (a *ClaudeAccount) ToOAuthCredentials() *OAuthCredentials {
 		RefreshToken: a.RefreshToken,
 		ExpiresAt:    a.ExpiresAt,
 		Scopes:       a.Scopes,
+		BaseURL:      a.BaseURL,
 	}
 }
 
-// GetAccount returns the specified account's credentials, or the default/first available account
+// GetAccount returns the specified account's credentials, or the default account's
 func (c *ClaudeCredentials) GetAccount(accountName string) *OAuthCredentials {
-	// Try multi-account format first
-	if c.Accounts != nil {
-		if accountName == "" {
-			// Try "default" first, then fall back to first account
-			if acc, ok := c.Accounts["default"]; ok {
-				return acc.ToOAuthCredentials()
-			}
-			for _, acc := range c.Accounts {
-				return acc.ToOAuthCredentials()
-			}
-		} else {
-			if acc, ok := c.Accounts[accountName]; ok {
-				return acc.ToOAuthCredentials()
-			}
-		}
-	}
-
-	// Fall back to legacy format
-	return c.ClaudeAiOauth
-}
-
-// ListAccounts returns all account names for this provider
-func (c *ClaudeCredentials) ListAccounts() []string {
-	if c.Accounts != nil {
-		names := make([]string, 0, len(c.Accounts))
-		for name := range c.Accounts {
-			names = append(names, name)
-		}
-		return names
-	}
-	if c.ClaudeAiOauth != nil {
-		return []string{"default"}
-	}
-	return nil
+	return c.AccountSet.GetAccount(accountName).ToOAuthCredentials()
 }
 
 // Validate checks if the Claude credentials are valid
 func (c *ClaudeCredentials) Validate() error {
-	if len(c.Accounts) > 0 {
-		for name, acc := range c.Accounts {
-			if acc.AccessToken == "" {
-				return fmt.Errorf("no access token found for account %q", name)
-			}
+	return c.validate(func(acc *ClaudeAccount) error {
+		if acc.AccessToken == "" {
+			return errors.New("no access token found")
 		}
-		return nil
-	}
-	if c.ClaudeAiOauth == nil {
-		return fmt.Errorf("no OAuth credentials found")
-	}
-	if c.ClaudeAiOauth.AccessToken == "" {
-		return fmt.Errorf("no access token found")
-	}
-	return nil
+		return validateBaseURL(acc.BaseURL)
+	})
 }
 
 // KimiCredentials represents Kimi API credentials with multi-account support
 type KimiCredentials struct {
-	APIKey   string                  `json:"apiKey,omitempty"`   // Legacy single-account format
-	Accounts map[string]*KimiAccount `json:"accounts,omitempty"` // Multi-account format
+	AccountSet[KimiAccount]
 }
 
 // KimiAccount represents a single Kimi account's credentials
 type KimiAccount struct {
-	APIKey string `json:"apiKey"`
-}
-
-// GetAccount returns the specified account's credentials, or the default/first available account
-func (k *KimiCredentials) GetAccount(accountName string) *KimiAccount {
-	// Try multi-account format first
-	if k.Accounts != nil {
-		if accountName == "" {
-			// Try "default" first, then fall back to first account
-			if acc, ok := k.Accounts["default"]; ok {
-				return acc
-			}
-			for _, acc := range k.Accounts {
-				return acc
-			}
-		} else {
-			if acc, ok := k.Accounts[accountName]; ok {
-				return acc
-			}
-		}
-	}
-
-	// Fall back to legacy format
-	if k.APIKey != "" {
-		return &KimiAccount{APIKey: k.APIKey}
-	}
-	return nil
-}
-
-// ListAccounts returns all account names for this provider
-func (k *KimiCredentials) ListAccounts() []string {
-	if k.Accounts != nil {
-		names := make([]string, 0, len(k.Accounts))
-		for name := range k.Accounts {
-			names = append(names, name)
-		}
-		return names
-	}
-	if k.APIKey != "" {
-		return []string{"default"}
-	}
-	return nil
+	APIKey      string `json:"apiKey"`
+	BaseURL     string `json:"baseUrl,omitempty"`     // Overrides the API base URL (e.g. the moonshot.cn endpoint)
+	SecretStore string `json:"secretStore,omitempty"` // Backend holding the account's secrets; empty keeps them in this file
+	AccountMeta
 }
 
 // Validate checks if the Kimi credentials are valid
 func (k *KimiCredentials) Validate() error {
-	if len(k.Accounts) > 0 {
-		for name, acc := range k.Accounts {
-			if acc.APIKey == "" {
-				return fmt.Errorf("no API key found for account %q", name)
-			}
+	return k.validate(func(acc *KimiAccount) error {
+		if acc.APIKey == "" {
+			return errors.New("no API key found")
 		}
-		return nil
-	}
-	if k.APIKey == "" {
-		return fmt.Errorf("no API key found")
-	}
-	return nil
+		return validateBaseURL(acc.BaseURL)
+	})
 }
 
 // ZAiCredentials represents Z.AI API credentials with multi-account support
 type ZAiCredentials struct {
-	APIKey   string                 `json:"apiKey,omitempty"`   // Legacy single-account format
-	Accounts map[string]*ZAiAccount `json:"accounts,omitempty"` // Multi-account format
+	AccountSet[ZAiAccount]
 }
 
 // ZAiAccount represents a single Z.AI account's credentials
 type ZAiAccount struct {
-	APIKey string `json:"apiKey"`
-}
-
-// GetAccount returns the specified account's credentials, or the default/first available account
-func (z *ZAiCredentials) GetAccount(accountName string) *ZAiAccount {
-	// Try multi-account format first
-	if z.Accounts != nil {
-		if accountName == "" {
-			// Try "default" first, then fall back to first account
-			if acc, ok := z.Accounts["default"]; ok {
-				return acc
-			}
-			for _, acc := range z.Accounts {
-				return acc
-			}
-		} else {
-			if acc, ok := z.Accounts[accountName]; ok {
-				return acc
-			}
-		}
-	}
-
-	// Fall back to legacy format
-	if z.APIKey != "" {
-		return &ZAiAccount{APIKey: z.APIKey}
-	}
-	return nil
-}
-
-// ListAccounts returns all account names for this provider
-func (z *ZAiCredentials) ListAccounts() []string {
-	if z.Accounts != nil {
-		names := make([]string, 0, len(z.Accounts))
-		for name := range z.Accounts {
-			names = append(names, name)
-		}
-		return names
-	}
-	if z.APIKey != "" {
-		return []string{"default"}
-	}
-	return nil
+	APIKey      string `json:"apiKey"`
+	SecretStore string `json:"secretStore,omitempty"` // Backend holding the account's secrets; empty keeps them in this file
+	AccountMeta
 }
 
 // Validate checks if the Z.AI credentials are valid
 func (z *ZAiCredentials) Validate() error {
-	if len(z.Accounts) > 0 {
-		for name, acc := range z.Accounts {
-			if acc.APIKey == "" {
-				return fmt.Errorf("no API key found for account %q", name)
-			}
+	return z.validate(func(acc *ZAiAccount) error {
+		if acc.APIKey == "" {
+			return errors.New("no API key found")
 		}
 		return nil
-	}
-	if z.APIKey == "" {
-		return fmt.Errorf("no API key found")
-	}
-	return nil
+	})
 }
 
 // MiniMaxCredentials represents MiniMax credentials with multi-account support
 type MiniMaxCredentials struct {
-	Cookie   string                     `json:"cookie,omitempty"`
-	GroupID  string                     `json:"groupId,omitempty"`
-	Accounts map[string]*MiniMaxAccount `json:"accounts,omitempty"`
+	AccountSet[MiniMaxAccount]
 }
 
 // MiniMaxAccount represents a single MiniMax account's credentials
 type MiniMaxAccount struct {
-	Cookie  string `json:"cookie"`
-	GroupID string `json:"groupId"`
-}
-
-// GetAccount returns the specified account's credentials, or the default/first available account
-func (m *MiniMaxCredentials) GetAccount(accountName string) *MiniMaxAccount {
-	// Try multi-account format first
-	if m.Accounts != nil {
-		if accountName == "" {
-			// Try "default" first, then fall back to first account
-			if acc, ok := m.Accounts["default"]; ok {
-				return acc
-			}
-			for _, acc := range m.Accounts {
-				return acc
-			}
-		} else {
-			if acc, ok := m.Accounts[accountName]; ok {
-				return acc
-			}
+	Cookie      string `json:"cookie"`
+	GroupID     string `json:"groupId"`
+	BaseURL     string `json:"baseUrl,omitempty"`     // Overrides the platform base URL (e.g. the China platform)
+	SecretStore string `json:"secretStore,omitempty"` // Backend holding the account's secrets; empty keeps them in this file
+	AccountMeta
+}
+
+// Validate checks if the MiniMax credentials are valid
+func (m *MiniMaxCredentials) Validate() error {
+	return m.validate(func(acc *MiniMaxAccount) error {
+		if acc.Cookie == "" {
+			return errors.New("no cookie found")
 		}
-	}
+		if acc.GroupID == "" {
+			return errors.New("no group ID found")
+		}
+		return validateBaseURL(acc.BaseURL)
+	})
+}
 
-	// Fall back to legacy format
-	if m.Cookie != "" {
-		return &MiniMaxAccount{Cookie: m.Cookie, GroupID: m.GroupID}
-	}
-	return nil
+// OpenAICredentials represents OpenAI admin API credentials with multi-account support
+type OpenAICredentials struct {
+	AccountSet[OpenAIAccount]
+}
+
+// OpenAIAccount represents a single OpenAI organization's credentials
+type OpenAIAccount struct {
+	AdminKey      string   `json:"adminKey"`
+	BaseURL       string   `json:"baseUrl,omitempty"`       // Overrides the API base URL (e.g. a local stub)
+	MonthlyBudget *float64 `json:"monthlyBudget,omitempty"` // Monthly spend budget in USD
+	DailyBudget   *float64 `json:"dailyBudget,omitempty"`   // Daily spend budget in USD
+	ProbeKey      string   `json:"probeKey,omitempty"`      // Project API key used to probe live rate limits
+	SecretStore   string   `json:"secretStore,omitempty"`   // Backend holding the account's secrets; empty keeps them in this file
+	AccountMeta
 }
 
-// ListAccounts returns all account names for this provider
-func (m *MiniMaxCredentials) ListAccounts() []string {
-	if m.Accounts != nil {
-		names := make([]string, 0, len(m.Accounts))
-		for name := range m.Accounts {
-			names = append(names, name)
+// Validate checks if the OpenAI credentials are valid
+func (o *OpenAICredentials) Validate() error {
+	return o.validate(func(acc *OpenAIAccount) error {
+		if acc.AdminKey == "" {
+			return errors.New("no admin key found")
 		}
-		return names
-	}
-	if m.Cookie != "" {
-		return []string{"default"}
-	}
-	return nil
+		return validateBaseURL(acc.BaseURL)
+	})
 }
 
-// Validate checks if the MiniMax credentials are valid
-func (m *MiniMaxCredentials) Validate() error {
-	if len(m.Accounts) > 0 {
-		for name, acc := range m.Accounts {
-			if acc.Cookie == "" {
-				return fmt.Errorf("no cookie found for account %q", name)
-			}
-			if acc.GroupID == "" {
-				return fmt.Errorf("no group ID found for account %q", name)
-			}
+// AnthropicAPICredentials represents Anthropic Admin API credentials with multi-account support
+type AnthropicAPICredentials struct {
+	AccountSet[AnthropicAPIAccount]
+}
+
+// AnthropicAPIAccount represents a single Anthropic organization's credentials
+type AnthropicAPIAccount struct {
+	AdminKey      string   `json:"adminKey"`
+	BaseURL       string   `json:"baseUrl,omitempty"`       // Overrides the API base URL (e.g. a local stub)
+	MonthlyBudget *float64 `json:"monthlyBudget,omitempty"` // Monthly spend budget in USD
+	DailyBudget   *float64 `json:"dailyBudget,omitempty"`   // Daily spend budget in USD
+	ProbeKey      string   `json:"probeKey,omitempty"`      // Regular API key used to probe live rate limits
+	SecretStore   string   `json:"secretStore,omitempty"`   // Backend holding the account's secrets; empty keeps them in this file
+	AccountMeta
+}
+
+// Validate checks if the Anthropic API credentials are valid
+func (a *AnthropicAPICredentials) Validate() error {
+	return a.validate(func(acc *AnthropicAPIAccount) error {
+		if acc.AdminKey == "" {
+			return errors.New("no admin key found")
+		}
+		return validateBaseURL(acc.BaseURL)
+	})
+}
+
+// OpenRouterCredentials represents OpenRouter API credentials with multi-account support
+type OpenRouterCredentials struct {
+	AccountSet[OpenRouterAccount]
+}
+
+// OpenRouterAccount represents a single OpenRouter account's credentials
+type OpenRouterAccount struct {
+	APIKey      string `json:"apiKey"`                // Regular or provisioning API key
+	BaseURL     string `json:"baseUrl,omitempty"`     // Overrides the API base URL (e.g. a local stub)
+	SecretStore string `json:"secretStore,omitempty"` // Backend holding the account's secrets; empty keeps them in this file
+	AccountMeta
+}
+
+// Validate checks if the OpenRouter credentials are valid
+func (o *OpenRouterCredentials) Validate() error {
+	return o.validate(func(acc *OpenRouterAccount) error {
+		if acc.APIKey == "" {
+			return errors.New("no API key found")
 		}
+		return validateBaseURL(acc.BaseURL)
+	})
+}
+
+// validateBaseURL checks an account's optional base URL override
+func validateBaseURL(baseURL string) error {
+	if baseURL == "" {
 		return nil
 	}
-	if m.Cookie == "" {
-		return fmt.Errorf("no cookie found")
-	}
-	if m.GroupID == "" {
-		return fmt.Errorf("no group ID found")
-	}
-	return nil
+	return httpclient.ValidateBaseURL(baseURL)
 }
 
-// SaveProvider saves provider credentials to the config file
+// GenericCredentials represents credentials for providers defined in the configuration file
+type GenericCredentials struct {
+	AccountSet[GenericAccount]
+}
+
+// GenericAccount represents a single account of a configuration-defined provider.
+// Fields holds additional named secrets available to request templates.
+type GenericAccount struct {
+	APIKey      string            `json:"apiKey,omitempty"`
+	Fields      map[string]string `json:"fields,omitempty"`
+	SecretStore string            `json:"secretStore,omitempty"` // Backend holding the account's secrets; empty keeps them in this file
+	AccountMeta
+}
+
+// Validate checks if the generic credentials are valid
+func (g *GenericCredentials) Validate() error {
+	return g.validate(func(acc *GenericAccount) error {
+		if acc.APIKey == "" && len(acc.Fields) == 0 {
+			return errors.New("no API key or fields found")
+		}
+		return nil
+	})
+}
+
+// SaveProvider saves provider credentials to the config file. Secrets of accounts with a
+// secret store are saved to the store instead, and secrets of accounts that were removed
+// or moved to another store are deleted from their previous store. The file is replaced
+// atomically; callers changing credentials they loaded should do both within Update.
 func (m *Manager) SaveProvider(providerID string, data any) error {
 	if err := m.EnsureConfigDir(); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	configPath := m.providerPath(providerID)
+	configPath := m.ProviderPath(providerID)
 
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal credentials: %w", err)
 	}
 
-	if err := os.WriteFile(configPath, jsonData, 0600); err != nil {
+	jsonData, err = stampVersion(jsonData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	jsonData, err = m.storeSecrets(providerID, jsonData)
+	if err != nil {
+		return err
+	}
+
+	previous, _ := os.ReadFile(configPath) //nolint:gosec
+	if err := m.writeProviderFile(providerID, jsonData); err != nil {
 		return fmt.Errorf("failed to write credentials file: %w", err)
 	}
 
+	if previous != nil {
+		return m.deleteStaleSecrets(providerID, previous, jsonData)
+	}
 	return nil
 }
 
-// DeleteProvider deletes a provider's credential file
+// DeleteProvider deletes a provider's credential file and the secrets of its accounts
 func (m *Manager) DeleteProvider(providerID string) error {
-	configPath := m.providerPath(providerID)
+	configPath := m.ProviderPath(providerID)
+	previous, _ := os.ReadFile(configPath) //nolint:gosec
 	if err := os.Remove(configPath); err != nil {
 		if os.IsNotExist(err) {
 			return fmt.Errorf("no credentials found for provider %q", providerID)
 		}
 		return fmt.Errorf("failed to delete credentials file: %w", err)
 	}
-	return nil
+	_ = os.Remove(configPath + BackupSuffix)
+	m.Refresh()
+	return m.deleteStaleSecrets(providerID, previous, nil)
 }
 
-// ListAccounts returns all account names for a provider
+// ListAccounts returns all account names for a provider.
+// Claude accounts include those discovered in the Claude CLI configuration.
 func (m *Manager) ListAccounts(providerID string) ([]string, error) {
 	switch providerID {
 	case "claude":
-		creds, err := m.LoadClaude()
-		if err != nil {
-			return nil, err
+		discovered := m.DiscoverClaude()
+		if len(discovered) == 0 {
+			return nil, fmt.Errorf("no Claude credentials found")
 		}
-		return creds.ListAccounts(), nil
+		names := make([]string, 0, len(discovered))
+		for _, d := range discovered {
+			names = append(names, d.Name)
+		}
+		return names, nil
 	case "kimi":
 		creds, err := m.LoadKimi()
 		if err != nil {
@@ -485,8 +519,31 @@ func (m *Manager) ListAccounts(providerID string) ([]string, error) {
 			return nil, err
 		}
 		return creds.ListAccounts(), nil
+	case "openai":
+		creds, err := m.LoadOpenAI()
+		if err != nil {
+			return nil, err
+		}
+		return creds.ListAccounts(), nil
+	case "anthropic-api":
+		creds, err := m.LoadAnthropicAPI()
+		if err != nil {
+			return nil, err
+		}
+		return creds.ListAccounts(), nil
+	case "openrouter":
+		creds, err := m.LoadOpenRouter()
+		if err != nil {
+			return nil, err
+		}
+		return creds.ListAccounts(), nil
 	default:
-		return nil, fmt.Errorf("unknown provider: %s", providerID)
+		// Providers defined in the configuration file use the generic format
+		creds, err := m.LoadGeneric(providerID)
+		if err != nil {
+			return nil, err
+		}
+		return creds.ListAccounts(), nil
 	}
 }
 
@@ -498,33 +555,29 @@ func (m *Manager) MigrateFromClaudeCLI() error {
 	}
 
 	oldPath := filepath.Join(homeDir, ".claude", ".credentials.json")
-	newPath := m.providerPath("claude")
+	newPath := m.ProviderPath("claude")
 
 	// Check if old file exists
 	if _, err := os.Stat(oldPath); os.IsNotExist(err) {
 		return fmt.Errorf("old Claude credentials not found at %s", oldPath)
 	}
 
-	// Check if new file already exists
-	if _, err := os.Stat(newPath); err == nil {
-		return fmt.Errorf("new credentials already exist at %s", newPath)
-	}
-
-	// Ensure config directory exists
-	if err := m.EnsureConfigDir(); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
-	}
-
-	// Read old file
-	data, err := os.ReadFile(oldPath) //nolint:gosec
-	if err != nil {
-		return fmt.Errorf("failed to read old credentials: %w", err)
-	}
+	return m.Update(func() error {
+		// Check if new file already exists
+		if _, err := os.Stat(newPath); err == nil {
+			return fmt.Errorf("new credentials already exist at %s", newPath)
+		}
 
-	// Write new file
-	if err := os.WriteFile(newPath, data, 0600); err != nil {
-		return fmt.Errorf("failed to write new credentials: %w", err)
-	}
+		// Read old file
+		data, err := os.ReadFile(oldPath) //nolint:gosec
+		if err != nil {
+			return fmt.Errorf("failed to read old credentials: %w", err)
+		}
 
-	return nil
+		// Write new file
+		if err := m.writeProviderFile("claude", data); err != nil {
+			return fmt.Errorf("failed to write new credentials: %w", err)
+		}
+		return nil
+	})
 }
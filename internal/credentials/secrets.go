@@ -0,0 +1,323 @@
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// SecretStore keeps the secret fields of credential accounts outside the credentials files.
+// Secrets are addressed by references of the form "<provider>/<account>" and hold a JSON
+// object with the account's secret fields.
+type SecretStore interface {
+	// Name returns the backend name used in the accounts' secretStore field
+	Name() string
+	// Get returns the secret stored under ref, or ErrSecretNotFound
+	Get(ref string) (string, error)
+	// Set stores a secret under ref, replacing any previous value
+	Set(ref, secret string) error
+	// Delete removes the secret stored under ref; missing secrets are not an error
+	Delete(ref string) error
+}
+
+// ErrSecretNotFound is returned by SecretStore.Get for unknown references
+var ErrSecretNotFound = errors.New("secret not found")
+
+// Secret store backends, selected per account with the secretStore field
+const (
+	// StoreFile keeps secrets in plain text in the provider's credentials file (the default)
+	StoreFile          = "file"
+	StoreAge           = "age"
+	StorePass          = "pass"
+	StoreSecretService = "secret-service"
+)
+
+// SecretStores lists the available secret store backends
+var SecretStores = []string{StoreFile, StoreAge, StorePass, StoreSecretService}
+
+// secretFields lists the account fields kept in a secret store
+var secretFields = []string{"accessToken", "refreshToken", "apiKey", "cookie", "adminKey", "probeKey", "fields"}
+
+// rawAccounts is the accounts object of a credentials file, keeping unknown fields intact
+type rawAccounts map[string]map[string]json.RawMessage
+
+// secretRef returns the reference of an account's secrets
+func secretRef(providerID, accountName string) string {
+	return providerID + "/" + accountName
+}
+
+// accountStore returns the secret store named by an account's secretStore field,
+// or an empty string for secrets kept in the credentials file
+func accountStore(acc map[string]json.RawMessage) string {
+	var name string
+	if raw, ok := acc["secretStore"]; ok {
+		_ = json.Unmarshal(raw, &name)
+	}
+	return normalizeStore(name)
+}
+
+// normalizeStore returns a store name in the form returned by accountStore
+func normalizeStore(name string) string {
+	if name == StoreFile {
+		return ""
+	}
+	return name
+}
+
+// secretStore returns the backend with the given name, creating it on first use
+func (m *Manager) secretStore(name string) (SecretStore, error) {
+	m.storesMu.Lock()
+	defer m.storesMu.Unlock()
+
+	if store, ok := m.stores[name]; ok {
+		return store, nil
+	}
+
+	var store SecretStore
+	switch name {
+	case StoreAge:
+		store = m.newAgeStore()
+	case StorePass:
+		store = &passStore{command: "pass"}
+	case StoreSecretService:
+		store = &secretServiceStore{}
+	default:
+		return nil, fmt.Errorf("unknown secret store %q (valid: %s)", name, strings.Join(SecretStores, ", "))
+	}
+
+	if m.stores == nil {
+		m.stores = make(map[string]SecretStore)
+	}
+	m.stores[name] = store
+	return store, nil
+}
+
+// splitAccounts decodes a credentials file into its top-level fields and accounts
+func splitAccounts(data []byte) (map[string]json.RawMessage, rawAccounts, error) {
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, err
+	}
+	accounts := make(rawAccounts)
+	if raw, ok := file["accounts"]; ok {
+		if err := json.Unmarshal(raw, &accounts); err != nil {
+			return nil, nil, err
+		}
+	}
+	return file, accounts, nil
+}
+
+// resolveSecrets fills in the secret fields of accounts kept in a secret store
+func (m *Manager) resolveSecrets(providerID string, data []byte) ([]byte, error) {
+	file, accounts, err := splitAccounts(data)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := false
+	for _, name := range sortedNames(accounts) {
+		acc := accounts[name]
+		storeName := accountStore(acc)
+		if storeName == "" {
+			continue
+		}
+
+		store, err := m.secretStore(storeName)
+		if err != nil {
+			return nil, fmt.Errorf("account %q: %w", name, err)
+		}
+		secret, err := store.Get(secretRef(providerID, name))
+		if err != nil {
+			return nil, fmt.Errorf("account %q: failed to read secrets from %s: %w", name, storeName, err)
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+			return nil, fmt.Errorf("account %q: invalid secrets in %s: %w", name, storeName, err)
+		}
+		for key, value := range fields {
+			acc[key] = value
+		}
+		resolved = true
+	}
+	if !resolved {
+		return data, nil
+	}
+
+	if file["accounts"], err = json.Marshal(accounts); err != nil {
+		return nil, err
+	}
+	return json.Marshal(file)
+}
+
+// storeSecrets moves the secret fields of accounts using a secret store out of a
+// credentials file, saving them in their store
+func (m *Manager) storeSecrets(providerID string, data []byte) ([]byte, error) {
+	file, accounts, err := splitAccounts(data)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := false
+	for _, name := range sortedNames(accounts) {
+		acc := accounts[name]
+		storeName := accountStore(acc)
+		if storeName == "" {
+			continue
+		}
+
+		store, err := m.secretStore(storeName)
+		if err != nil {
+			return nil, fmt.Errorf("account %q: %w", name, err)
+		}
+
+		fields := make(map[string]json.RawMessage)
+		for _, key := range secretFields {
+			if value, ok := acc[key]; ok {
+				fields[key] = value
+				delete(acc, key)
+			}
+		}
+		secret, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		if err := store.Set(secretRef(providerID, name), string(secret)); err != nil {
+			return nil, fmt.Errorf("account %q: failed to save secrets to %s: %w", name, storeName, err)
+		}
+		stored = true
+	}
+	if !stored {
+		return data, nil
+	}
+
+	if file["accounts"], err = json.Marshal(accounts); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(file, "", "  ")
+}
+
+// deleteStaleSecrets removes secrets of accounts in old that no longer use the same store in
+// current, such as removed, renamed or migrated accounts. current may be nil.
+func (m *Manager) deleteStaleSecrets(providerID string, old, current []byte) error {
+	_, oldAccounts, err := splitAccounts(old)
+	if err != nil {
+		return nil //nolint:nilerr // An unreadable previous file has no secrets to clean up
+	}
+	currentAccounts := make(rawAccounts)
+	if current != nil {
+		if _, currentAccounts, err = splitAccounts(current); err != nil {
+			return err
+		}
+	}
+
+	var errs []error
+	for _, name := range sortedNames(oldAccounts) {
+		storeName := accountStore(oldAccounts[name])
+		if storeName == "" {
+			continue
+		}
+		if acc, ok := currentAccounts[name]; ok && accountStore(acc) == storeName {
+			continue
+		}
+
+		store, err := m.secretStore(storeName)
+		if err == nil {
+			err = store.Delete(secretRef(providerID, name))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("account %q: failed to delete secrets from %s: %w", name, storeName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MigrateSecrets moves accounts of a provider to the given secret store. An empty accountName
+// migrates all accounts; legacy single-account files are converted to the "default" account.
+// It returns the names of the migrated accounts.
+func (m *Manager) MigrateSecrets(providerID, accountName, storeName string) ([]string, error) {
+	if !slices.Contains(SecretStores, storeName) {
+		return nil, fmt.Errorf("unknown secret store %q (valid: %s)", storeName, strings.Join(SecretStores, ", "))
+	}
+
+	var migrated []string
+	err := m.Update(func() error {
+		var err error
+		migrated, err = m.migrateSecrets(providerID, accountName, storeName)
+		return err
+	})
+	return migrated, err
+}
+
+// migrateSecrets does the work of MigrateSecrets while the credentials are locked
+func (m *Manager) migrateSecrets(providerID, accountName, storeName string) ([]string, error) {
+	file, accounts, err := m.readAccounts(providerID)
+	if err != nil {
+		return nil, err
+	}
+	if accountName != "" && accounts[accountName] == nil {
+		return nil, fmt.Errorf("account '%s' not found", accountName)
+	}
+
+	target, _ := json.Marshal(storeName)
+	var migrated []string
+	for _, name := range sortedNames(accounts) {
+		if accountName != "" && name != accountName {
+			continue
+		}
+		acc := accounts[name]
+		if accountStore(acc) == normalizeStore(storeName) {
+			continue
+		}
+		if storeName == StoreFile {
+			delete(acc, "secretStore")
+		} else {
+			acc["secretStore"] = target
+		}
+		migrated = append(migrated, name)
+	}
+	if len(migrated) == 0 {
+		return nil, nil
+	}
+
+	return migrated, m.saveAccounts(providerID, file, accounts)
+}
+
+// readAccounts reads a provider's credentials file with the secrets of its accounts resolved,
+// upgrading files in an older format.
+func (m *Manager) readAccounts(providerID string) (map[string]json.RawMessage, rawAccounts, error) {
+	data, err := m.readProviderFile(providerID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("no credentials file for provider %q", providerID)
+		}
+		return nil, nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	if data, _, err = upgradeFile(data); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	if data, err = m.resolveSecrets(providerID, data); err != nil {
+		return nil, nil, err
+	}
+
+	file, accounts, err := splitAccounts(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	return file, accounts, nil
+}
+
+// legacyRawAccount converts a legacy single-account file into a "default" account. Claude
+// files copied from the Claude CLI only contribute their claudeAiOauth object, so that other
+// Claude CLI settings such as mcpOAuth do not become account fields.
+func legacyRawAccount(file map[string]json.RawMessage) (map[string]json.RawMessage, rawAccounts) {
+	acc := file
+	if oauth, ok := file["claudeAiOauth"]; ok {
+		acc = make(map[string]json.RawMessage)
+		_ = json.Unmarshal(oauth, &acc)
+	}
+	return make(map[string]json.RawMessage), rawAccounts{"default": acc}
+}
@@ -0,0 +1,314 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"filippo.io/age"
+	"golang.org/x/term"
+
+	"github.com/denysvitali/llm-usage/internal/fsutil"
+)
+
+// Environment variables configuring the age secret store
+const (
+	// AgeIdentityEnv names an age identity file; its X25519 recipients encrypt the store
+	AgeIdentityEnv = "LLM_USAGE_AGE_IDENTITY"
+	// AgePassphraseEnv holds the store's passphrase, which is prompted for otherwise
+	AgePassphraseEnv = "LLM_USAGE_AGE_PASSPHRASE"
+)
+
+// AgeSecretsFile is the age store's file in the configuration directory
+const AgeSecretsFile = "secrets.age"
+
+// ageStore keeps all secrets in one age-encrypted JSON file, encrypted either with a
+// passphrase or with the recipients of an identity file
+type ageStore struct {
+	path         string
+	identityFile string
+	passphrase   func(confirm bool) (string, error) // confirm asks twice, for a new store
+	workFactor   int                                // scrypt work factor (log2); zero uses age's default
+
+	mu     sync.Mutex
+	cached string // Passphrase read on first use
+
+	// Decrypted secrets file, kept while the file is unchanged since decrypting with a
+	// passphrase runs scrypt, which takes about a second
+	secrets map[string]string
+	modTime time.Time
+	size    int64
+}
+
+// newAgeStore returns the age store in the configuration directory
+func (m *Manager) newAgeStore() *ageStore {
+	s := &ageStore{path: filepath.Join(m.configDir, AgeSecretsFile)}
+	for _, kv := range m.getenv() {
+		key, value, _ := strings.Cut(kv, "=")
+		switch key {
+		case AgeIdentityEnv:
+			s.identityFile = value
+		case AgePassphraseEnv:
+			if value != "" {
+				s.passphrase = func(bool) (string, error) { return value, nil }
+			}
+		}
+	}
+	if s.passphrase == nil {
+		s.passphrase = func(confirm bool) (string, error) {
+			passphrase, err := PromptPassphrase("Secrets passphrase: ")
+			if errors.Is(err, ErrNoTerminal) {
+				return "", fmt.Errorf("%w; set %s", err, AgePassphraseEnv)
+			}
+			if err != nil || !confirm {
+				return passphrase, err
+			}
+			again, err := PromptPassphrase("Confirm passphrase: ")
+			if err != nil {
+				return "", err
+			}
+			if again != passphrase {
+				return "", errors.New("passphrases do not match")
+			}
+			return passphrase, nil
+		}
+	}
+	return s
+}
+
+// Name returns the backend name
+func (s *ageStore) Name() string {
+	return StoreAge
+}
+
+// Get returns the secret stored under ref
+func (s *ageStore) Get(ref string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[ref]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return secret, nil
+}
+
+// Set stores a secret under ref
+func (s *ageStore) Set(ref, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.read()
+	if err != nil {
+		return err
+	}
+	secrets[ref] = secret
+	return s.write(secrets)
+}
+
+// Delete removes the secret stored under ref
+func (s *ageStore) Delete(ref string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[ref]; !ok {
+		return nil
+	}
+	delete(secrets, ref)
+	return s.write(secrets)
+}
+
+// read decrypts the secrets file, unless it is unchanged since it was last read or written;
+// a missing file holds no secrets. The returned map is the caller's to change.
+func (s *ageStore) read() (map[string]string, error) {
+	secrets := make(map[string]string)
+
+	info, err := os.Stat(s.path)
+	if err == nil && s.secrets != nil && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return maps.Clone(s.secrets), nil
+	}
+	s.secrets = nil
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return secrets, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+
+	identities, err := s.identities()
+	if err != nil {
+		return nil, err
+	}
+	r, err := age.Decrypt(bytes.NewReader(data), identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", s.path, err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", s.path, err)
+	}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	if info != nil {
+		s.remember(secrets, info)
+	}
+	return maps.Clone(secrets), nil
+}
+
+// remember caches the decrypted secrets of the file described by info
+func (s *ageStore) remember(secrets map[string]string, info os.FileInfo) {
+	s.secrets, s.modTime, s.size = secrets, info.ModTime(), info.Size()
+}
+
+// write encrypts the secrets file, replacing it atomically
+func (s *ageStore) write(secrets map[string]string) error {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	recipients, err := s.recipients()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipients...)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+
+	s.secrets = nil
+	if err := fsutil.WriteFile(s.path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.remember(secrets, info)
+	}
+	return nil
+}
+
+// identities returns the identities decrypting the store
+func (s *ageStore) identities() ([]age.Identity, error) {
+	if s.identityFile != "" {
+		return s.parseIdentityFile()
+	}
+	passphrase, err := s.getPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return []age.Identity{identity}, nil
+}
+
+// recipients returns the recipients encrypting the store
+func (s *ageStore) recipients() ([]age.Recipient, error) {
+	if s.identityFile != "" {
+		identities, err := s.parseIdentityFile()
+		if err != nil {
+			return nil, err
+		}
+		var recipients []age.Recipient
+		for _, identity := range identities {
+			if x, ok := identity.(*age.X25519Identity); ok {
+				recipients = append(recipients, x.Recipient())
+			}
+		}
+		if len(recipients) == 0 {
+			return nil, fmt.Errorf("no X25519 identities in %s", s.identityFile)
+		}
+		return recipients, nil
+	}
+
+	// The passphrase of a new store is chosen now, so it is asked for twice
+	_, err := os.Stat(s.path)
+	passphrase, err := s.getPassphrase(os.IsNotExist(err))
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if s.workFactor > 0 {
+		recipient.SetWorkFactor(s.workFactor)
+	}
+	return []age.Recipient{recipient}, nil
+}
+
+// getPassphrase returns the store's passphrase, prompting for it at most once
+func (s *ageStore) getPassphrase(confirm bool) (string, error) {
+	if s.cached == "" {
+		passphrase, err := s.passphrase(confirm)
+		if err != nil {
+			return "", err
+		}
+		s.cached = passphrase
+	}
+	return s.cached, nil
+}
+
+// parseIdentityFile reads the identities of the configured identity file
+func (s *ageStore) parseIdentityFile() ([]age.Identity, error) {
+	f, err := os.Open(s.identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open age identity file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse age identity file: %w", err)
+	}
+	return identities, nil
+}
+
+// ErrNoTerminal is returned by PromptPassphrase when there is no terminal to prompt on
+var ErrNoTerminal = errors.New("no terminal to prompt for the passphrase")
+
+// PromptPassphrase reads a passphrase from the terminal without echoing it. The terminal
+// is used even when standard input is redirected.
+func PromptPassphrase(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", ErrNoTerminal
+	}
+	defer func() { _ = tty.Close() }()
+
+	_, _ = fmt.Fprint(tty, prompt)
+	passphrase, err := term.ReadPassword(int(tty.Fd())) //nolint:gosec // File descriptors fit in an int
+	_, _ = fmt.Fprintln(tty)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if len(passphrase) == 0 {
+		return "", errors.New("empty passphrase")
+	}
+	return string(passphrase), nil
+}
@@ -0,0 +1,211 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Secret Service D-Bus names
+const (
+	secretServiceName         = "org.freedesktop.secrets"
+	secretServicePath         = dbus.ObjectPath("/org/freedesktop/secrets")
+	secretServiceInterface    = "org.freedesktop.Secret.Service"
+	secretItemInterface       = "org.freedesktop.Secret.Item"
+	secretCollectionInterface = "org.freedesktop.Secret.Collection"
+	secretPromptInterface     = "org.freedesktop.Secret.Prompt"
+	secretSessionInterface    = "org.freedesktop.Secret.Session"
+	secretServiceAttribute    = "llm-usage"
+	secretServiceNoPrompt     = dbus.ObjectPath("/")
+	secretContentType         = "application/json"
+)
+
+// secretServiceSecret is the Secret struct of the Secret Service API
+type secretServiceSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// secretServiceStore keeps secrets in the freedesktop Secret Service (GNOME Keyring,
+// KWallet, KeePassXC) over the session D-Bus, in the default collection
+type secretServiceStore struct{}
+
+// Name returns the backend name
+func (s *secretServiceStore) Name() string {
+	return StoreSecretService
+}
+
+// secretServiceSession is an open plain-text transfer session with the Secret Service
+type secretServiceSession struct {
+	conn    *dbus.Conn
+	service dbus.BusObject
+	path    dbus.ObjectPath
+}
+
+// open connects to the session bus and opens a Secret Service session
+func (s *secretServiceStore) open() (*secretServiceSession, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the session bus: %w", err)
+	}
+
+	service := conn.Object(secretServiceName, secretServicePath)
+	var output dbus.Variant
+	var path dbus.ObjectPath
+	if err := service.Call(secretServiceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &path); err != nil {
+		return nil, fmt.Errorf("failed to open Secret Service session: %w", err)
+	}
+	return &secretServiceSession{conn: conn, service: service, path: path}, nil
+}
+
+// close closes the session; the shared bus connection stays open
+func (ss *secretServiceSession) close() {
+	_ = ss.conn.Object(secretServiceName, ss.path).Call(secretSessionInterface+".Close", 0).Err
+}
+
+// secretServiceAttributes returns the lookup attributes of a secret reference
+func secretServiceAttributes(ref string) map[string]string {
+	return map[string]string{"application": secretServiceAttribute, "ref": ref}
+}
+
+// search returns the unlocked items holding a secret reference
+func (ss *secretServiceSession) search(ref string) ([]dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	if err := ss.service.Call(secretServiceInterface+".SearchItems", 0, secretServiceAttributes(ref)).Store(&unlocked, &locked); err != nil {
+		return nil, fmt.Errorf("failed to search secrets: %w", err)
+	}
+	if len(locked) > 0 {
+		if err := ss.unlock(locked); err != nil {
+			return nil, err
+		}
+		unlocked = append(unlocked, locked...)
+	}
+	return unlocked, nil
+}
+
+// unlock unlocks items or collections, prompting the user if needed
+func (ss *secretServiceSession) unlock(paths []dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := ss.service.Call(secretServiceInterface+".Unlock", 0, paths).Store(&unlocked, &prompt); err != nil {
+		return fmt.Errorf("failed to unlock secrets: %w", err)
+	}
+	return ss.prompt(prompt)
+}
+
+// prompt shows a Secret Service prompt and waits until it completes
+func (ss *secretServiceSession) prompt(path dbus.ObjectPath) error {
+	if path == secretServiceNoPrompt || path == "" {
+		return nil
+	}
+
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface(secretPromptInterface),
+		dbus.WithMatchMember("Completed"),
+	}
+	if err := ss.conn.AddMatchSignal(match...); err != nil {
+		return fmt.Errorf("failed to watch prompt: %w", err)
+	}
+	defer func() { _ = ss.conn.RemoveMatchSignal(match...) }()
+
+	signals := make(chan *dbus.Signal, 1)
+	ss.conn.Signal(signals)
+	defer ss.conn.RemoveSignal(signals)
+
+	if err := ss.conn.Object(secretServiceName, path).Call(secretPromptInterface+".Prompt", 0, "").Err; err != nil {
+		return fmt.Errorf("failed to show prompt: %w", err)
+	}
+	for signal := range signals {
+		if signal.Path != path || signal.Name != secretPromptInterface+".Completed" {
+			continue
+		}
+		if dismissed, _ := signal.Body[0].(bool); dismissed {
+			return errors.New("prompt dismissed")
+		}
+		return nil
+	}
+	return errors.New("session bus closed")
+}
+
+// Get returns the secret stored under ref
+func (s *secretServiceStore) Get(ref string) (string, error) {
+	ss, err := s.open()
+	if err != nil {
+		return "", err
+	}
+	defer ss.close()
+
+	items, err := ss.search(ref)
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", ErrSecretNotFound
+	}
+
+	var secret secretServiceSecret
+	if err := ss.conn.Object(secretServiceName, items[0]).Call(secretItemInterface+".GetSecret", 0, ss.path).Store(&secret); err != nil {
+		return "", fmt.Errorf("failed to read secret: %w", err)
+	}
+	return string(secret.Value), nil
+}
+
+// Set stores a secret under ref in the default collection
+func (s *secretServiceStore) Set(ref, secret string) error {
+	ss, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer ss.close()
+
+	var collection dbus.ObjectPath
+	if err := ss.service.Call(secretServiceInterface+".ReadAlias", 0, "default").Store(&collection); err != nil {
+		return fmt.Errorf("failed to find the default collection: %w", err)
+	}
+	if collection == secretServiceNoPrompt {
+		return errors.New("no default Secret Service collection")
+	}
+	if err := ss.unlock([]dbus.ObjectPath{collection}); err != nil {
+		return err
+	}
+
+	properties := map[string]dbus.Variant{
+		"org.freedesktop.Secret.Item.Label":      dbus.MakeVariant("llm-usage: " + ref),
+		"org.freedesktop.Secret.Item.Attributes": dbus.MakeVariant(secretServiceAttributes(ref)),
+	}
+	value := secretServiceSecret{Session: ss.path, Value: []byte(secret), ContentType: secretContentType}
+
+	var item, prompt dbus.ObjectPath
+	if err := ss.conn.Object(secretServiceName, collection).Call(secretCollectionInterface+".CreateItem", 0, properties, value, true).Store(&item, &prompt); err != nil {
+		return fmt.Errorf("failed to save secret: %w", err)
+	}
+	return ss.prompt(prompt)
+}
+
+// Delete removes the secret stored under ref
+func (s *secretServiceStore) Delete(ref string) error {
+	ss, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer ss.close()
+
+	items, err := ss.search(ref)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+		if err := ss.conn.Object(secretServiceName, item).Call(secretItemInterface+".Delete", 0).Store(&prompt); err != nil {
+			return fmt.Errorf("failed to delete secret: %w", err)
+		}
+		if err := ss.prompt(prompt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// passPrefix is the password-store folder holding llm-usage secrets
+const passPrefix = "llm-usage/"
+
+// passStore keeps secrets in the standard Unix password manager through its CLI,
+// one entry per account under llm-usage/<provider>/<account>
+type passStore struct {
+	command string // pass executable
+}
+
+// Name returns the backend name
+func (s *passStore) Name() string {
+	return StorePass
+}
+
+// Get returns the secret stored under ref
+func (s *passStore) Get(ref string) (string, error) {
+	out, err := s.run(nil, "show", passPrefix+ref)
+	if err != nil {
+		if strings.Contains(err.Error(), "is not in the password store") {
+			return "", ErrSecretNotFound
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+// Set stores a secret under ref
+func (s *passStore) Set(ref, secret string) error {
+	_, err := s.run(strings.NewReader(secret+"\n"), "insert", "--multiline", "--force", passPrefix+ref)
+	return err
+}
+
+// Delete removes the secret stored under ref
+func (s *passStore) Delete(ref string) error {
+	_, err := s.run(nil, "rm", "--force", passPrefix+ref)
+	if err != nil && strings.Contains(err.Error(), "is not in the password store") {
+		return nil
+	}
+	return err
+}
+
+// run executes a pass subcommand and returns its standard output
+func (s *passStore) run(stdin *strings.Reader, args ...string) (string, error) {
+	cmd := exec.Command(s.command, args...) //nolint:gosec // Arguments are built from account names
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("%s not found; install password-store to use the pass secret store", s.command)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("pass %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("pass %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,210 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakePass writes a pass executable keeping entries as files in a temporary directory
+func fakePass(t *testing.T) (command, dir string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake pass requires a POSIX shell")
+	}
+
+	dir = t.TempDir()
+	script := fmt.Sprintf(`#!/bin/sh
+store=%q
+case "$1" in
+show) [ -f "$store/$2" ] || { echo "Error: $2 is not in the password store." >&2; exit 1; }; cat "$store/$2" ;;
+insert) mkdir -p "$(dirname "$store/$4")"; cat > "$store/$4" ;;
+rm) [ -f "$store/$3" ] || { echo "Error: $3 is not in the password store." >&2; exit 1; }; rm "$store/$3" ;;
+esac
+`, filepath.Join(dir, "store"))
+
+	command = filepath.Join(dir, "pass")
+	if err := os.WriteFile(command, []byte(script), 0o700); err != nil { //nolint:gosec // Test executable
+		t.Fatal(err)
+	}
+	return command, filepath.Join(dir, "store")
+}
+
+func readCredentials(t *testing.T, m *Manager, providerID string) string {
+	t.Helper()
+	data, err := os.ReadFile(m.ProviderPath(providerID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSecretStore_Age(t *testing.T) {
+	m := newEnvManager(t, AgePassphraseEnv+"=correct horse")
+	store := m.newAgeStore()
+	store.workFactor = 10
+	m.stores = map[string]SecretStore{StoreAge: store}
+
+	writeCredentials(t, m, "openai", `{"accounts":{"default":{"adminKey":"sk-admin-default","dailyBudget":10},"work":{"adminKey":"sk-admin-work"}}}`)
+
+	migrated, err := m.MigrateSecrets("openai", "", StoreAge)
+	if err != nil {
+		t.Fatalf("MigrateSecrets() error = %v", err)
+	}
+	if !slices.Equal(migrated, []string{"default", "work"}) {
+		t.Errorf("migrated = %v", migrated)
+	}
+
+	file := readCredentials(t, m, "openai")
+	if strings.Contains(file, "sk-admin") || !strings.Contains(file, `"dailyBudget": 10`) {
+		t.Errorf("credentials file still holds secrets or lost fields:\n%s", file)
+	}
+	encrypted, err := os.ReadFile(store.path)
+	if err != nil || strings.Contains(string(encrypted), "sk-admin") {
+		t.Fatalf("secrets file = %q, %v", encrypted, err)
+	}
+
+	creds, err := m.LoadOpenAI()
+	if err != nil {
+		t.Fatalf("LoadOpenAI() error = %v", err)
+	}
+	if acc := creds.GetAccount("work"); acc == nil || acc.AdminKey != "sk-admin-work" {
+		t.Errorf("work account = %+v", acc)
+	}
+
+	// Removing an account deletes its secrets
+	delete(creds.Accounts, "work")
+	if err := m.SaveProvider("openai", creds); err != nil {
+		t.Fatalf("SaveProvider() error = %v", err)
+	}
+	if _, err := store.Get("openai/work"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Get(openai/work) error = %v, want ErrSecretNotFound", err)
+	}
+
+	// A wrong passphrase cannot read the store
+	wrong := newEnvManager(t, AgePassphraseEnv+"=battery staple").newAgeStore()
+	wrong.path = store.path
+	if _, err := wrong.Get("openai/default"); err == nil {
+		t.Error("Get() with a wrong passphrase expected error")
+	}
+}
+
+func TestSecretStore_AgeCachesSecrets(t *testing.T) {
+	store := newEnvManager(t, AgePassphraseEnv+"=correct horse").newAgeStore()
+	store.workFactor = 10
+	if err := store.Set("kimi/default", "sk-kimi"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	info, err := os.Stat(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An unchanged file is not decrypted again
+	if err := os.WriteFile(store.path, make([]byte, info.Size()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(store.path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if secret, err := store.Get("kimi/default"); err != nil || secret != "sk-kimi" {
+		t.Errorf("Get() of the unchanged file = %q, %v", secret, err)
+	}
+
+	// A file changed by another process is
+	later := info.ModTime().Add(time.Second)
+	if err := os.Chtimes(store.path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("kimi/default"); err == nil {
+		t.Error("Get() of the changed file returned the cached secrets")
+	}
+}
+
+func TestSecretStore_AgeConfirmsNewPassphrase(t *testing.T) {
+	var confirms []bool
+	store := newEnvManager(t).newAgeStore()
+	store.workFactor = 10
+	store.passphrase = func(confirm bool) (string, error) {
+		confirms = append(confirms, confirm)
+		return "correct horse", nil
+	}
+
+	if err := store.Set("kimi/default", "sk-kimi"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	// A second process opens the existing store
+	store.cached, store.secrets = "", nil
+	if secret, err := store.Get("kimi/default"); err != nil || secret != "sk-kimi" {
+		t.Fatalf("Get() = %q, %v", secret, err)
+	}
+	if err := store.Set("kimi/work", "sk-kimi-work"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if !slices.Equal(confirms, []bool{true, false}) {
+		t.Errorf("passphrase prompts confirm = %v, want [true false]", confirms)
+	}
+}
+
+func TestSecretStore_PassMigration(t *testing.T) {
+	command, dir := fakePass(t)
+	m := newEnvManager(t)
+	m.stores = map[string]SecretStore{StorePass: &passStore{command: command}}
+
+	writeCredentials(t, m, "claude", `{"claudeAiOauth":{"accessToken":"sk-ant-oat-file","refreshToken":"sk-ant-ort-file","expiresAt":1700000000000}}`)
+
+	if _, err := m.MigrateSecrets("claude", "work", StorePass); err == nil {
+		t.Error("MigrateSecrets() expected error for an unknown account")
+	}
+	if _, err := m.MigrateSecrets("claude", "", "vault"); err == nil {
+		t.Error("MigrateSecrets() expected error for an unknown store")
+	}
+
+	// Legacy files become a default account whose secrets move to pass
+	if _, err := m.MigrateSecrets("claude", "", StorePass); err != nil {
+		t.Fatalf("MigrateSecrets() error = %v", err)
+	}
+	if file := readCredentials(t, m, "claude"); strings.Contains(file, "sk-ant") || !strings.Contains(file, `"secretStore": "pass"`) {
+		t.Errorf("credentials file:\n%s", file)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "llm-usage", "claude", "default")); err != nil {
+		t.Errorf("pass entry missing: %v", err)
+	}
+
+	creds, err := m.LoadClaude()
+	if err != nil {
+		t.Fatalf("LoadClaude() error = %v", err)
+	}
+	if acc := creds.GetAccount("default"); acc == nil || acc.AccessToken != "sk-ant-oat-file" || acc.ExpiresAt != 1700000000000 {
+		t.Errorf("default account = %+v", acc)
+	}
+
+	// Moving back to the file restores plain-text secrets and removes the pass entry
+	if _, err := m.MigrateSecrets("claude", "default", StoreFile); err != nil {
+		t.Fatalf("MigrateSecrets(file) error = %v", err)
+	}
+	if file := readCredentials(t, m, "claude"); !strings.Contains(file, "sk-ant-oat-file") || strings.Contains(file, "secretStore") {
+		t.Errorf("credentials file:\n%s", file)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "llm-usage", "claude", "default")); !os.IsNotExist(err) {
+		t.Errorf("pass entry not deleted: %v", err)
+	}
+}
+
+func TestSecretStore_PassMissingEntry(t *testing.T) {
+	command, _ := fakePass(t)
+	m := newEnvManager(t)
+	m.stores = map[string]SecretStore{StorePass: &passStore{command: command}}
+
+	writeCredentials(t, m, "kimi", `{"accounts":{"default":{"secretStore":"pass"}}}`)
+	if _, err := m.LoadKimi(); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("LoadKimi() error = %v, want %v", err, ErrSecretNotFound)
+	}
+}
@@ -0,0 +1,43 @@
+package doctor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// cookieExpiry returns the earliest expiry of the JWTs among the values of a Cookie
+// header, as MiniMax keeps its session in a JWT cookie
+func cookieExpiry(cookie string) (time.Time, bool) {
+	var earliest time.Time
+	for _, pair := range strings.Split(cookie, ";") {
+		_, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		if exp, ok := jwtExpiry(value); ok && (earliest.IsZero() || exp.Before(earliest)) {
+			earliest = exp
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+// jwtExpiry returns the exp claim of a JWT, without verifying its signature
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(claims.Exp), 0), true
+}
@@ -0,0 +1,444 @@
+// Package doctor diagnoses the llm-usage directories, configuration, credentials and accounts.
+package doctor
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/config"
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+// Status is the outcome of a check
+type Status string
+
+// Check outcomes
+const (
+	StatusOK    Status = "ok"
+	StatusWarn  Status = "warn"
+	StatusError Status = "error"
+)
+
+// Sections group the checks of a report, in this order
+const (
+	SectionDirectories = "directories"
+	SectionConfig      = "config"
+	SectionCredentials = "credentials"
+	SectionAccounts    = "accounts"
+)
+
+var sections = []string{SectionDirectories, SectionConfig, SectionCredentials, SectionAccounts}
+
+// cookieMaxAge is the age after which a MiniMax session cookie has likely expired
+const cookieMaxAge = 30 * 24 * time.Hour
+
+// Check is the result of one diagnostic
+type Check struct {
+	Section   string     `json:"section"`
+	Name      string     `json:"name"` // What was checked: a directory, a file or provider/account
+	Status    Status     `json:"status"`
+	Message   string     `json:"message"`
+	Fix       string     `json:"fix,omitempty"` // Action resolving a warning or error
+	Path      string     `json:"path,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Token or cookie expiry, when known
+}
+
+// Report holds the checks of a doctor run
+type Report struct {
+	Checks   []Check `json:"checks"`
+	Errors   int     `json:"errors"`
+	Warnings int     `json:"warnings"`
+}
+
+func (r *Report) add(c Check) {
+	switch c.Status {
+	case StatusError:
+		r.Errors++
+	case StatusWarn:
+		r.Warnings++
+	}
+	r.Checks = append(r.Checks, c)
+}
+
+// Doctor checks an llm-usage installation
+type Doctor struct {
+	Credentials *credentials.Manager // Should be read-only, so that checks change no files
+	ConfigPath  string               // config.yaml
+	CacheDir    string
+
+	// Validate fetches the usage of every account to check its credentials with the provider
+	Validate bool
+
+	now func() time.Time
+}
+
+// Run performs all checks
+func (d *Doctor) Run() *Report {
+	r := &Report{Checks: []Check{}}
+
+	d.checkDir(r, "config directory", d.Credentials.ConfigDir(), true)
+	d.checkDir(r, "cache directory", d.CacheDir, false)
+	cfg := d.checkConfig(r)
+	d.checkCredentialFiles(r, cfg)
+	d.checkClaude(r)
+	d.checkMiniMax(r)
+	if d.Validate {
+		d.validateAccounts(r, cfg)
+	}
+
+	slices.SortStableFunc(r.Checks, func(a, b Check) int {
+		if c := cmp.Compare(slices.Index(sections, a.Section), slices.Index(sections, b.Section)); c != 0 {
+			return c
+		}
+		if a.Section == SectionAccounts {
+			return cmp.Compare(a.Name, b.Name)
+		}
+		return 0
+	})
+	return r
+}
+
+func (d *Doctor) clock() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
+}
+
+// checkModes reports whether file modes are meaningful on this platform
+func checkModes() bool {
+	return runtime.GOOS != "windows"
+}
+
+// checkDir checks that a directory is writable and, for private directories, not
+// accessible to other users
+func (d *Doctor) checkDir(r *Report, name, path string, private bool) {
+	check := Check{Section: SectionDirectories, Name: name, Path: path}
+
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		if private {
+			check.Status, check.Message = StatusWarn, "does not exist"
+			check.Fix = "Run 'llm-usage setup' to add an account"
+		} else {
+			check.Status, check.Message = StatusOK, "not created yet"
+		}
+		r.add(check)
+		return
+	case err != nil:
+		check.Status, check.Message = StatusError, err.Error()
+		r.add(check)
+		return
+	case !info.IsDir():
+		check.Status, check.Message = StatusError, "is not a directory"
+		check.Fix = fmt.Sprintf("Move %s out of the way", path)
+		r.add(check)
+		return
+	}
+
+	if err := checkWritable(path); err != nil {
+		check.Status, check.Message = StatusError, fmt.Sprintf("is not writable: %v", err)
+		check.Fix = fmt.Sprintf("chmod u+rwx %s", path)
+	} else if perm := info.Mode().Perm(); private && checkModes() && perm&0o077 != 0 {
+		check.Status, check.Message = StatusWarn, fmt.Sprintf("has mode %04o; other users can list it", perm)
+		check.Fix = fmt.Sprintf("chmod 700 %s", path)
+	} else {
+		check.Status, check.Message = StatusOK, "writable"
+	}
+	r.add(check)
+}
+
+// checkWritable creates and removes a file in dir
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkConfig loads config.yaml. An invalid file is reported and replaced by an empty configuration.
+func (d *Doctor) checkConfig(r *Report) *config.Config {
+	check := Check{Section: SectionConfig, Name: config.FileName, Path: d.ConfigPath}
+
+	cfg, err := config.LoadFromPath(d.ConfigPath)
+	switch {
+	case err != nil:
+		check.Status, check.Message = StatusError, err.Error()
+		check.Fix = fmt.Sprintf("Fix %s; see the Configuration section of the README", d.ConfigPath)
+		cfg = &config.Config{}
+	case !fileExists(d.ConfigPath):
+		check.Status, check.Message = StatusOK, "not present; using defaults"
+	default:
+		check.Status, check.Message = StatusOK, fmt.Sprintf("valid, %d configured provider(s)", len(cfg.Providers))
+	}
+	r.add(check)
+	return cfg
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// checkCredentialFiles checks the mode and contents of every credentials file and the age store,
+// and reports corrupted files that were replaced by their backup
+func (d *Doctor) checkCredentialFiles(r *Report, cfg *config.Config) {
+	entries, err := os.ReadDir(d.Credentials.ConfigDir())
+	if err != nil {
+		return // Reported by the directory check
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasSuffix(name, ".json"+credentials.CorruptSuffix) {
+			r.add(Check{
+				Section: SectionCredentials,
+				Name:    name,
+				Path:    filepath.Join(d.Credentials.ConfigDir(), name),
+				Status:  StatusWarn,
+				Message: "corrupted credentials file, replaced by its backup",
+				Fix:     "Check the restored accounts with 'llm-usage setup list', then delete the corrupted copy",
+			})
+			continue
+		}
+		if entry.IsDir() || (filepath.Ext(name) != ".json" && name != credentials.AgeSecretsFile) {
+			continue
+		}
+		path := filepath.Join(d.Credentials.ConfigDir(), name)
+		d.checkFileMode(r, name, path)
+		if providerID, ok := strings.CutSuffix(name, ".json"); ok {
+			d.checkCredentials(r, cfg, providerID, path)
+		}
+	}
+}
+
+// checkFileMode warns about secrets files readable by other users
+func (d *Doctor) checkFileMode(r *Report, name, path string) {
+	info, err := os.Stat(path)
+	if err != nil || !checkModes() {
+		return
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		r.add(Check{
+			Section: SectionCredentials,
+			Name:    name,
+			Path:    path,
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("has mode %04o, want 0600", perm),
+			Fix:     fmt.Sprintf("chmod 600 %s", path),
+		})
+	}
+}
+
+// checkCredentials parses a provider's credentials file and reports files in an older format
+func (d *Doctor) checkCredentials(r *Report, cfg *config.Config, providerID, path string) {
+	check := Check{Section: SectionCredentials, Name: providerID + ".json", Path: path}
+
+	if !config.IsBuiltin(providerID) && cfg.Provider(providerID) == nil {
+		check.Status = StatusWarn
+		check.Message = fmt.Sprintf("unused: %q is neither a built-in nor a configured provider", providerID)
+		check.Fix = fmt.Sprintf("Define %q in %s or delete the file", providerID, config.FileName)
+		r.add(check)
+		return
+	}
+
+	var accounts []string
+	var err error
+	if providerID == "claude" {
+		// ListAccounts would include the accounts discovered in the Claude CLI configuration
+		var creds *credentials.ClaudeCredentials
+		if creds, err = d.Credentials.LoadClaude(); err == nil {
+			accounts = creds.ListAccounts()
+		}
+	} else {
+		accounts, err = d.Credentials.ListAccounts(providerID)
+	}
+	if err != nil {
+		check.Status, check.Message = StatusError, err.Error()
+		check.Fix = fmt.Sprintf("Fix or delete %s, then run 'llm-usage setup add %s'", path, providerID)
+		r.add(check)
+		return
+	}
+
+	slices.Sort(accounts)
+	check.Status = StatusOK
+	check.Message = fmt.Sprintf("%d account(s): %s", len(accounts), strings.Join(accounts, ", "))
+	if legacy, err := d.Credentials.NeedsMigration(providerID); err == nil && legacy {
+		check.Status = StatusWarn
+		check.Message += fmt.Sprintf("; legacy format, older than version %d", credentials.FormatVersion)
+		check.Fix = "Run 'llm-usage setup list' to migrate the credentials files to the current format"
+	}
+	r.add(check)
+}
+
+// checkClaude reports the token expiry of the discovered Claude accounts and accounts
+// found in several sources
+func (d *Doctor) checkClaude(r *Report) {
+	now := d.clock()
+	for _, disc := range d.Credentials.DiscoverClaude() {
+		name := "claude/" + disc.Name
+		check := Check{Section: SectionAccounts, Name: name, Path: disc.Sources[0].Path}
+
+		switch {
+		case disc.Credentials.ExpiresAt == 0:
+			check.Status, check.Message = StatusOK, "token expiry unknown"
+		default:
+			expiresAt := time.UnixMilli(disc.Credentials.ExpiresAt)
+			check.ExpiresAt = &expiresAt
+			if now.After(expiresAt) {
+				check.Status = StatusError
+				check.Message = fmt.Sprintf("token expired %s ago", usage.FormatDuration(now.Sub(expiresAt)))
+				check.Fix = d.loginFix("claude", disc.Name)
+			} else {
+				check.Status = StatusOK
+				check.Message = fmt.Sprintf("token expires in %s", usage.FormatDuration(expiresAt.Sub(now)))
+			}
+		}
+		r.add(check)
+
+		if len(disc.Sources) > 1 {
+			r.add(duplicateClaudeCheck(name, disc))
+		}
+	}
+}
+
+// duplicateClaudeCheck warns about an account found in several sources
+func duplicateClaudeCheck(name string, disc *credentials.ClaudeDiscovery) Check {
+	sources := make([]string, 0, len(disc.Sources))
+	fromFile := false
+	for _, src := range disc.Sources {
+		if src.Kind == credentials.SourceFile || src.Kind == credentials.SourceFileEnv {
+			fromFile = true
+		}
+		if src.Path != "" {
+			sources = append(sources, fmt.Sprintf("%s (%s)", src.Kind, src.Path))
+		} else {
+			sources = append(sources, src.Kind)
+		}
+	}
+
+	check := Check{
+		Section: SectionAccounts,
+		Name:    name,
+		Status:  StatusWarn,
+		Message: fmt.Sprintf("same login in %d sources, the freshest token is used: %s", len(disc.Sources), strings.Join(sources, ", ")),
+		Fix:     "Remove the copy from all but one source",
+	}
+	if fromFile {
+		check.Fix = fmt.Sprintf("Run 'llm-usage setup remove claude %s'; the Claude CLI keeps its own copy up to date", disc.Name)
+	}
+	return check
+}
+
+// checkMiniMax reports the expiry or age of the MiniMax session cookies
+func (d *Doctor) checkMiniMax(r *Report) {
+	creds, err := d.Credentials.LoadMiniMax()
+	if err != nil {
+		return // Reported by the credentials check
+	}
+	path := d.Credentials.ProviderPath("minimax")
+	info, statErr := os.Stat(path)
+	now := d.clock()
+
+	for _, accountName := range creds.ListAccounts() {
+		acc := creds.GetAccount(accountName)
+		if acc == nil {
+			continue
+		}
+		check := Check{Section: SectionAccounts, Name: "minimax/" + accountName}
+
+		if expiresAt, ok := cookieExpiry(acc.Cookie); ok {
+			check.ExpiresAt = &expiresAt
+			if now.After(expiresAt) {
+				check.Status = StatusError
+				check.Message = fmt.Sprintf("session cookie expired %s ago", usage.FormatDuration(now.Sub(expiresAt)))
+				check.Fix = d.loginFix("minimax", accountName)
+			} else {
+				check.Status = StatusOK
+				check.Message = fmt.Sprintf("session cookie expires in %s", usage.FormatDuration(expiresAt.Sub(now)))
+			}
+			r.add(check)
+			continue
+		}
+
+		// Without an expiry in the cookie, the file's modification time tells when it was saved
+		if statErr != nil || d.Credentials.AccountSource("minimax", accountName) == credentials.SourceEnv {
+			continue
+		}
+		check.Path = path
+		age := now.Sub(info.ModTime())
+		if age > cookieMaxAge {
+			check.Status = StatusWarn
+			check.Message = fmt.Sprintf("session cookie saved %s ago and may have expired", usage.FormatDuration(age))
+			check.Fix = d.loginFix("minimax", accountName)
+		} else {
+			check.Status = StatusOK
+			check.Message = fmt.Sprintf("session cookie saved %s ago", usage.FormatDuration(age))
+		}
+		r.add(check)
+	}
+}
+
+// validateAccounts fetches the usage of every account concurrently
+func (d *Doctor) validateAccounts(r *Report, cfg *config.Config) {
+	instances := usage.GetProviders("all", "", true, d.Credentials, cfg)
+	checks := make([]Check, len(instances))
+
+	var wg sync.WaitGroup
+	for i, inst := range instances {
+		wg.Add(1)
+		go func(idx int, inst usage.ProviderInstance) {
+			defer wg.Done()
+			checks[idx] = d.validate(inst)
+		}(i, inst)
+	}
+	wg.Wait()
+
+	for _, check := range checks {
+		r.add(check)
+	}
+}
+
+// validate fetches the usage of one account
+func (d *Doctor) validate(inst usage.ProviderInstance) Check {
+	check := Check{Section: SectionAccounts, Name: inst.ID() + "/" + cmp.Or(inst.AccountName, "default")}
+
+	u, err := inst.GetUsage()
+	if err == nil && u != nil && u.Error != nil {
+		err = u.Error
+	}
+	if err != nil {
+		check.Status = StatusError
+		check.Message = fmt.Sprintf("validation failed: %v", err)
+		check.Fix = d.loginFix(inst.ID(), inst.AccountName)
+		return check
+	}
+	check.Status, check.Message = StatusOK, "credentials accepted by the provider"
+	return check
+}
+
+// loginFix tells how to renew an account's credentials, depending on where they come from
+func (d *Doctor) loginFix(providerID, accountName string) string {
+	source, _, _ := strings.Cut(d.Credentials.AccountSource(providerID, accountName), "+")
+	switch source {
+	case credentials.SourceEnv:
+		return fmt.Sprintf("Update the LLM_USAGE_* environment variables of %s account %q", providerID, accountName)
+	case credentials.SourceClaudeCLI, credentials.SourceKeychain:
+		return "Run 'claude' and log in again"
+	case credentials.SourceClaudeConfigDir:
+		return fmt.Sprintf("Run 'claude' with %s set to the account's directory and log in again", credentials.ClaudeConfigDirEnv)
+	default:
+		return fmt.Sprintf("Run 'llm-usage setup add %s --account %s'", providerID, accountName)
+	}
+}
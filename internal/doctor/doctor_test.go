@@ -0,0 +1,186 @@
+package doctor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/denysvitali/llm-usage/internal/config"
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/mockserver"
+)
+
+var testNow = time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+// newTestDoctor returns a doctor for an llm-usage config directory and home directory in t.TempDir
+func newTestDoctor(t *testing.T) (*Doctor, string, string) {
+	t.Helper()
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+	t.Setenv(credentials.ClaudeConfigDirEnv, "")
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+
+	mgr := credentials.NewReadOnlyManager()
+	if err := mgr.EnsureConfigDir(); err != nil {
+		t.Fatal(err)
+	}
+	d := &Doctor{
+		Credentials: mgr,
+		ConfigPath:  filepath.Join(mgr.ConfigDir(), config.FileName),
+		CacheDir:    filepath.Join(root, "cache"),
+		now:         func() time.Time { return testNow },
+	}
+	return d, mgr.ConfigDir(), home
+}
+
+func writeFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// findCheck returns the check of a name with a status
+func findCheck(r *Report, name string, status Status) *Check {
+	for i, c := range r.Checks {
+		if c.Name == name && c.Status == status {
+			return &r.Checks[i]
+		}
+	}
+	return nil
+}
+
+func testJWT(exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+	return "eyJhbGciOiJIUzI1NiJ9." + payload + ".c2ln"
+}
+
+func TestDoctor_Offline(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not checked on Windows")
+	}
+	d, dir, home := newTestDoctor(t)
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	expired := testNow.Add(-2 * time.Hour).UnixMilli()
+	token := fmt.Sprintf(`{"accessToken":"sk-ant-oat-1","refreshToken":"sk-ant-ort-1","expiresAt":%d}`, expired)
+	writeFile(t, filepath.Join(dir, "claude.json"), `{"version":2,"accounts":{"default":`+token+`}}`, 0o644)
+	writeFile(t, filepath.Join(home, ".claude", ".credentials.json"), `{"claudeAiOauth":`+token+`}`, 0o600)
+	minimax := `{"cookie":"_token=` + testJWT(testNow.Add(-time.Hour)) + `; lang=en","groupId":"42"}`
+	writeFile(t, filepath.Join(dir, "minimax.json"), minimax, 0o600)
+	writeFile(t, filepath.Join(dir, "kimi.json"), `{"apiKey":`, 0o600)
+	writeFile(t, filepath.Join(dir, "openai.json"), `{"accounts":`, 0o600)
+	writeFile(t, filepath.Join(dir, "openai.json.bak"), `{"version":2,"accounts":{"default":{"adminKey":"sk-admin"}}}`, 0o600)
+	writeFile(t, filepath.Join(dir, "orphan.json"), `{}`, 0o600)
+	writeFile(t, filepath.Join(dir, "zai.json.corrupt"), `{"apiKey":`, 0o600)
+
+	r := d.Run()
+
+	// The doctor neither migrates legacy files nor repairs corrupted ones
+	for name, want := range map[string]string{"minimax.json": minimax, "openai.json": `{"accounts":`} {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want it unchanged", name, data, err)
+		}
+	}
+	for _, name := range []string{".lock", "minimax.json.bak", "openai.json.corrupt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("doctor created %s", name)
+		}
+	}
+
+	for _, want := range []struct {
+		name   string
+		status Status
+		fix    string
+	}{
+		{"config directory", StatusWarn, "chmod 700 " + dir},
+		{"cache directory", StatusOK, ""},
+		{"config.yaml", StatusOK, ""},
+		{"claude.json", StatusWarn, "chmod 600 " + filepath.Join(dir, "claude.json")},
+		{"claude.json", StatusOK, ""},
+		{"minimax.json", StatusWarn, "llm-usage setup list"},
+		{"openai.json", StatusOK, ""},
+		{"kimi.json", StatusError, "llm-usage setup add kimi"},
+		{"orphan.json", StatusWarn, "Define \"orphan\""},
+		{"zai.json.corrupt", StatusWarn, "delete the corrupted copy"},
+		{"claude/default", StatusError, "llm-usage setup add claude --account default"},
+		{"claude/default", StatusWarn, "llm-usage setup remove claude default"},
+		{"minimax/default", StatusError, "llm-usage setup add minimax --account default"},
+	} {
+		c := findCheck(r, want.name, want.status)
+		if c == nil {
+			t.Errorf("no %s check for %s in %+v", want.status, want.name, r.Checks)
+			continue
+		}
+		if !strings.Contains(c.Fix, want.fix) {
+			t.Errorf("%s fix = %q, want %q", want.name, c.Fix, want.fix)
+		}
+	}
+	if r.Errors != 3 || r.Warnings != 6 {
+		t.Errorf("errors = %d, warnings = %d", r.Errors, r.Warnings)
+	}
+	if c := findCheck(r, "claude/default", StatusWarn); c != nil && !strings.Contains(c.Message, "claude-cli") {
+		t.Errorf("duplicate message = %q", c.Message)
+	}
+}
+
+func TestDoctor_Validate(t *testing.T) {
+	d, dir, _ := newTestDoctor(t)
+	d.Validate = true
+
+	mock := httptest.NewServer(mockserver.NewServer(&mockserver.Config{Period: time.Hour}).Handler())
+	t.Cleanup(mock.Close)
+	unauthorized := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":{"message":"invalid key"}}`, http.StatusUnauthorized)
+	}))
+	t.Cleanup(unauthorized.Close)
+
+	expiresAt := time.Now().Add(time.Hour).UnixMilli()
+	writeFile(t, filepath.Join(dir, "claude.json"), fmt.Sprintf(`{"accounts":{"work":{"accessToken":"mock-token","expiresAt":%d,"baseUrl":%q}}}`, expiresAt, mock.URL), 0o600)
+	writeFile(t, filepath.Join(dir, "openrouter.json"), fmt.Sprintf(`{"accounts":{"personal":{"apiKey":"sk-or-bad","baseUrl":%q}}}`, unauthorized.URL), 0o600)
+
+	r := d.Run()
+
+	if c := findCheck(r, "claude/work", StatusOK); c == nil || c.ExpiresAt == nil {
+		t.Errorf("claude/work expiry check = %+v", c)
+	}
+	if findCheck(r, "claude/work", StatusError) != nil {
+		t.Errorf("claude/work failed validation: %+v", r.Checks)
+	}
+	c := findCheck(r, "openrouter/personal", StatusError)
+	if c == nil || c.Fix != "Run 'llm-usage setup add openrouter --account personal'" {
+		t.Errorf("openrouter/personal check = %+v", c)
+	}
+	if r.Errors != 1 {
+		t.Errorf("errors = %d in %+v", r.Errors, r.Checks)
+	}
+}
+
+func TestCookieExpiry(t *testing.T) {
+	exp := time.Unix(1767225600, 0)
+	if got, ok := cookieExpiry("lang=en; _token=" + testJWT(exp.Add(time.Hour)) + "; sid=" + testJWT(exp)); !ok || !got.Equal(exp) {
+		t.Errorf("cookieExpiry() = %v, %v, want %v", got, ok, exp)
+	}
+	if _, ok := cookieExpiry("lang=en; sid=abc.def.ghi"); ok {
+		t.Error("cookieExpiry() found an expiry in a cookie without JWTs")
+	}
+}
@@ -0,0 +1,63 @@
+package doctor
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	okStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("70"))
+	warnStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	dimStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+var sectionTitles = map[string]string{
+	SectionDirectories: "Directories",
+	SectionConfig:      "Configuration",
+	SectionCredentials: "Credentials",
+	SectionAccounts:    "Accounts",
+}
+
+// WriteText writes the report grouped by section, with the fix below each problem
+func (r *Report) WriteText(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "llm-usage doctor")
+	_, _ = fmt.Fprintln(w, "================")
+
+	section := ""
+	for _, c := range r.Checks {
+		if c.Section != section {
+			section = c.Section
+			_, _ = fmt.Fprintf(w, "\n%s\n", sectionTitles[section])
+		}
+
+		_, _ = fmt.Fprintf(w, "  %s %s: %s\n", statusSymbol(c.Status), c.Name, c.Message)
+		if c.Path != "" && c.Status != StatusOK {
+			_, _ = fmt.Fprintf(w, "      %s\n", dimStyle.Render(c.Path))
+		}
+		if c.Fix != "" {
+			_, _ = fmt.Fprintf(w, "      fix: %s\n", c.Fix)
+		}
+	}
+
+	_, _ = fmt.Fprintln(w)
+	switch {
+	case r.Errors == 0 && r.Warnings == 0:
+		_, _ = fmt.Fprintln(w, okStyle.Render("No problems found"))
+	default:
+		_, _ = fmt.Fprintf(w, "%d error(s), %d warning(s)\n", r.Errors, r.Warnings)
+	}
+}
+
+func statusSymbol(s Status) string {
+	switch s {
+	case StatusError:
+		return errorStyle.Render("✗")
+	case StatusWarn:
+		return warnStyle.Render("!")
+	default:
+		return okStyle.Render("✓")
+	}
+}
@@ -0,0 +1,84 @@
+// Package fsutil provides atomic file writes and advisory file locks, so that concurrent
+// llm-usage processes never leave partially written credentials or cache files.
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WriteFile writes data to a temporary file in the same directory and renames it over path,
+// so that readers see either the previous or the new content, never a partial write
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer func() { _ = os.Remove(tmp) }() // No-op once renamed
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ErrLocked is returned by TryLock when another process holds the lock
+var ErrLocked = errors.New("file is locked by another process")
+
+// Lock is an exclusive advisory lock on a lock file
+type Lock struct {
+	f *os.File
+}
+
+// TryLock takes the lock on path, creating the file if needed, or returns ErrLocked
+func TryLock(path string) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600) //nolint:gosec // Lock files are chosen by the caller
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &Lock{f: f}, nil
+}
+
+// pollInterval is how often LockTimeout retries a held lock
+const pollInterval = 50 * time.Millisecond
+
+// LockTimeout takes the lock on path, waiting up to timeout for other processes to release it
+func LockTimeout(path string, timeout time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		lock, err := TryLock(path)
+		if !errors.Is(err, ErrLocked) {
+			return lock, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out after %s waiting for %s: %w", timeout, path, err)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Unlock releases the lock
+func (l *Lock) Unlock() error {
+	err := unlockFile(l.f)
+	return errors.Join(err, l.f.Close())
+}
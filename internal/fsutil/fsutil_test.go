@@ -0,0 +1,76 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "creds.json")
+
+	for _, content := range []string{`{"a":1}`, `{"b":2}`} {
+		if err := WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("content = %q, want %q", data, content)
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("mode = %04o, want 0600", perm)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestTryLock(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "windows" {
+		t.Skip("file locks are not supported on " + runtime.GOOS)
+	}
+	path := filepath.Join(t.TempDir(), ".lock")
+
+	lock, err := TryLock(path)
+	if err != nil {
+		t.Fatalf("TryLock() error = %v", err)
+	}
+	if _, err := TryLock(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("TryLock() on a held lock error = %v, want ErrLocked", err)
+	}
+	if _, err := LockTimeout(path, 2*pollInterval); !errors.Is(err, ErrLocked) {
+		t.Errorf("LockTimeout() on a held lock error = %v, want ErrLocked", err)
+	}
+
+	go func() {
+		time.Sleep(2 * pollInterval)
+		_ = lock.Unlock()
+	}()
+	waited, err := LockTimeout(path, time.Second)
+	if err != nil {
+		t.Fatalf("LockTimeout() error = %v", err)
+	}
+	if err := waited.Unlock(); err != nil {
+		t.Errorf("Unlock() error = %v", err)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package fsutil
+
+import "os"
+
+// lockFile does nothing on platforms without file locks; updates still happen atomically
+func lockFile(*os.File) error {
+	return nil
+}
+
+// unlockFile does nothing on platforms without file locks
+func unlockFile(*os.File) error {
+	return nil
+}
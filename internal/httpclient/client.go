@@ -0,0 +1,437 @@
+// Package httpclient provides the shared HTTP client used by all providers.
+//
+// It adds connection reuse, retries with exponential backoff and jitter for
+// rate-limited (429) responses, and for transient server (5xx) responses and connection
+// errors of idempotent requests, Retry-After support,
+// per-host concurrency limits, a consistent User-Agent and response size limits.
+// Exchanges can be recorded to and replayed from a directory of JSON fixtures,
+// for end-to-end tests and reproducing bug reports offline.
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/version"
+)
+
+const (
+	// DefaultTimeout is the default timeout of a single attempt
+	DefaultTimeout = 30 * time.Second
+	// DefaultMaxRetries is the default number of retries after the first attempt
+	DefaultMaxRetries = 3
+	// DefaultMaxPerHost is the default number of concurrent requests per host
+	DefaultMaxPerHost = 4
+	// DefaultMaxBodyBytes is the default response body size limit
+	DefaultMaxBodyBytes = 10 << 20
+
+	defaultBaseDelay = 500 * time.Millisecond
+	defaultMaxDelay  = 30 * time.Second
+)
+
+// ErrBodyTooLarge is returned when a response body exceeds the configured limit
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// Options configures a Client. Zero values select the defaults.
+type Options struct {
+	Proxy        string        // Proxy URL; empty uses HTTP_PROXY/HTTPS_PROXY/NO_PROXY
+	Timeout      time.Duration // Timeout of a single attempt
+	MaxRetries   *int          // Retries after the first attempt; 0 disables retries
+	MaxPerHost   int           // Concurrent requests per host
+	MaxBodyBytes int64         // Response body size limit
+	BaseDelay    time.Duration // First backoff delay, doubled on every retry
+	MaxDelay     time.Duration // Upper bound for backoff and Retry-After delays
+	Record       string        // Directory to save sanitized request/response pairs to
+	Replay       string        // Directory to serve recorded responses from instead of the network
+}
+
+// StatusError is returned when a response has a non-2xx status code
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error implements the error interface
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// Client is an HTTP client with retries and per-host concurrency limits
+type Client struct {
+	http         *http.Client
+	maxRetries   int
+	maxPerHost   int
+	maxBodyBytes int64
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+	hosts        *hostLimiter
+}
+
+// hostLimiter bounds the number of concurrent requests per host
+type hostLimiter struct {
+	mu    sync.Mutex
+	max   int
+	slots map[string]chan struct{}
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultClient = mustNew(Options{})
+)
+
+// New creates a client with the given options
+func New(opts Options) (*Client, error) {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("unexpected default transport type")
+	}
+	transport = transport.Clone()
+
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	var roundTripper http.RoundTripper = transport
+	switch {
+	case opts.Record != "" && opts.Replay != "":
+		return nil, errors.New("record and replay cannot be used together")
+	case opts.Replay != "":
+		r, err := newReplayer(opts.Replay)
+		if err != nil {
+			return nil, err
+		}
+		roundTripper = r
+	case opts.Record != "":
+		r, err := newRecorder(transport, opts.Record)
+		if err != nil {
+			return nil, err
+		}
+		roundTripper = r
+	}
+
+	c := &Client{
+		http:         &http.Client{Transport: roundTripper, Timeout: opts.Timeout},
+		maxRetries:   DefaultMaxRetries,
+		maxPerHost:   opts.MaxPerHost,
+		maxBodyBytes: opts.MaxBodyBytes,
+		baseDelay:    opts.BaseDelay,
+		maxDelay:     opts.MaxDelay,
+	}
+	if c.http.Timeout <= 0 {
+		c.http.Timeout = DefaultTimeout
+	}
+	if opts.MaxRetries != nil && *opts.MaxRetries >= 0 {
+		c.maxRetries = *opts.MaxRetries
+	}
+	if c.maxPerHost <= 0 {
+		c.maxPerHost = DefaultMaxPerHost
+	}
+	if c.maxBodyBytes <= 0 {
+		c.maxBodyBytes = DefaultMaxBodyBytes
+	}
+	if c.baseDelay <= 0 {
+		c.baseDelay = defaultBaseDelay
+	}
+	if c.maxDelay <= 0 {
+		c.maxDelay = defaultMaxDelay
+	}
+	transport.MaxConnsPerHost = c.maxPerHost
+	c.hosts = &hostLimiter{max: c.maxPerHost, slots: make(map[string]chan struct{})}
+
+	return c, nil
+}
+
+// mustNew creates a client with options known to be valid
+func mustNew(opts Options) *Client {
+	c, err := New(opts)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Configure replaces the default client used by providers
+func Configure(opts Options) error {
+	c, err := New(opts)
+	if err != nil {
+		return err
+	}
+	defaultMu.Lock()
+	defaultClient = c
+	defaultMu.Unlock()
+	return nil
+}
+
+// Default returns the shared client
+func Default() *Client {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultClient
+}
+
+// WithoutRetries returns a client sharing c's connections and limits that
+// never retries, for callers that need to see rate-limited responses
+func (c *Client) WithoutRetries() *Client {
+	clone := *c
+	clone.maxRetries = 0
+	return &clone
+}
+
+// Do sends the request, retrying rate-limited requests, and the transient failures of
+// idempotent requests: GET and HEAD requests, and requests with an Idempotency-Key or
+// X-Idempotency-Key header. Timeouts are not retried, so that a hung host costs a single
+// timeout. Requests with a body are only retried when the body can be replayed, and
+// responses whose retry would not start before the context's deadline are returned as they
+// are. A User-Agent header is added unless the request already has one.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent())
+	}
+
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			if attemptReq, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		release, err := c.hosts.acquire(ctx, req.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.http.Do(attemptReq)
+		canRetry := attempt < c.maxRetries && (req.Body == nil || req.GetBody != nil)
+		if err != nil {
+			release()
+			if !canRetry || ctx.Err() != nil || !idempotent(req) || !temporary(err) {
+				return nil, err
+			}
+			if err := sleep(ctx, c.backoff(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if !canRetry || !retryableStatus(resp.StatusCode, idempotent(req)) {
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+			return resp, nil
+		}
+
+		delay := c.backoff(attempt)
+		if d, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			delay = min(d, c.maxDelay)
+		}
+		// A retry the request's deadline cannot wait for fails anyway, so the response is kept
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+			return resp, nil
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, c.maxBodyBytes))
+		_ = resp.Body.Close()
+		release()
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Send sends the request and decodes a successful JSON response into target.
+// Non-2xx responses are returned as a *StatusError.
+func (c *Client) Send(req *http.Request, target any) error {
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := c.ReadBody(resp)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// ReadBody reads a response body, failing if it exceeds the size limit
+func (c *Client) ReadBody(resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.maxBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > c.maxBodyBytes {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrBodyTooLarge, c.maxBodyBytes)
+	}
+	return body, nil
+}
+
+// ValidateBaseURL checks that s is an absolute http or https URL without query or fragment,
+// suitable as the base URL of a provider API
+func ValidateBaseURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid base URL %q: %w", s, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid base URL %q: must be an absolute http or https URL", s)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid base URL %q: must not contain a query or fragment", s)
+	}
+	return nil
+}
+
+// UserAgent returns the User-Agent header sent with every request
+func UserAgent() string {
+	return "llm-usage/" + version.Version
+}
+
+// ParseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// backoff returns the delay before the given retry, using exponential backoff with full jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.maxDelay
+	if attempt < 30 {
+		d = min(c.baseDelay<<attempt, c.maxDelay)
+	}
+	return time.Duration(rand.Int64N(int64(d)) + 1) //nolint:gosec // jitter does not need a secure source
+}
+
+// acquire waits for a free request slot for the host and returns its release function
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	l.mu.Lock()
+	sem, ok := l.slots[host]
+	if !ok {
+		sem = make(chan struct{}, l.max)
+		l.slots[host] = sem
+	}
+	l.mu.Unlock()
+
+	select {
+	case sem <- struct{}{}:
+		var once sync.Once
+		return func() { once.Do(func() { <-sem }) }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// releasingBody releases the host slot once the response body is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close closes the body and releases the host slot
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// rewind returns a copy of req with a fresh body for another attempt
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewind request body: %w", err)
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// idempotent reports whether a request may be sent again after the server may have
+// processed it
+func idempotent(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	_, ok := req.Header["Idempotency-Key"]
+	if !ok {
+		_, ok = req.Header["X-Idempotency-Key"]
+	}
+	return ok
+}
+
+// retryableStatus reports whether a response status is worth retrying. Rate-limited requests
+// were not processed; server errors are only retried for idempotent requests.
+func retryableStatus(code int, idempotent bool) bool {
+	switch code {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent
+	default:
+		return false
+	}
+}
+
+// temporary reports whether a transport error is worth retrying. Timeouts are not, since
+// every attempt would wait for the whole timeout again.
+func temporary(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
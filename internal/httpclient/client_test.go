@@ -0,0 +1,278 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testClient returns a client with short delays suitable for tests
+func testClient(t *testing.T, opts Options) *Client {
+	t.Helper()
+	opts.BaseDelay = time.Millisecond
+	opts.MaxDelay = 10 * time.Millisecond
+	c, err := New(opts)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestClient_SendRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("User-Agent"); got != UserAgent() {
+			t.Errorf("User-Agent = %q, want %q", got, UserAgent())
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"q":1}` {
+			t.Errorf("attempt %d body = %q", attempts.Load(), body)
+		}
+		switch attempts.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = io.WriteString(w, `{"ok":true}`)
+		}
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, strings.NewReader(`{"q":1}`))
+	req.Header.Set("Idempotency-Key", "q1")
+	var out struct {
+		OK bool `json:"ok"`
+	}
+	if err := testClient(t, Options{}).Send(req, &out); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if !out.OK || attempts.Load() != 3 {
+		t.Errorf("ok = %v after %d attempts, want true after 3", out.OK, attempts.Load())
+	}
+}
+
+func TestClient_SendStatusError(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	err := testClient(t, Options{}).Send(req, &struct{}{})
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Send() error = %v, want *StatusError with 401", err)
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("client errors should not be retried, got %d attempts", attempts.Load())
+	}
+}
+
+func TestClient_RetryPolicy(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		switch r.URL.Path {
+		case "/hang":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		case "/limited":
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	for _, tt := range []struct {
+		method, path string
+		want         int32
+	}{
+		{http.MethodPost, "/unavailable", 1}, // The server may have processed the request
+		{http.MethodPost, "/limited", 4},
+		{http.MethodGet, "/unavailable", 4},
+		{http.MethodGet, "/hang", 1}, // Every retry would wait for the whole timeout again
+	} {
+		attempts.Store(0)
+		req, _ := http.NewRequestWithContext(context.Background(), tt.method, server.URL+tt.path, strings.NewReader("{}"))
+		resp, err := testClient(t, Options{Timeout: 100 * time.Millisecond}).Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		if got := attempts.Load(); got != tt.want {
+			t.Errorf("%s %s: %d attempts, want %d", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestClient_RetryAfterPastDeadline(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	c, err := New(Options{MaxDelay: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	start := time.Now()
+	err = c.Send(req, &struct{}{})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Send() error = %v, want *StatusError with 429", err)
+	}
+	if attempts.Load() != 1 || time.Since(start) > time.Second {
+		t.Errorf("%d attempts in %s, want 1 without waiting", attempts.Load(), time.Since(start))
+	}
+}
+
+func TestClient_RetriesExhausted(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	retries := 2
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	resp, err := testClient(t, Options{MaxRetries: &retries}).Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || attempts.Load() != 3 {
+		t.Errorf("status %d after %d attempts, want 502 after 3", resp.StatusCode, attempts.Load())
+	}
+
+	attempts.Store(0)
+	req, _ = http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	resp, err = testClient(t, Options{}).WithoutRetries().Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	_ = resp.Body.Close()
+	if attempts.Load() != 1 {
+		t.Errorf("WithoutRetries() made %d attempts, want 1", attempts.Load())
+	}
+}
+
+func TestClient_BodyLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"data":"`+strings.Repeat("x", 100)+`"}`)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	err := testClient(t, Options{MaxBodyBytes: 50}).Send(req, &struct{}{})
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Send() error = %v, want ErrBodyTooLarge", err)
+	}
+}
+
+func TestClient_MaxPerHost(t *testing.T) {
+	var current, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := current.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		current.Add(-1)
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+
+	c := testClient(t, Options{MaxPerHost: 2})
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+			if err := c.Send(req, &struct{}{}); err != nil {
+				t.Errorf("Send() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", peak.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"Sun, 15 Mar 2026 12:00:30 GMT", 30 * time.Second, true},
+		{"Sun, 15 Mar 2026 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+
+	for _, tc := range tests {
+		got, ok := ParseRetryAfter(tc.value, now)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("ParseRetryAfter(%q) = %v, %v, want %v, %v", tc.value, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestNew_InvalidProxy(t *testing.T) {
+	if _, err := New(Options{Proxy: "://bad"}); err == nil {
+		t.Error("New() expected error for invalid proxy URL")
+	}
+}
+
+func TestValidateBaseURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://api.anthropic.com", true},
+		{"http://localhost:8787/", true},
+		{"https://gateway.corp.example/anthropic", true},
+		{"api.anthropic.com", false},
+		{"ftp://example.com", false},
+		{"https://example.com?key=1", false},
+		{"://bad", false},
+	}
+
+	for _, tc := range tests {
+		if err := ValidateBaseURL(tc.url); (err == nil) != tc.valid {
+			t.Errorf("ValidateBaseURL(%q) error = %v, want valid = %v", tc.url, err, tc.valid)
+		}
+	}
+}
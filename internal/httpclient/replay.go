@@ -0,0 +1,333 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redacted replaces sensitive values in recorded fixtures
+const redacted = "REDACTED"
+
+// sensitiveHeaders are always redacted, whatever their value
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// Fixture is a recorded request/response pair, stored as one JSON file per request
+type Fixture struct {
+	Request  FixtureRequest  `json:"request"`
+	Response FixtureResponse `json:"response"`
+}
+
+// FixtureRequest describes the recorded request
+type FixtureRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// FixtureResponse describes the recorded response. JSON bodies are stored
+// inline in Body so fixtures stay readable; other bodies are stored in Text.
+type FixtureResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	Text    string            `json:"text,omitempty"`
+}
+
+// body returns the raw response body
+func (r *FixtureResponse) body() []byte {
+	if len(r.Body) > 0 {
+		return r.Body
+	}
+	return []byte(r.Text)
+}
+
+// recorder is a transport that saves sanitized request/response pairs to a directory
+type recorder struct {
+	next http.RoundTripper
+	dir  string
+
+	mu    sync.Mutex
+	count int
+}
+
+// newRecorder creates a recorder writing to dir, numbering fixtures after any already there
+func newRecorder(next http.RoundTripper, dir string) (*recorder, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create record directory: %w", err)
+	}
+	existing, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	return &recorder{next: next, dir: dir, count: len(existing)}, nil
+}
+
+// RoundTrip performs the request and records the exchange
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	fixture := Fixture{
+		Request: FixtureRequest{
+			Method:  req.Method,
+			URL:     sanitizeURL(req.URL),
+			Headers: sanitizeHeaders(req.Header),
+		},
+		Response: FixtureResponse{
+			Status:  resp.StatusCode,
+			Headers: sanitizeHeaders(resp.Header),
+		},
+	}
+	if sanitized, ok := sanitizeJSON(body); ok {
+		fixture.Response.Body = sanitized
+	} else {
+		fixture.Response.Text = string(body)
+	}
+
+	if err := r.save(req, &fixture); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// nonAlnum matches runs of characters not allowed in fixture file names
+var nonAlnum = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// save writes the fixture to the next numbered file
+func (r *recorder) save(req *http.Request, f *Fixture) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+
+	r.mu.Lock()
+	r.count++
+	n := r.count
+	r.mu.Unlock()
+
+	slug := strings.Trim(nonAlnum.ReplaceAllString(req.URL.Host+req.URL.Path, "-"), "-")
+	name := fmt.Sprintf("%03d-%s-%s.json", n, strings.ToLower(req.Method), slug)
+	if err := os.WriteFile(filepath.Join(r.dir, name), append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// replayer is a transport that serves recorded responses instead of touching the network
+type replayer struct {
+	mu       sync.Mutex
+	fixtures []*replayFixture
+}
+
+// replayFixture is a loaded fixture with its match keys and replay state
+type replayFixture struct {
+	Fixture
+	exact string // method, URL and query
+	path  string // method and URL without query
+	used  bool
+}
+
+// newReplayer loads all fixtures in dir, in file name order
+func newReplayer(dir string) (*replayer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no fixtures found in %s", dir)
+	}
+	sort.Strings(files)
+
+	r := &replayer{}
+	for _, file := range files {
+		data, err := os.ReadFile(file) //nolint:gosec // fixture directory is chosen by the user
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", filepath.Base(file), err)
+		}
+		u, err := url.Parse(f.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL in fixture %s: %w", filepath.Base(file), err)
+		}
+		exact, path := matchKeys(f.Request.Method, u)
+		r.fixtures = append(r.fixtures, &replayFixture{Fixture: f, exact: exact, path: path})
+	}
+	return r, nil
+}
+
+// RoundTrip serves the recorded response matching the request.
+// Fixtures with the same method, URL and query are preferred, then fixtures
+// for the same endpoint, since queries often contain the current time.
+// Matching fixtures are served in order; the last one is repeated once all are used.
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	exact, path := matchKeys(req.Method, req.URL)
+
+	r.mu.Lock()
+	f := r.match(exact, path)
+	r.mu.Unlock()
+	if f == nil {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, sanitizeURL(req.URL))
+	}
+
+	header := make(http.Header, len(f.Response.Headers))
+	for k, v := range f.Response.Headers {
+		header.Set(k, v)
+	}
+	body := f.Response.body()
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Response.Status, http.StatusText(f.Response.Status)),
+		StatusCode:    f.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// match returns the next fixture for the request, or nil if there is none
+func (r *replayer) match(exact, path string) *replayFixture {
+	for _, key := range []func(*replayFixture) bool{
+		func(f *replayFixture) bool { return f.exact == exact },
+		func(f *replayFixture) bool { return f.path == path },
+	} {
+		var last *replayFixture
+		for _, f := range r.fixtures {
+			if !key(f) {
+				continue
+			}
+			if !f.used {
+				f.used = true
+				return f
+			}
+			last = f
+		}
+		if last != nil {
+			return last
+		}
+	}
+	return nil
+}
+
+// matchKeys returns the keys used to match a request against fixtures
+func matchKeys(method string, u *url.URL) (exact, path string) {
+	path = strings.ToUpper(method) + " " + u.Scheme + "://" + u.Host + u.Path
+	return path + "?" + sanitizeQuery(u.Query()).Encode(), path
+}
+
+// sanitizeURL returns the URL with sensitive query parameters redacted
+func sanitizeURL(u *url.URL) string {
+	clone := *u
+	clone.User = nil
+	clone.RawQuery = sanitizeQuery(u.Query()).Encode()
+	return clone.String()
+}
+
+// sanitizeQuery redacts the values of sensitive query parameters
+func sanitizeQuery(query url.Values) url.Values {
+	for k, values := range query {
+		if sensitiveName(k) {
+			for i := range values {
+				values[i] = redacted
+			}
+		}
+	}
+	return query
+}
+
+// sanitizeHeaders flattens headers, redacting credentials
+func sanitizeHeaders(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for k, values := range h {
+		v := strings.Join(values, ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] || sensitiveName(k) {
+			v = redacted
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// sanitizeJSON redacts string values of sensitive keys in a JSON document.
+// It reports false if body is not JSON.
+func sanitizeJSON(body []byte) (json.RawMessage, bool) {
+	var doc any
+	if len(bytes.TrimSpace(body)) == 0 || json.Unmarshal(body, &doc) != nil {
+		return nil, false
+	}
+	out, err := json.Marshal(redactValue(doc))
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// redactValue walks a decoded JSON value, redacting sensitive string fields
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if _, isString := child.(string); isString && sensitiveName(k) {
+				v[k] = redacted
+				continue
+			}
+			v[k] = redactValue(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = redactValue(child)
+		}
+	}
+	return v
+}
+
+// sensitiveName reports whether a header, query parameter or JSON key name
+// is likely to hold a credential, such as access_token, api_key or X-Auth-Token
+func sensitiveName(name string) bool {
+	n := strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+	if n == "key" || strings.HasSuffix(n, "apikey") || strings.HasSuffix(n, "adminkey") {
+		return true
+	}
+	for _, s := range []string{"token", "secret", "password", "cookie", "session", "auth"} {
+		if strings.Contains(n, s) && !strings.Contains(n, "ratelimit") {
+			return true
+		}
+	}
+	return false
+}
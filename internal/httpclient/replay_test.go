@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=abc123")
+		w.Header().Set("X-Ratelimit-Remaining-Tokens", "900")
+		_, _ = io.WriteString(w, `{"access_token":"sk-live-secret","usage":{"input_tokens":42},"page":"`+r.URL.Query().Get("page")+`"}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recordClient := testClient(t, Options{Record: dir})
+	for _, page := range []string{"1", "2"} {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/v1/usage?api_key=sk-query&page="+page, nil)
+		req.Header.Set("Authorization", "Bearer sk-header")
+		if err := recordClient.Send(req, &struct{}{}); err != nil {
+			t.Fatalf("Send() while recording error = %v", err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("recorded %d fixtures, want 2", len(files))
+	}
+	for _, file := range files {
+		data, _ := os.ReadFile(file) //nolint:gosec // test fixture
+		for _, secret := range []string{"sk-live-secret", "sk-query", "sk-header", "abc123"} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("%s contains unredacted %q", filepath.Base(file), secret)
+			}
+		}
+		if !strings.Contains(string(data), `"input_tokens": 42`) || !strings.Contains(string(data), "900") {
+			t.Errorf("%s redacted non-sensitive values:\n%s", filepath.Base(file), data)
+		}
+	}
+	server.Close()
+
+	replayClient := testClient(t, Options{Replay: dir})
+	for _, page := range []string{"2", "1"} {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/v1/usage?api_key=other&page="+page, nil)
+		var out struct {
+			Page string `json:"page"`
+		}
+		if err := replayClient.Send(req, &out); err != nil {
+			t.Fatalf("Send() while replaying error = %v", err)
+		}
+		if out.Page != page {
+			t.Errorf("replayed page = %q, want %q", out.Page, page)
+		}
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/v1/other", nil)
+	if err := replayClient.Send(req, &struct{}{}); err == nil {
+		t.Error("Send() expected error for a request without a recorded response")
+	}
+}
+
+func TestReplay_EndpointFallback(t *testing.T) {
+	dir := t.TempDir()
+	fixture := `{"request":{"method":"GET","url":"https://api.example.com/usage?since=2026-01-01"},` +
+		`"response":{"status":429,"headers":{"Retry-After":"0"},"text":"slow down"}}`
+	if err := os.WriteFile(filepath.Join(dir, "001.json"), []byte(fixture), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	retries := 0
+	c := testClient(t, Options{Replay: dir, MaxRetries: &retries})
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://api.example.com/usage?since=2026-10-01", nil)
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusTooManyRequests || string(body) != "slow down" {
+		t.Errorf("got %d %q, want 429 %q", resp.StatusCode, body, "slow down")
+	}
+}
+
+func TestNew_RecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := New(Options{Record: dir, Replay: dir}); err == nil {
+		t.Error("New() expected error when both record and replay are set")
+	}
+	if _, err := New(Options{Replay: dir}); err == nil {
+		t.Error("New() expected error for an empty replay directory")
+	}
+}
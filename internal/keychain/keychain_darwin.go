@@ -52,6 +52,9 @@ import (
 	"unsafe"
 )
 
+// Service is the keychain service holding the Claude CLI credentials.
+const Service = "Claude Code-credentials"
+
 // ErrNotFound indicates that credentials were not found in the keychain.
 var ErrNotFound = errors.New("credentials not found in keychain")
 
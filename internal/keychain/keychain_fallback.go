@@ -13,6 +13,9 @@ import (
 // ErrNotSupported indicates that keychain is not supported on this platform.
 var ErrNotSupported = fmt.Errorf("keychain access is only supported on macOS, current platform: %s", runtime.GOOS)
 
+// Service is the keychain service holding the Claude CLI credentials.
+const Service = "Claude Code-credentials"
+
 // ErrNotFound indicates that credentials were not found in the keychain.
 var ErrNotFound = errors.New("credentials not found in keychain")
 
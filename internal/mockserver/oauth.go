@@ -0,0 +1,73 @@
+package mockserver
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// mockTokenLifetime is the lifetime of issued access tokens, in seconds
+const mockTokenLifetime = 8 * 60 * 60
+
+// handleClaudeAuthorize approves every authorization request and redirects back with a code
+func (s *Server) handleClaudeAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || !redirect.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "an S256 code_challenge is required", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.issued++
+	code := fmt.Sprintf("mock-code-%d", s.issued)
+	s.grants[code] = q.Get("code_challenge")
+	s.mu.Unlock()
+
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// handleClaudeToken exchanges an authorization code issued by handleClaudeAuthorize
+func (s *Server) handleClaudeToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		GrantType    string `json:"grant_type"`
+		Code         string `json:"code"`
+		CodeVerifier string `json:"code_verifier"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.GrantType != "authorization_code" {
+		writeRaw(w, http.StatusBadRequest, `{"error":"invalid_request"}`)
+		return
+	}
+
+	sum := sha256.Sum256([]byte(req.CodeVerifier))
+	s.mu.Lock()
+	challenge, ok := s.grants[req.Code]
+	delete(s.grants, req.Code)
+	s.mu.Unlock()
+	if !ok || challenge != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		writeRaw(w, http.StatusBadRequest, `{"error":"invalid_grant"}`)
+		return
+	}
+
+	id := strings.TrimPrefix(req.Code, "mock-code-")
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"token_type":    "Bearer",
+		"access_token":  "mock-access-" + id,
+		"refresh_token": "mock-refresh-" + id,
+		"expires_in":    mockTokenLifetime,
+		"scope":         "user:inference user:profile",
+	})
+}
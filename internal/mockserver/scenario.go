@@ -0,0 +1,93 @@
+package mockserver
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Scenario names the behavior of an emulated endpoint
+type Scenario string
+
+const (
+	// ScenarioRamp climbs from 0% to 100% utilization over one period and stays there
+	ScenarioRamp Scenario = "ramp"
+	// ScenarioReset climbs from 0% to 100% utilization and resets every period
+	ScenarioReset Scenario = "reset"
+	// ScenarioExpired answers 401 as if the credentials had expired
+	ScenarioExpired Scenario = "expired"
+	// ScenarioRateLimited answers 429 with a Retry-After header
+	ScenarioRateLimited Scenario = "ratelimited"
+	// ScenarioMalformed answers 200 with a truncated JSON document
+	ScenarioMalformed Scenario = "malformed"
+)
+
+// Scenarios lists all known scenarios
+var Scenarios = []Scenario{ScenarioRamp, ScenarioReset, ScenarioExpired, ScenarioRateLimited, ScenarioMalformed}
+
+// Step plays a scenario for a number of usage requests; 0 means forever
+type Step struct {
+	Scenario Scenario
+	Requests int
+}
+
+// Script is a sequence of steps. After the last step, its scenario is kept.
+type Script []Step
+
+// ParseScript parses a script such as "ramp:3,ratelimited:1,expired",
+// which serves three ramping responses, one 429 and then 401 forever
+func ParseScript(s string) (Script, error) {
+	var script Script
+	for _, part := range strings.Split(s, ",") {
+		name, count, hasCount := strings.Cut(strings.TrimSpace(part), ":")
+		step := Step{Scenario: Scenario(name)}
+		if !slices.Contains(Scenarios, step.Scenario) {
+			return nil, fmt.Errorf("unknown scenario %q (valid: %s)", name, scenarioNames())
+		}
+		if hasCount {
+			n, err := strconv.Atoi(count)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid request count %q for scenario %s", count, name)
+			}
+			step.Requests = n
+		}
+		script = append(script, step)
+	}
+	return script, nil
+}
+
+// at returns the scenario to play for the n-th usage request, starting at 0
+func (s Script) at(n int) Scenario {
+	if len(s) == 0 {
+		return ScenarioReset
+	}
+	for _, step := range s {
+		if step.Requests == 0 || n < step.Requests {
+			return step.Scenario
+		}
+		n -= step.Requests
+	}
+	return s[len(s)-1].Scenario
+}
+
+// String returns the script in the form accepted by ParseScript
+func (s Script) String() string {
+	parts := make([]string, len(s))
+	for i, step := range s {
+		parts[i] = string(step.Scenario)
+		if step.Requests > 0 {
+			parts[i] += ":" + strconv.Itoa(step.Requests)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// scenarioNames returns the scenario names as a comma-separated list
+func scenarioNames() string {
+	names := make([]string, len(Scenarios))
+	for i, s := range Scenarios {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,338 @@
+// Package mockserver emulates the usage endpoints of the Claude, Kimi and MiniMax
+// APIs with scriptable scenarios, and the Claude OAuth login, for demos and offline
+// integration tests.
+package mockserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Emulated endpoints, matching the paths used by the provider clients
+const (
+	claudeUsagePath         = "/api/oauth/usage"
+	kimiUsagePath           = "/apiv2/kimi.gateway.billing.v1.BillingService/GetUsages"
+	kimiSubscriptionPath    = "/apiv2/kimi.gateway.order.v1.SubscriptionService/GetSubscription"
+	minimaxUsagePath        = "/v1/api/openplatform/coding_plan/remains"
+	minimaxSubscriptionPath = "/v1/api/openplatform/charge/combo/cycle_audio_resource_package"
+
+	claudeAuthorizePath = "/oauth/authorize"
+	claudeTokenPath     = "/v1/oauth/token"
+
+	controlPath = "/_mock/scenario"
+)
+
+// Providers lists the providers emulated by the server
+var Providers = []string{"claude", "kimi", "minimax"}
+
+// DefaultPeriod is the default time it takes usage to go from 0% to 100%
+const DefaultPeriod = 10 * time.Minute
+
+// Config holds the mock server configuration
+type Config struct {
+	Host   string
+	Port   int
+	Period time.Duration // Time for usage to climb from 0% to 100%
+
+	// Default is the script played by providers without an entry in Scripts
+	Default Script
+	// Scripts maps provider IDs to their scripts
+	Scripts map[string]Script
+}
+
+// Server is an HTTP server emulating provider usage endpoints
+type Server struct {
+	config *Config
+	server *http.Server
+	start  time.Time
+	now    func() time.Time
+
+	mu       sync.Mutex
+	scripts  map[string]Script
+	requests map[string]int
+	grants   map[string]string // Pending OAuth authorization codes and their PKCE challenges
+	issued   int               // OAuth authorization codes issued
+}
+
+// providerState describes the current state of an emulated provider
+type providerState struct {
+	Script   string   `json:"script"`
+	Requests int      `json:"requests"`
+	Scenario Scenario `json:"scenario"`
+}
+
+// NewServer creates a new mock server
+func NewServer(cfg *Config) *Server {
+	if cfg.Period <= 0 {
+		cfg.Period = DefaultPeriod
+	}
+
+	s := &Server{
+		config:   cfg,
+		start:    time.Now(),
+		now:      time.Now,
+		scripts:  make(map[string]Script),
+		requests: make(map[string]int),
+		grants:   make(map[string]string),
+	}
+	for _, id := range Providers {
+		s.scripts[id] = cfg.Default
+		if script, ok := cfg.Scripts[id]; ok {
+			s.scripts[id] = script
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+claudeUsagePath, s.handleClaudeUsage)
+	mux.HandleFunc("POST "+kimiUsagePath, s.handleKimiUsage)
+	mux.HandleFunc("POST "+kimiSubscriptionPath, s.handleKimiSubscription)
+	mux.HandleFunc("GET "+minimaxUsagePath, s.handleMiniMaxUsage)
+	mux.HandleFunc("GET "+minimaxSubscriptionPath, s.handleMiniMaxSubscription)
+	mux.HandleFunc("GET "+claudeAuthorizePath, s.handleClaudeAuthorize)
+	mux.HandleFunc("POST "+claudeTokenPath, s.handleClaudeToken)
+	mux.HandleFunc("GET "+controlPath, s.handleGetScenario)
+	mux.HandleFunc("POST "+controlPath, s.handleSetScenario)
+
+	s.server = &http.Server{
+		Addr:              net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the server's HTTP handler, for use with httptest
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// Start starts the server and blocks until ctx is done or the server fails
+func (s *Server) Start(ctx context.Context) error {
+	log.Printf("Mock server listening on http://%s", s.server.Addr)
+	for _, id := range Providers {
+		log.Printf("  %-8s %s", id, s.script(id))
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.server.Shutdown(shutdownCtx)
+	}()
+
+	return s.server.ListenAndServe()
+}
+
+// script returns the provider's current script
+func (s *Server) script(id string) Script {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scripts[id]
+}
+
+// next counts a usage request and returns the scenario to play for it
+func (s *Server) next(id string) Scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.requests[id]
+	s.requests[id]++
+	return s.scripts[id].at(n)
+}
+
+// current returns the scenario of the provider's latest usage request without counting
+// a new one, so subscription endpoints fail together with the usage endpoint
+func (s *Server) current(id string) Scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := max(s.requests[id]-1, 0)
+	return s.scripts[id].at(n)
+}
+
+// level returns the utilization percentage and reset time for a scenario
+func (s *Server) level(scenario Scenario) (float64, time.Time) {
+	now := s.now()
+	period := s.config.Period
+	elapsed := now.Sub(s.start)
+
+	if scenario == ScenarioRamp {
+		utilization := min(float64(elapsed)/float64(period), 1) * 100
+		return utilization, now.Add(period)
+	}
+
+	inCycle := elapsed % period
+	return float64(inCycle) / float64(period) * 100, now.Add(period - inCycle)
+}
+
+// writeScenario writes the response for a scenario. For successful scenarios,
+// body is called with the utilization and reset time to build the response.
+func (s *Server) writeScenario(w http.ResponseWriter, scenario Scenario, errBody string, body func(float64, time.Time) any) {
+	switch scenario {
+	case ScenarioExpired:
+		writeRaw(w, http.StatusUnauthorized, errBody)
+		return
+	case ScenarioRateLimited:
+		w.Header().Set("Retry-After", "1")
+		writeRaw(w, http.StatusTooManyRequests, `{"error":"rate limited"}`)
+		return
+	}
+
+	data, err := json.Marshal(body(s.level(scenario)))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if scenario == ScenarioMalformed {
+		data = data[:len(data)/2]
+	}
+	writeRaw(w, http.StatusOK, string(data))
+}
+
+// writeRaw writes a JSON response body with the given status
+func writeRaw(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(body))
+}
+
+// handleClaudeUsage emulates the Anthropic OAuth usage endpoint
+func (s *Server) handleClaudeUsage(w http.ResponseWriter, _ *http.Request) {
+	const expired = `{"type":"error","error":{"type":"authentication_error","message":"OAuth token has expired."}}`
+	s.writeScenario(w, s.next("claude"), expired, func(utilization float64, resetsAt time.Time) any {
+		weekly := resetsAt.Add(6 * 24 * time.Hour)
+		return map[string]any{
+			"five_hour":      map[string]any{"utilization": round(utilization), "resets_at": resetsAt.UTC()},
+			"seven_day":      map[string]any{"utilization": round(utilization / 3), "resets_at": weekly.UTC()},
+			"seven_day_opus": map[string]any{"utilization": round(utilization / 5), "resets_at": weekly.UTC()},
+		}
+	})
+}
+
+// handleKimiUsage emulates the Kimi billing usage endpoint
+func (s *Server) handleKimiUsage(w http.ResponseWriter, _ *http.Request) {
+	const expired = `{"code":"unauthenticated","message":"token expired"}`
+	s.writeScenario(w, s.next("kimi"), expired, func(utilization float64, resetsAt time.Time) any {
+		detail := func(limit float64, resetsAt time.Time) map[string]any {
+			return map[string]any{
+				"limit":     strconv.FormatFloat(limit, 'f', -1, 64),
+				"used":      strconv.Itoa(int(limit * utilization / 100)),
+				"resetTime": resetsAt.UTC().Format(time.RFC3339Nano),
+			}
+		}
+		return map[string]any{
+			"usages": []map[string]any{{
+				"scope":  "FEATURE_CODING",
+				"detail": detail(2048, resetsAt.Add(6*24*time.Hour)),
+				"limits": []map[string]any{{
+					"window": map[string]any{"duration": 300, "timeUnit": "TIME_UNIT_MINUTE"},
+					"detail": detail(200, resetsAt),
+				}},
+			}},
+		}
+	})
+}
+
+// handleKimiSubscription emulates the Kimi subscription endpoint
+func (s *Server) handleKimiSubscription(w http.ResponseWriter, _ *http.Request) {
+	const expired = `{"code":"unauthenticated","message":"token expired"}`
+	s.writeScenario(w, s.current("kimi"), expired, func(_ float64, _ time.Time) any {
+		start := s.start.UTC().Truncate(24 * time.Hour)
+		return map[string]any{
+			"subscribed": true,
+			"subscription": map[string]any{
+				"subscriptionId":   "mock-subscription",
+				"status":           "SUBSCRIPTION_STATUS_ACTIVE",
+				"currentStartTime": start.Format(time.RFC3339),
+				"currentEndTime":   start.AddDate(0, 1, 0).Format(time.RFC3339),
+				"goods": map[string]any{
+					"title":           "Moderato",
+					"membershipLevel": "LEVEL_INTERMEDIATE",
+					"amounts":         []map[string]any{{"currency": "USD", "priceInCents": "1900"}},
+					"billingCycle":    map[string]any{"duration": 1, "timeUnit": "TIME_UNIT_MONTH"},
+				},
+			},
+		}
+	})
+}
+
+// handleMiniMaxUsage emulates the MiniMax coding plan endpoint
+func (s *Server) handleMiniMaxUsage(w http.ResponseWriter, _ *http.Request) {
+	const expired = `{"base_resp":{"status_code":1004,"status_msg":"cookie is expired"}}`
+	s.writeScenario(w, s.next("minimax"), expired, func(utilization float64, resetsAt time.Time) any {
+		const total = 1500
+		now := s.now()
+		return map[string]any{
+			"model_remains": []map[string]any{{
+				"model_name":                   "MiniMax-M2",
+				"start_time":                   now.Add(-5 * time.Hour).UnixMilli(),
+				"end_time":                     resetsAt.UnixMilli(),
+				"remains_time":                 resetsAt.Sub(now).Milliseconds(),
+				"current_interval_total_count": total,
+				"current_interval_usage_count": int(total * utilization / 100),
+			}},
+			"base_resp": map[string]any{"status_code": 0, "status_msg": "success"},
+		}
+	})
+}
+
+// handleMiniMaxSubscription emulates the MiniMax subscription endpoint
+func (s *Server) handleMiniMaxSubscription(w http.ResponseWriter, _ *http.Request) {
+	const expired = `{"base_resp":{"status_code":1004,"status_msg":"cookie is expired"}}`
+	s.writeScenario(w, s.current("minimax"), expired, func(_ float64, _ time.Time) any {
+		return map[string]any{"base_resp": map[string]any{"status_code": 0, "status_msg": "success"}}
+	})
+}
+
+// handleGetScenario returns the script and request count of every provider
+func (s *Server) handleGetScenario(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	state := make(map[string]providerState, len(s.scripts))
+	for id, script := range s.scripts {
+		state[id] = providerState{Script: script.String(), Requests: s.requests[id], Scenario: script.at(s.requests[id])}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(state)
+}
+
+// handleSetScenario replaces the script of one provider, or of all providers when
+// none is given, and restarts it: POST /_mock/scenario?provider=claude&script=expired
+func (s *Server) handleSetScenario(w http.ResponseWriter, r *http.Request) {
+	script, err := ParseScript(r.FormValue("script"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ids := Providers
+	if id := r.FormValue("provider"); id != "" {
+		if !slices.Contains(Providers, id) {
+			http.Error(w, fmt.Sprintf("unknown provider %q", id), http.StatusBadRequest)
+			return
+		}
+		ids = []string{id}
+	}
+
+	s.mu.Lock()
+	for _, id := range ids {
+		s.scripts[id] = script
+		s.requests[id] = 0
+	}
+	s.mu.Unlock()
+
+	log.Printf("Scenario for %v set to %s", ids, script)
+	s.handleGetScenario(w, r)
+}
+
+// round rounds a percentage to one decimal place, like the real APIs report it
+func round(v float64) float64 {
+	return float64(int(v*10+0.5)) / 10
+}
@@ -0,0 +1,190 @@
+package mockserver
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider/claude"
+	"github.com/denysvitali/llm-usage/internal/provider/kimi"
+	"github.com/denysvitali/llm-usage/internal/provider/minimax"
+)
+
+func newTestServer(t *testing.T, cfg *Config) (*Server, *httptest.Server) {
+	t.Helper()
+	s := NewServer(cfg)
+	start := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	s.start = start
+	s.now = func() time.Time { return start.Add(cfg.Period / 4) }
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+func request(t *testing.T, method, u string) (int, []byte) {
+	t.Helper()
+	req, _ := http.NewRequest(method, u, strings.NewReader("{}"))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error = %v", method, u, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, body
+}
+
+func TestParseScript(t *testing.T) {
+	script, err := ParseScript("ramp:2, ratelimited:1,expired")
+	if err != nil {
+		t.Fatalf("ParseScript() error = %v", err)
+	}
+	want := []Scenario{ScenarioRamp, ScenarioRamp, ScenarioRateLimited, ScenarioExpired, ScenarioExpired}
+	for i, w := range want {
+		if got := script.at(i); got != w {
+			t.Errorf("at(%d) = %s, want %s", i, got, w)
+		}
+	}
+	if script.String() != "ramp:2,ratelimited:1,expired" {
+		t.Errorf("String() = %q", script.String())
+	}
+
+	for _, bad := range []string{"", "sunny", "ramp:0", "ramp:x"} {
+		if _, err := ParseScript(bad); err == nil {
+			t.Errorf("ParseScript(%q) expected error", bad)
+		}
+	}
+}
+
+func TestServer_Usage(t *testing.T) {
+	_, ts := newTestServer(t, &Config{Period: time.Hour})
+
+	status, body := request(t, http.MethodGet, ts.URL+claudeUsagePath)
+	var claudeUsage claude.UsageResponse
+	if status != http.StatusOK || json.Unmarshal(body, &claudeUsage) != nil {
+		t.Fatalf("claude: %d %s", status, body)
+	}
+	if claudeUsage.FiveHour.Utilization != 25 || claudeUsage.FiveHour.ResetsAt == nil {
+		t.Errorf("claude five_hour = %+v", claudeUsage.FiveHour)
+	}
+
+	status, body = request(t, http.MethodPost, ts.URL+kimiUsagePath)
+	var kimiUsage kimi.UsageResponse
+	if status != http.StatusOK || json.Unmarshal(body, &kimiUsage) != nil || len(kimiUsage.Usages) != 1 {
+		t.Fatalf("kimi: %d %s", status, body)
+	}
+	if got := kimiUsage.Usages[0].Limits[0].Detail.Used; got != "50" {
+		t.Errorf("kimi rate limit used = %s, want 50", got)
+	}
+
+	status, body = request(t, http.MethodGet, ts.URL+minimaxUsagePath+"?GroupId=1")
+	var minimaxUsage minimax.CodingPlanResponse
+	if status != http.StatusOK || json.Unmarshal(body, &minimaxUsage) != nil || len(minimaxUsage.ModelRemains) != 1 {
+		t.Fatalf("minimax: %d %s", status, body)
+	}
+	if got := minimaxUsage.ModelRemains[0].CurrentIntervalUsageCount; got != 375 {
+		t.Errorf("minimax usage count = %d, want 375", got)
+	}
+}
+
+func TestServer_ClaudeProvider(t *testing.T) {
+	_, ts := newTestServer(t, &Config{Period: time.Hour})
+
+	usage, err := claude.NewProvider("mock-token", ts.URL).GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if len(usage.Windows) != 3 || usage.Windows[0].Label != "5-Hour" || usage.Windows[0].Utilization != 25 {
+		t.Errorf("windows = %+v", usage.Windows)
+	}
+}
+
+func TestServer_ClaudeOAuth(t *testing.T) {
+	_, ts := newTestServer(t, &Config{Period: time.Hour})
+	oauth := claude.OAuthConfig{AuthorizeURL: ts.URL + claudeAuthorizePath, TokenURL: ts.URL + claudeTokenPath}
+
+	pkce, err := claude.NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	callback, err := claude.ListenCallback(pkce.State)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = callback.Close() }()
+
+	// The browser follows the redirect to the loopback callback
+	if status, body := request(t, http.MethodGet, oauth.AuthCodeURL(pkce, callback.RedirectURI)); status != http.StatusOK {
+		t.Fatalf("authorize: %d %s", status, body)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	code, err := callback.Wait(ctx)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	token, err := oauth.Exchange(ctx, code, pkce, callback.RedirectURI)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if token.AccessToken != "mock-access-1" || token.RefreshToken == "" || token.ExpiresAt(time.Now()) == 0 {
+		t.Errorf("token = %+v", token)
+	}
+
+	// Codes are single-use, and pasted codes must carry the request's state
+	if _, err := oauth.Exchange(ctx, code, pkce, callback.RedirectURI); err == nil {
+		t.Error("Exchange() expected error for a reused code")
+	}
+	if _, err := oauth.Exchange(ctx, code+"#other-state", pkce, callback.RedirectURI); err == nil {
+		t.Error("Exchange() expected error for a state mismatch")
+	}
+}
+
+func TestServer_Script(t *testing.T) {
+	script, _ := ParseScript("reset:1,ratelimited:1,malformed:1,expired")
+	_, ts := newTestServer(t, &Config{Period: time.Hour, Scripts: map[string]Script{"claude": script}})
+
+	wantStatus := []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK, http.StatusUnauthorized, http.StatusUnauthorized}
+	for i, want := range wantStatus {
+		status, body := request(t, http.MethodGet, ts.URL+claudeUsagePath)
+		if status != want {
+			t.Errorf("request %d status = %d, want %d", i, status, want)
+		}
+		if i == 2 && json.Valid(body) {
+			t.Errorf("malformed scenario returned valid JSON: %s", body)
+		}
+	}
+
+	// Other providers keep the default script
+	if status, _ := request(t, http.MethodPost, ts.URL+kimiUsagePath); status != http.StatusOK {
+		t.Errorf("kimi status = %d, want 200", status)
+	}
+}
+
+func TestServer_SetScenario(t *testing.T) {
+	_, ts := newTestServer(t, &Config{Period: time.Hour})
+
+	status, _ := request(t, http.MethodPost, ts.URL+controlPath+"?"+url.Values{"provider": {"minimax"}, "script": {"expired"}}.Encode())
+	if status != http.StatusOK {
+		t.Fatalf("set scenario status = %d", status)
+	}
+	if status, _ := request(t, http.MethodGet, ts.URL+minimaxUsagePath); status != http.StatusUnauthorized {
+		t.Errorf("minimax usage status = %d, want 401", status)
+	}
+	if status, _ := request(t, http.MethodGet, ts.URL+minimaxSubscriptionPath); status != http.StatusUnauthorized {
+		t.Errorf("minimax subscription status = %d, want 401", status)
+	}
+	if status, _ := request(t, http.MethodGet, ts.URL+claudeUsagePath); status != http.StatusOK {
+		t.Errorf("claude usage status = %d, want 200", status)
+	}
+
+	if status, _ := request(t, http.MethodPost, ts.URL+controlPath+"?provider=zai&script=expired"); status != http.StatusBadRequest {
+		t.Errorf("unknown provider status = %d, want 400", status)
+	}
+}
@@ -0,0 +1,88 @@
+package anthropicapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/httpclient"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+const (
+	// DefaultBaseURL is the Anthropic API base URL
+	DefaultBaseURL   = "https://api.anthropic.com"
+	apiVersion       = "2023-06-01"
+	messagesEndpoint = "/v1/organizations/usage_report/messages"
+	costEndpoint     = "/v1/organizations/cost_report"
+)
+
+// Client is an HTTP client for the Anthropic Admin API usage and cost reports
+type Client struct {
+	http     *httpclient.Client
+	adminKey string
+	baseURL  string
+}
+
+// NewClient creates a new API client with the given admin API key.
+// An empty baseURL selects the public Anthropic API.
+func NewClient(adminKey, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		http:     httpclient.Default(),
+		adminKey: adminKey,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// GetMessagesUsage fetches daily messages usage grouped by workspace, API key and model since the given time
+func (c *Client) GetMessagesUsage(since time.Time) ([]Bucket[MessagesResult], error) {
+	query := url.Values{}
+	query.Set("starting_at", since.UTC().Format(time.RFC3339))
+	query.Set("bucket_width", "1d")
+	query.Set("limit", "31")
+	query.Add("group_by[]", "workspace_id")
+	query.Add("group_by[]", "api_key_id")
+	query.Add("group_by[]", "model")
+
+	return provider.GetAllPages(query, func(query url.Values, page *provider.Page[Bucket[MessagesResult]]) error {
+		return c.get(messagesEndpoint, query, page)
+	})
+}
+
+// GetCostReport fetches daily costs grouped by workspace and description since the given time.
+// Grouping by description breaks costs down per model.
+func (c *Client) GetCostReport(since time.Time) ([]Bucket[CostResult], error) {
+	query := url.Values{}
+	query.Set("starting_at", since.UTC().Format(time.RFC3339))
+	query.Set("bucket_width", "1d")
+	query.Set("limit", "31")
+	query.Add("group_by[]", "workspace_id")
+	query.Add("group_by[]", "description")
+
+	return provider.GetAllPages(query, func(query url.Values, page *provider.Page[Bucket[CostResult]]) error {
+		return c.get(costEndpoint, query, page)
+	})
+}
+
+// get performs an authenticated GET request and decodes the JSON response into target
+func (c *Client) get(endpoint string, query url.Values, target any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	reqURL := c.baseURL + endpoint + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("X-Api-Key", c.adminKey)
+	req.Header.Set("Anthropic-Version", apiVersion)
+
+	return c.http.Send(req, target)
+}
@@ -0,0 +1,35 @@
+package anthropicapi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+const (
+	countTokensEndpoint = "/v1/messages/count_tokens"
+	probeModel          = "claude-haiku-4-5"
+)
+
+// NewRateLimitProbe returns a probe that reads the live rate limits of a regular
+// (non-admin) API key. Admin keys cannot call the Messages API, so a separate key
+// is needed. Token counting is free, so the probe does not incur any cost.
+// An empty baseURL selects the public Anthropic API.
+func NewRateLimitProbe(apiKey, baseURL string) *provider.RateLimitProbe {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	header := http.Header{}
+	header.Set("X-Api-Key", apiKey)
+	header.Set("Anthropic-Version", apiVersion)
+	header.Set("Content-Type", "application/json")
+
+	return &provider.RateLimitProbe{
+		Method: http.MethodPost,
+		URL:    strings.TrimSuffix(baseURL, "/") + countTokensEndpoint,
+		Header: header,
+		Body:   `{"model":"` + probeModel + `","messages":[{"role":"user","content":"."}]}`,
+	}
+}
@@ -0,0 +1,160 @@
+// Package anthropicapi implements the Anthropic Admin API usage and cost report provider for llm-usage.
+package anthropicapi
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+const (
+	defaultWorkspace = "default"
+	unknownModel     = "unknown"
+	noAPIKey         = "none"
+)
+
+// breakdown accumulates month-to-date usage for a model, workspace or API key
+type breakdown struct {
+	InputTokens  int64
+	OutputTokens int64
+	CostUSD      float64
+}
+
+// Provider implements the provider.Provider interface for the Anthropic API
+type Provider struct {
+	client *Client
+	budget provider.Budget
+	now    func() time.Time
+}
+
+// NewProvider creates a new Anthropic API provider with the given admin API key.
+// An empty baseURL selects the public Anthropic API.
+func NewProvider(adminKey, baseURL string, budget provider.Budget) *Provider {
+	return &Provider{
+		client: NewClient(adminKey, baseURL),
+		budget: budget,
+		now:    time.Now,
+	}
+}
+
+// Name returns the provider's display name
+func (p *Provider) Name() string {
+	return "Anthropic API"
+}
+
+// ID returns the provider's unique identifier
+func (p *Provider) ID() string {
+	return "anthropic-api"
+}
+
+// GetUsage fetches the current billing month's token usage and costs
+func (p *Provider) GetUsage() (*provider.Usage, error) {
+	billing := provider.NewBilling(p.now())
+
+	costs, err := p.client.GetCostReport(billing.MonthStart)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := p.client.GetMessagesUsage(billing.MonthStart)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make(map[string]*breakdown)
+	workspaces := make(map[string]*breakdown)
+	// The cost report cannot be grouped by API key, so API keys only carry token counts
+	apiKeys := make(map[string]*breakdown)
+
+	for _, b := range costs {
+		for _, r := range b.Results {
+			cents, err := strconv.ParseFloat(r.Amount, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse cost amount %q: %w", r.Amount, err)
+			}
+			cost := cents / 100
+			billing.AddCost(b.StartingAt, cost)
+
+			// Non-token costs (e.g. web search) are not attributed to a model
+			if r.Model != nil {
+				entry(models, *r.Model).CostUSD += cost
+			}
+			entry(workspaces, valueOr(r.WorkspaceID, defaultWorkspace)).CostUSD += cost
+		}
+	}
+
+	for _, b := range messages {
+		for _, r := range b.Results {
+			input := r.InputTokens()
+			billing.AddTokens(b.StartingAt, float64(input+r.OutputTokens))
+
+			for _, e := range []*breakdown{
+				entry(models, valueOr(r.Model, unknownModel)),
+				entry(workspaces, valueOr(r.WorkspaceID, defaultWorkspace)),
+				entry(apiKeys, valueOr(r.APIKeyID, noAPIKey)),
+			} {
+				e.InputTokens += input
+				e.OutputTokens += r.OutputTokens
+			}
+		}
+	}
+
+	extra := billing.Extra()
+	if len(models) > 0 {
+		extra["models"] = breakdownList("model", models)
+	}
+	if len(workspaces) > 0 {
+		extra["workspaces"] = breakdownList("workspace_id", workspaces)
+	}
+	if len(apiKeys) > 0 {
+		extra["api_keys"] = breakdownList("api_key_id", apiKeys)
+	}
+
+	return &provider.Usage{
+		Provider: "anthropic-api",
+		Windows:  billing.Windows(p.budget),
+		Extra:    extra,
+	}, nil
+}
+
+// entry returns the breakdown for key, creating it if needed
+func entry(m map[string]*breakdown, key string) *breakdown {
+	e, ok := m[key]
+	if !ok {
+		e = &breakdown{}
+		m[key] = e
+	}
+	return e
+}
+
+// valueOr dereferences s, returning fallback when it is nil or empty
+func valueOr(s *string, fallback string) string {
+	if s == nil || *s == "" {
+		return fallback
+	}
+	return *s
+}
+
+// breakdownList converts a breakdown map to a list sorted by key
+func breakdownList(keyName string, m map[string]*breakdown) []map[string]any {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	list := make([]map[string]any, 0, len(keys))
+	for _, k := range keys {
+		e := m[k]
+		list = append(list, map[string]any{
+			keyName:         k,
+			"input_tokens":  e.InputTokens,
+			"output_tokens": e.OutputTokens,
+			"cost_usd":      e.CostUSD,
+		})
+	}
+	return list
+}
@@ -0,0 +1,131 @@
+package anthropicapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func TestProvider_GetUsage(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	yesterday := "2026-03-14T00:00:00Z"
+	today := "2026-03-15T00:00:00Z"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+costEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Api-Key"); got != "sk-ant-admin" {
+			t.Errorf("X-Api-Key = %q", got)
+		}
+		if got := r.Header.Get("Anthropic-Version"); got != apiVersion {
+			t.Errorf("Anthropic-Version = %q", got)
+		}
+		if got := r.URL.Query().Get("starting_at"); got != "2026-03-01T00:00:00Z" {
+			t.Errorf("starting_at = %q", got)
+		}
+		// Paginate to exercise next_page handling
+		if r.URL.Query().Get("page") == "" {
+			fmt.Fprintf(w, `{"data":[{"starting_at":%q,"results":[
+				{"currency":"USD","amount":"3000","workspace_id":null,"model":"claude-sonnet-4"}
+			]}],"has_more":true,"next_page":"p2"}`, yesterday)
+			return
+		}
+		fmt.Fprintf(w, `{"data":[{"starting_at":%q,"results":[
+			{"currency":"USD","amount":"200","workspace_id":"wrk_1","model":"claude-opus-4"},
+			{"currency":"USD","amount":"50","workspace_id":"wrk_1","model":null}
+		]}],"has_more":false}`, today)
+	})
+	mux.HandleFunc("GET "+messagesEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query()["group_by[]"]; !slices.Equal(got, []string{"workspace_id", "api_key_id", "model"}) {
+			t.Errorf("group_by[] = %v", got)
+		}
+		fmt.Fprintf(w, `{"data":[
+			{"starting_at":%q,"results":[{"uncached_input_tokens":1000,"cache_read_input_tokens":200,"cache_creation":{"ephemeral_5m_input_tokens":100},"output_tokens":500,"workspace_id":null,"api_key_id":"key_1","model":"claude-sonnet-4"}]},
+			{"starting_at":%q,"results":[{"uncached_input_tokens":100,"output_tokens":50,"workspace_id":"wrk_1","api_key_id":"key_2","model":"claude-opus-4"}]}
+		],"has_more":false}`, yesterday, today)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	monthly := 100.0
+	p := NewProvider("sk-ant-admin", server.URL, provider.Budget{Monthly: &monthly})
+	p.now = func() time.Time { return now }
+
+	usage, err := p.GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+
+	if len(usage.Windows) != 4 {
+		t.Fatalf("expected 4 windows, got %d", len(usage.Windows))
+	}
+
+	month := usage.Windows[0]
+	if *month.Used != 32.5 || *month.Limit != 100 || month.Utilization != 32.5 {
+		t.Errorf("month spend window = used %v limit %v util %v", *month.Used, *month.Limit, month.Utilization)
+	}
+	if !month.ResetsAt.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("month resets at %v, want 2026-04-01", month.ResetsAt)
+	}
+
+	day := usage.Windows[1]
+	if *day.Used != 2.5 || day.Limit != nil || day.Utilization != 0 {
+		t.Errorf("day spend window = %+v", day)
+	}
+
+	if got := *usage.Windows[2].Used; got != 1950 {
+		t.Errorf("month tokens = %v, want 1950", got)
+	}
+	if got := *usage.Windows[3].Used; got != 150 {
+		t.Errorf("day tokens = %v, want 150", got)
+	}
+
+	models, ok := usage.Extra["models"].([]map[string]any)
+	if !ok || len(models) != 2 {
+		t.Fatalf("Extra[models] = %v", usage.Extra["models"])
+	}
+	if models[1]["model"] != "claude-sonnet-4" || models[1]["input_tokens"] != int64(1300) || models[1]["cost_usd"] != 30.0 {
+		t.Errorf("claude-sonnet-4 breakdown = %v", models[1])
+	}
+
+	workspaces, ok := usage.Extra["workspaces"].([]map[string]any)
+	if !ok || len(workspaces) != 2 {
+		t.Fatalf("Extra[workspaces] = %v", usage.Extra["workspaces"])
+	}
+	if workspaces[0]["workspace_id"] != defaultWorkspace || workspaces[1]["cost_usd"] != 2.5 {
+		t.Errorf("workspaces breakdown = %v", workspaces)
+	}
+
+	if apiKeys, ok := usage.Extra["api_keys"].([]map[string]any); !ok || len(apiKeys) != 2 {
+		t.Errorf("Extra[api_keys] = %v", usage.Extra["api_keys"])
+	}
+}
+
+func TestProvider_GetUsageInvalidAmount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"data":[{"starting_at":"2026-03-01T00:00:00Z","results":[{"currency":"USD","amount":"n/a"}]}],"has_more":false}`)
+	}))
+	defer server.Close()
+
+	p := NewProvider("sk-ant-admin", server.URL, provider.Budget{})
+	if _, err := p.GetUsage(); err == nil {
+		t.Error("GetUsage() expected error for unparsable amount")
+	}
+}
+
+func TestProvider_GetUsageUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	p := NewProvider("bad", server.URL, provider.Budget{})
+	if _, err := p.GetUsage(); err == nil {
+		t.Error("GetUsage() expected error for 401 response")
+	}
+}
@@ -0,0 +1,43 @@
+package anthropicapi
+
+import "time"
+
+// Bucket aggregates results over a time bucket
+type Bucket[T any] struct {
+	StartingAt time.Time `json:"starting_at"`
+	EndingAt   time.Time `json:"ending_at"`
+	Results    []T       `json:"results"`
+}
+
+// MessagesResult is a messages usage entry, grouped by workspace, API key and model
+type MessagesResult struct {
+	UncachedInputTokens  int64         `json:"uncached_input_tokens"`
+	CacheCreation        CacheCreation `json:"cache_creation"`
+	CacheReadInputTokens int64         `json:"cache_read_input_tokens"`
+	OutputTokens         int64         `json:"output_tokens"`
+	WorkspaceID          *string       `json:"workspace_id"` // nil for the default workspace
+	APIKeyID             *string       `json:"api_key_id"`   // nil for usage outside the API (e.g. Workbench)
+	Model                *string       `json:"model"`
+}
+
+// CacheCreation holds the number of input tokens written to the prompt cache
+type CacheCreation struct {
+	Ephemeral1hInputTokens int64 `json:"ephemeral_1h_input_tokens"`
+	Ephemeral5mInputTokens int64 `json:"ephemeral_5m_input_tokens"`
+}
+
+// InputTokens returns the total number of input tokens, including cache reads and writes
+func (r MessagesResult) InputTokens() int64 {
+	return r.UncachedInputTokens + r.CacheReadInputTokens +
+		r.CacheCreation.Ephemeral1hInputTokens + r.CacheCreation.Ephemeral5mInputTokens
+}
+
+// CostResult is a cost entry, grouped by workspace and description
+type CostResult struct {
+	Currency    string  `json:"currency"`
+	Amount      string  `json:"amount"` // Decimal string in cents
+	WorkspaceID *string `json:"workspace_id"`
+	Description *string `json:"description"`
+	CostType    *string `json:"cost_type"`
+	Model       *string `json:"model"`
+}
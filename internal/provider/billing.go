@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// MaxPages bounds the pages GetAllPages follows
+const MaxPages = 10
+
+// Budget holds optional spending limits in US dollars
+type Budget struct {
+	Monthly *float64
+	Daily   *float64
+}
+
+// Page is a cursor-paginated response of the organization usage and cost APIs
+type Page[T any] struct {
+	Data     []T    `json:"data"`
+	HasMore  bool   `json:"has_more"`
+	NextPage string `json:"next_page"`
+}
+
+// GetAllPages follows next_page cursors, fetching each page with get, and returns the
+// data of all pages. Results spanning more than MaxPages pages are an error rather than
+// partial totals.
+func GetAllPages[T any](query url.Values, get func(query url.Values, page *Page[T]) error) ([]T, error) {
+	var data []T
+	for range MaxPages {
+		var page Page[T]
+		if err := get(query, &page); err != nil {
+			return nil, err
+		}
+		data = append(data, page.Data...)
+		if !page.HasMore || page.NextPage == "" {
+			return data, nil
+		}
+		query.Set("page", page.NextPage)
+	}
+	return nil, fmt.Errorf("results span more than %d pages", MaxPages)
+}
+
+// Billing accumulates the spend and token counts of the current UTC billing month and day
+type Billing struct {
+	MonthStart time.Time
+	DayStart   time.Time
+
+	monthCost, dayCost     float64
+	monthTokens, dayTokens float64
+}
+
+// NewBilling starts the billing month and day containing now
+func NewBilling(now time.Time) *Billing {
+	now = now.UTC()
+	return &Billing{
+		MonthStart: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+		DayStart:   time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// AddCost adds the US dollar cost of the bucket starting at start
+func (b *Billing) AddCost(start time.Time, usd float64) {
+	b.monthCost += usd
+	if !start.Before(b.DayStart) {
+		b.dayCost += usd
+	}
+}
+
+// AddTokens adds the token count of the bucket starting at start
+func (b *Billing) AddTokens(start time.Time, tokens float64) {
+	b.monthTokens += tokens
+	if !start.Before(b.DayStart) {
+		b.dayTokens += tokens
+	}
+}
+
+// Windows returns the spend windows, measured against budget, and the token windows
+func (b *Billing) Windows(budget Budget) []UsageWindow {
+	monthEnd := b.MonthStart.AddDate(0, 1, 0)
+	dayEnd := b.DayStart.AddDate(0, 0, 1)
+	return []UsageWindow{
+		spendWindow("Month Spend", b.monthCost, budget.Monthly, monthEnd),
+		spendWindow("Today Spend", b.dayCost, budget.Daily, dayEnd),
+		tokenWindow("Month Tokens", b.monthTokens, monthEnd),
+		tokenWindow("Today Tokens", b.dayTokens, dayEnd),
+	}
+}
+
+// Extra returns the costs reported in the extra usage information
+func (b *Billing) Extra() map[string]any {
+	return map[string]any{
+		"month_cost_usd": b.monthCost,
+		"day_cost_usd":   b.dayCost,
+	}
+}
+
+// spendWindow builds a dollar spend window, measured against budget when configured
+func spendWindow(label string, spent float64, budget *float64, resetsAt time.Time) UsageWindow {
+	w := UsageWindow{
+		Label:     label,
+		ResetsAt:  &resetsAt,
+		Used:      &spent,
+		Unbounded: true,
+	}
+	if budget != nil && *budget > 0 {
+		limit := *budget
+		remaining := limit - spent
+		w.Limit = &limit
+		w.Remaining = &remaining
+		w.Utilization = spent / limit * 100
+		w.Unbounded = false
+	}
+	return w
+}
+
+// tokenWindow builds a token count window without a limit
+func tokenWindow(label string, tokens float64, resetsAt time.Time) UsageWindow {
+	return UsageWindow{
+		Label:     label,
+		ResetsAt:  &resetsAt,
+		Used:      &tokens,
+		Unbounded: true,
+	}
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetAllPages(t *testing.T) {
+	pages := func(n int) func(url.Values, *Page[int]) error {
+		return func(query url.Values, page *Page[int]) error {
+			i, _ := strconv.Atoi(query.Get("page"))
+			page.Data = []int{i}
+			if i+1 < n {
+				page.HasMore = true
+				page.NextPage = fmt.Sprint(i + 1)
+			}
+			return nil
+		}
+	}
+
+	data, err := GetAllPages(url.Values{}, pages(3))
+	if err != nil {
+		t.Fatalf("GetAllPages() error = %v", err)
+	}
+	if fmt.Sprint(data) != "[0 1 2]" {
+		t.Errorf("GetAllPages() = %v, want [0 1 2]", data)
+	}
+
+	if _, err := GetAllPages(url.Values{}, pages(MaxPages+1)); err == nil || !strings.Contains(err.Error(), "more than 10 pages") {
+		t.Errorf("GetAllPages() past the page limit error = %v, want page limit error", err)
+	}
+}
+
+func TestBilling_Windows(t *testing.T) {
+	b := NewBilling(time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC))
+	b.AddCost(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC), 30)
+	b.AddCost(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), 10)
+	b.AddTokens(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), 500)
+
+	monthly := 80.0
+	windows := b.Windows(Budget{Monthly: &monthly})
+	if len(windows) != 4 {
+		t.Fatalf("expected 4 windows, got %d", len(windows))
+	}
+
+	if w := windows[0]; *w.Used != 40 || w.Utilization != 50 || w.Unbounded {
+		t.Errorf("month spend window = %+v", w)
+	}
+	if w := windows[1]; *w.Used != 10 || w.Limit != nil || !w.Unbounded {
+		t.Errorf("day spend window without budget = %+v", w)
+	}
+	for _, w := range windows[2:] {
+		if !w.Unbounded || *w.Used != 500 {
+			t.Errorf("token window = %+v", w)
+		}
+	}
+	if !windows[1].ResetsAt.Equal(time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("day resets at %v, want 2026-03-16", windows[1].ResetsAt)
+	}
+}
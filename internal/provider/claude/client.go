@@ -3,35 +3,38 @@ package claude
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"strings"
 	"time"
 
-	"github.com/denysvitali/llm-usage/internal/version"
+	"github.com/denysvitali/llm-usage/internal/httpclient"
 )
 
 const (
-	baseURL       = "https://api.anthropic.com"
-	usageEndpoint = "/api/oauth/usage"
-	userAgent     = "llm-usage/1.0.0"
-	betaHeader    = "oauth-2025-04-20"
+	// DefaultBaseURL is the Anthropic API base URL
+	DefaultBaseURL = "https://api.anthropic.com"
+	usageEndpoint  = "/api/oauth/usage"
+	betaHeader     = "oauth-2025-04-20"
 )
 
 // Client is an HTTP client for the Anthropic OAuth API
 type Client struct {
-	httpClient  *http.Client
+	http        *httpclient.Client
 	accessToken string
+	baseURL     string
 }
 
-// NewClient creates a new API client with the given access token
-func NewClient(accessToken string) *Client {
+// NewClient creates a new API client with the given access token.
+// An empty baseURL selects the public Anthropic API.
+func NewClient(accessToken, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		http:        httpclient.Default(),
 		accessToken: accessToken,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
 	}
 }
 
@@ -40,35 +43,18 @@ func (c *Client) GetUsage() (*UsageResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+usageEndpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+usageEndpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.accessToken)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "llm-usage/"+version.Version)
 	req.Header.Set("anthropic-beta", betaHeader)
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
 	var usage UsageResponse
-	if err := json.Unmarshal(body, &usage); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.http.Send(req, &usage); err != nil {
+		return nil, err
 	}
 
 	return &usage, nil
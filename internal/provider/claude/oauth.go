@@ -0,0 +1,267 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/httpclient"
+)
+
+// OAuth settings of the Claude CLI, whose tokens grant access to the usage endpoint
+const (
+	DefaultOAuthClientID  = "9d1c250a-e61b-44d9-88ed-5944d1962f5e"
+	DefaultAuthorizeURL   = "https://claude.ai/oauth/authorize"
+	DefaultTokenURL       = "https://console.anthropic.com/v1/oauth/token"
+	oauthCallbackPath     = "/callback"
+	oauthExchangeTimeout  = 30 * time.Second
+	oauthVerifierByteSize = 32
+)
+
+// DefaultOAuthScopes are the scopes requested by the login flow
+var DefaultOAuthScopes = []string{"org:create_api_key", "user:profile", "user:inference"}
+
+// OAuthConfig describes the OAuth authorization server.
+// Empty fields select the Claude defaults.
+type OAuthConfig struct {
+	ClientID     string
+	AuthorizeURL string
+	TokenURL     string
+	Scopes       []string
+}
+
+func (c OAuthConfig) withDefaults() OAuthConfig {
+	if c.ClientID == "" {
+		c.ClientID = DefaultOAuthClientID
+	}
+	if c.AuthorizeURL == "" {
+		c.AuthorizeURL = DefaultAuthorizeURL
+	}
+	if c.TokenURL == "" {
+		c.TokenURL = DefaultTokenURL
+	}
+	if len(c.Scopes) == 0 {
+		c.Scopes = DefaultOAuthScopes
+	}
+	return c
+}
+
+// PKCE holds the proof key and state of one authorization request (RFC 7636)
+type PKCE struct {
+	Verifier  string
+	Challenge string
+	State     string
+}
+
+// NewPKCE generates a random code verifier, its S256 challenge and a state value
+func NewPKCE() (*PKCE, error) {
+	verifier, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return &PKCE{
+		Verifier:  verifier,
+		Challenge: base64.RawURLEncoding.EncodeToString(sum[:]),
+		State:     state,
+	}, nil
+}
+
+func randomString() (string, error) {
+	b := make([]byte, oauthVerifierByteSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// AuthCodeURL returns the URL the user opens to authorize llm-usage
+func (c OAuthConfig) AuthCodeURL(pkce *PKCE, redirectURI string) string {
+	c = c.withDefaults()
+	params := url.Values{
+		"code":                  {"true"},
+		"client_id":             {c.ClientID},
+		"response_type":         {"code"},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(c.Scopes, " ")},
+		"code_challenge":        {pkce.Challenge},
+		"code_challenge_method": {"S256"},
+		"state":                 {pkce.State},
+	}
+	sep := "?"
+	if strings.Contains(c.AuthorizeURL, "?") {
+		sep = "&"
+	}
+	return c.AuthorizeURL + sep + params.Encode()
+}
+
+// Token is the response of the token endpoint
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // Seconds
+	Scope        string `json:"scope"`
+}
+
+// ExpiresAt returns the token's expiry in Unix milliseconds, as stored in the credentials
+// files, relative to now. Zero means unknown.
+func (t *Token) ExpiresAt(now time.Time) int64 {
+	if t.ExpiresIn <= 0 {
+		return 0
+	}
+	return now.Add(time.Duration(t.ExpiresIn) * time.Second).UnixMilli()
+}
+
+// Scopes returns the granted scopes
+func (t *Token) Scopes() []string {
+	return strings.Fields(t.Scope)
+}
+
+// parseAuthCode extracts the code and state from a pasted callback URL, or from a code
+// of the form "code#state" as shown by the Claude callback page
+func parseAuthCode(input string) (code, state string, hasState bool) {
+	input = strings.TrimSpace(input)
+	if u, err := url.Parse(input); err == nil && u.Query().Has("code") {
+		q := u.Query()
+		return q.Get("code"), q.Get("state"), q.Has("state")
+	}
+	return strings.Cut(input, "#")
+}
+
+// Exchange trades an authorization code for tokens. The code may be pasted as the
+// callback URL or as "code#state", in which case the state must match the request's.
+func (c OAuthConfig) Exchange(ctx context.Context, code string, pkce *PKCE, redirectURI string) (*Token, error) {
+	c = c.withDefaults()
+
+	code, state, hasState := parseAuthCode(code)
+	if code == "" {
+		return nil, errors.New("empty authorization code")
+	}
+	if hasState && state != pkce.State {
+		return nil, errors.New("authorization state mismatch; please restart the login")
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          code,
+		"state":         pkce.State,
+		"client_id":     c.ClientID,
+		"redirect_uri":  redirectURI,
+		"code_verifier": pkce.Verifier,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, oauthExchangeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Authorization codes can only be used once, so the exchange is never retried
+	var token Token
+	if err := httpclient.Default().WithoutRetries().Send(req, &token); err != nil {
+		return nil, fmt.Errorf("token exchange failed: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("token exchange failed: no access token in response")
+	}
+	return &token, nil
+}
+
+// CallbackServer receives the authorization code on a loopback redirect URI
+type CallbackServer struct {
+	RedirectURI string
+
+	server *http.Server
+	codes  chan callbackResult
+}
+
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// ListenCallback starts a callback server on a random loopback port.
+// Callbacks whose state does not match are rejected.
+func ListenCallback(state string) (*CallbackServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start callback server: %w", err)
+	}
+
+	s := &CallbackServer{
+		RedirectURI: fmt.Sprintf("http://localhost:%d%s", listener.Addr().(*net.TCPAddr).Port, oauthCallbackPath),
+		codes:       make(chan callbackResult, 1),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+oauthCallbackPath, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var result callbackResult
+		switch {
+		// Checked first, so that other local pages cannot end the login with an error either
+		case q.Get("state") != state:
+			http.Error(w, "Invalid state", http.StatusBadRequest)
+			return
+		case q.Get("error") != "":
+			result.err = fmt.Errorf("authorization denied: %s", strings.TrimSpace(q.Get("error")+" "+q.Get("error_description")))
+		case q.Get("code") == "":
+			http.Error(w, "Missing code", http.StatusBadRequest)
+			return
+		default:
+			result.code = q.Get("code")
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		message := "Login complete. You can close this window and return to llm-usage."
+		if result.err != nil {
+			message = result.err.Error()
+		}
+		_, _ = fmt.Fprintf(w, "<!doctype html><title>llm-usage</title><p>%s</p>", html.EscapeString(message))
+
+		select {
+		case s.codes <- result:
+		default:
+		}
+	})
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() { _ = s.server.Serve(listener) }()
+	return s, nil
+}
+
+// Wait returns the authorization code received by the callback
+func (s *CallbackServer) Wait(ctx context.Context) (string, error) {
+	select {
+	case result := <-s.codes:
+		return result.code, result.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Close stops the callback server
+func (s *CallbackServer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,52 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestListenCallback_State(t *testing.T) {
+	callback, err := ListenCallback("state-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = callback.Close() }()
+
+	get := func(query url.Values) int {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, callback.RedirectURI+"?"+query.Encode(), nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Callbacks from other pages, without the login's state, neither log in nor end the login
+	for _, query := range []url.Values{
+		{"code": {"code-1"}, "state": {"other"}},
+		{"error": {"access_denied"}, "state": {"other"}},
+		{"error": {"access_denied"}},
+	} {
+		if status := get(query); status != http.StatusBadRequest {
+			t.Errorf("callback %v status = %d, want 400", query, status)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if code, err := callback.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() after mismatched states = %q, %v, want no result", code, err)
+	}
+
+	if status := get(url.Values{"code": {"code-1"}, "state": {"state-1"}}); status != http.StatusOK {
+		t.Errorf("callback status = %d, want 200", status)
+	}
+	if code, err := callback.Wait(context.Background()); err != nil || code != "code-1" {
+		t.Errorf("Wait() = %q, %v, want code-1", code, err)
+	}
+}
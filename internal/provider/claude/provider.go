@@ -11,10 +11,11 @@ type Provider struct {
 	client *Client
 }
 
-// NewProvider creates a new Claude provider with the given access token
-func NewProvider(accessToken string) *Provider {
+// NewProvider creates a new Claude provider with the given access token.
+// An empty baseURL selects the public Anthropic API.
+func NewProvider(accessToken, baseURL string) *Provider {
 	return &Provider{
-		client: NewClient(accessToken),
+		client: NewClient(accessToken, baseURL),
 	}
 }
 
@@ -102,9 +103,10 @@ func (p *Provider) GetUsage() (*provider.Usage, error) {
 	}, nil
 }
 
-// IsExpired checks if the token has expired
+// IsExpired checks if the token has expired. A zero expiry is unknown, as for
+// tokens supplied through the environment, and never expires.
 func IsExpired(expiresAt int64) bool {
-	return time.Now().After(time.UnixMilli(expiresAt))
+	return expiresAt != 0 && time.Now().After(time.UnixMilli(expiresAt))
 }
 
 // ExpiresIn returns the duration until the token expires
@@ -0,0 +1,258 @@
+// Package exec implements providers backed by an external command.
+//
+// The command is run once per account and must print a JSON document on stdout:
+//
+//	{
+//	  "windows": [
+//	    {
+//	      "label": "Monthly",          // required
+//	      "utilization": 42.5,         // required, percentage (0-100)
+//	      "resets_at": "2026-01-01T00:00:00Z",
+//	      "limit": 1000, "used": 425, "remaining": 575
+//	    }
+//	  ],
+//	  "extra": {"plan": "enterprise"}, // optional, free-form
+//	  "error": ""                      // optional, reported as the provider error
+//	}
+//
+// Credentials are passed either as environment variables (LLM_USAGE_ACCOUNT,
+// LLM_USAGE_API_KEY and LLM_USAGE_FIELD_<NAME>) or as a JSON object
+// {"account": "...", "api_key": "...", "fields": {...}} on stdin.
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	osexec "os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/config"
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	maxStderrBytes = 4096
+)
+
+// Credentials holds the values passed to the command
+type Credentials struct {
+	Account string            `json:"account"`
+	APIKey  string            `json:"api_key"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// Document is the JSON document an exec provider command prints on stdout
+type Document struct {
+	Provider string                 `json:"provider,omitempty"`
+	Windows  []provider.UsageWindow `json:"windows"`
+	Extra    map[string]any         `json:"extra,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+// Error describes a failed command invocation
+type Error struct {
+	Command  string
+	ExitCode int    // -1 if the command did not exit normally
+	Stderr   string // Trimmed standard error output
+	Err      error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("command %s failed: %v", e.Command, e.Err)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrInvalidDocument is returned when the command output does not match the documented schema
+var ErrInvalidDocument = errors.New("invalid usage document")
+
+// Provider implements the provider.Provider interface for an external command
+type Provider struct {
+	cfg   *config.ProviderConfig
+	creds Credentials
+}
+
+// NewProvider creates a new exec provider for the given credentials
+func NewProvider(cfg *config.ProviderConfig, creds Credentials) *Provider {
+	return &Provider{
+		cfg:   cfg,
+		creds: creds,
+	}
+}
+
+// Name returns the provider's display name
+func (p *Provider) Name() string {
+	return p.cfg.DisplayName()
+}
+
+// ID returns the provider's unique identifier
+func (p *Provider) ID() string {
+	return p.cfg.ID
+}
+
+// GetUsage runs the configured command and parses its output
+func (p *Provider) GetUsage() (*provider.Usage, error) {
+	stdout, stderr, err := p.run()
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := parseDocument(stdout, p.cfg.ID)
+	if err != nil {
+		return nil, &Error{Command: p.cfg.Exec.Command, Stderr: stderr, Err: err}
+	}
+
+	if doc.Error != "" {
+		return nil, &Error{Command: p.cfg.Exec.Command, Stderr: stderr, Err: errors.New(doc.Error)}
+	}
+
+	windows := doc.Windows
+	if windows == nil {
+		windows = make([]provider.UsageWindow, 0)
+	}
+
+	return &provider.Usage{
+		Provider: p.cfg.ID,
+		Windows:  windows,
+		Extra:    doc.Extra,
+	}, nil
+}
+
+// run executes the command and returns its standard output and trimmed standard error
+func (p *Provider) run() ([]byte, string, error) {
+	timeout := p.cfg.Exec.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := osexec.CommandContext(ctx, p.cfg.Exec.Command, p.cfg.Exec.Args...) //nolint:gosec // command comes from the user's config file
+	// Don't wait for grandchildren holding the output pipes after a timeout
+	cmd.WaitDelay = time.Second
+	cmd.Env = inheritedEnv()
+	for k, v := range p.cfg.Exec.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	if p.cfg.Exec.Credentials == config.CredentialsStdin {
+		input, err := json.Marshal(p.creds)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to marshal credentials: %w", err)
+		}
+		cmd.Stdin = bytes.NewReader(input)
+	} else {
+		cmd.Env = append(cmd.Env, credentialEnv(p.creds)...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err == nil {
+		return stdout.Bytes(), trimStderr(stderr.String()), nil
+	}
+
+	execErr := &Error{
+		Command:  p.cfg.Exec.Command,
+		ExitCode: -1,
+		Stderr:   trimStderr(stderr.String()),
+		Err:      err,
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		execErr.Err = fmt.Errorf("timed out after %s", timeout)
+	}
+	var exitErr *osexec.ExitError
+	if errors.As(err, &exitErr) {
+		execErr.ExitCode = exitErr.ExitCode()
+	}
+	return nil, "", execErr
+}
+
+// inheritedEnv returns the environment passed on to the command, without the variables of
+// llm-usage's own credentials such as other providers' keys and the age passphrase
+func inheritedEnv() []string {
+	return slices.DeleteFunc(os.Environ(), func(v string) bool {
+		return strings.HasPrefix(v, credentials.EnvPrefix)
+	})
+}
+
+// credentialEnv returns the environment variables carrying the credentials
+func credentialEnv(creds Credentials) []string {
+	env := []string{
+		"LLM_USAGE_ACCOUNT=" + creds.Account,
+		"LLM_USAGE_API_KEY=" + creds.APIKey,
+	}
+	for name, value := range creds.Fields {
+		env = append(env, "LLM_USAGE_FIELD_"+envName(name)+"="+value)
+	}
+	return env
+}
+
+// envName converts a field name to an environment variable suffix
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// trimStderr limits the amount of stderr included in errors
+func trimStderr(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > maxStderrBytes {
+		s = s[:maxStderrBytes] + "..."
+	}
+	return s
+}
+
+// parseDocument decodes and validates the command output
+func parseDocument(data []byte, providerID string) (*Document, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var doc Document
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDocument, err)
+	}
+
+	if doc.Provider != "" && doc.Provider != providerID {
+		return nil, fmt.Errorf("%w: provider %q does not match %q", ErrInvalidDocument, doc.Provider, providerID)
+	}
+
+	for i, w := range doc.Windows {
+		if w.Label == "" {
+			return nil, fmt.Errorf("%w: windows[%d]: label is required", ErrInvalidDocument, i)
+		}
+		if math.IsNaN(w.Utilization) || math.IsInf(w.Utilization, 0) || w.Utilization < 0 {
+			return nil, fmt.Errorf("%w: windows[%d]: utilization must be a non-negative number", ErrInvalidDocument, i)
+		}
+	}
+
+	return &doc, nil
+}
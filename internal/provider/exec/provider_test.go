@@ -0,0 +1,140 @@
+package exec
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/config"
+)
+
+// shellProvider returns a provider that runs the given shell script
+func shellProvider(t *testing.T, script, credentials string) *Provider {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("exec provider tests require a POSIX shell")
+	}
+	return NewProvider(&config.ProviderConfig{
+		ID:   "gateway",
+		Type: config.TypeExec,
+		Exec: config.ExecConfig{
+			Command:     "sh",
+			Args:        []string{"-c", script},
+			Timeout:     5 * time.Second,
+			Credentials: credentials,
+		},
+	}, Credentials{Account: "work", APIKey: "secret", Fields: map[string]string{"org-id": "42"}})
+}
+
+func TestProvider_GetUsage(t *testing.T) {
+	p := shellProvider(t, `printf '{"windows":[{"label":"%s","utilization":12.5,"resets_at":"2026-01-01T00:00:00Z"}],"extra":{"org":"%s"}}' "$LLM_USAGE_API_KEY" "$LLM_USAGE_FIELD_ORG_ID"`, "")
+
+	usage, err := p.GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if usage.Provider != "gateway" {
+		t.Errorf("Provider = %q, want gateway", usage.Provider)
+	}
+	if len(usage.Windows) != 1 {
+		t.Fatalf("expected 1 window, got %d", len(usage.Windows))
+	}
+	w := usage.Windows[0]
+	if w.Label != "secret" || w.Utilization != 12.5 || w.ResetsAt == nil {
+		t.Errorf("window = %+v", w)
+	}
+	if usage.Extra["org"] != "42" {
+		t.Errorf("Extra[org] = %v, want 42", usage.Extra["org"])
+	}
+}
+
+func TestProvider_GetUsageStdinCredentials(t *testing.T) {
+	p := shellProvider(t, `read -r input; case "$input" in *'"api_key":"secret"'*) echo '{"windows":[]}';; *) echo "missing credentials" >&2; exit 3;; esac`, config.CredentialsStdin)
+
+	usage, err := p.GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if usage.Windows == nil {
+		t.Error("Windows should be an empty slice, not nil")
+	}
+}
+
+func TestProvider_GetUsageInheritedEnv(t *testing.T) {
+	t.Setenv("LLM_USAGE_KIMI_API_KEY", "sk-kimi")
+	t.Setenv("LLM_USAGE_AGE_PASSPHRASE", "correct horse")
+	p := shellProvider(t, `if env | grep -q '^LLM_USAGE_'; then env | grep '^LLM_USAGE_' >&2; exit 3; fi; echo '{"windows":[]}'`, config.CredentialsStdin)
+
+	if _, err := p.GetUsage(); err != nil {
+		t.Errorf("GetUsage() error = %v, want no LLM_USAGE_ variables", err)
+	}
+}
+
+func TestProvider_GetUsageErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		exitCode int
+		stderr   string
+		invalid  bool
+	}{
+		{"non-zero exit", `echo "token expired" >&2; exit 2`, 2, "token expired", false},
+		{"malformed JSON", `echo "not json"`, 0, "", true},
+		{"unknown field", `echo '{"windows":[],"bogus":1}'`, 0, "", true},
+		{"missing label", `echo '{"windows":[{"utilization":5}]}'`, 0, "", true},
+		{"negative utilization", `echo '{"windows":[{"label":"x","utilization":-1}]}'`, 0, "", true},
+		{"wrong provider", `echo '{"provider":"other","windows":[]}'`, 0, "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := shellProvider(t, tc.script, "").GetUsage()
+			var execErr *Error
+			if !errors.As(err, &execErr) {
+				t.Fatalf("GetUsage() error = %v, want *Error", err)
+			}
+			if execErr.ExitCode != tc.exitCode {
+				t.Errorf("ExitCode = %d, want %d", execErr.ExitCode, tc.exitCode)
+			}
+			if execErr.Stderr != tc.stderr {
+				t.Errorf("Stderr = %q, want %q", execErr.Stderr, tc.stderr)
+			}
+			if got := errors.Is(err, ErrInvalidDocument); got != tc.invalid {
+				t.Errorf("errors.Is(err, ErrInvalidDocument) = %v, want %v", got, tc.invalid)
+			}
+		})
+	}
+}
+
+func TestProvider_GetUsageReportedError(t *testing.T) {
+	_, err := shellProvider(t, `echo "upstream returned 503" >&2; echo '{"windows":[],"error":"quota service unavailable"}'`, "").GetUsage()
+	var execErr *Error
+	if !errors.As(err, &execErr) {
+		t.Fatalf("GetUsage() error = %v, want *Error", err)
+	}
+	if execErr.Err == nil || execErr.Err.Error() != "quota service unavailable" {
+		t.Errorf("Err = %v, want reported error", execErr.Err)
+	}
+	if execErr.Stderr != "upstream returned 503" {
+		t.Errorf("Stderr = %q, want %q", execErr.Stderr, "upstream returned 503")
+	}
+	if errors.Is(err, ErrInvalidDocument) {
+		t.Error("reported error is an invalid document")
+	}
+}
+
+func TestProvider_GetUsageTimeout(t *testing.T) {
+	p := shellProvider(t, `sleep 5`, "")
+	p.cfg.Exec.Timeout = 100 * time.Millisecond
+
+	_, err := p.GetUsage()
+	var execErr *Error
+	if !errors.As(err, &execErr) {
+		t.Fatalf("GetUsage() error = %v, want *Error", err)
+	}
+	if !strings.Contains(execErr.Error(), "timed out") {
+		t.Errorf("error = %v, want timeout", execErr)
+	}
+}
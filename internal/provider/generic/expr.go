@@ -0,0 +1,189 @@
+package generic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// step is a single component of a path expression
+type step struct {
+	key     string // Object key (when index is nil and !iterate)
+	index   *int   // Array index, negative values count from the end
+	iterate bool   // "[]": every element of an array
+}
+
+// expr is a parsed jq-style path expression such as ".data.items[0].used"
+type expr struct {
+	source string
+	steps  []step
+}
+
+// parseExpr parses a path expression. Supported syntax is a subset of jq:
+// "." (identity), ".key", ".\"quoted key\"", ".[\"quoted key\"]", "[N]" and "[]".
+func parseExpr(source string) (*expr, error) {
+	s := strings.TrimSpace(source)
+	if s == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+	if s[0] != '.' {
+		return nil, fmt.Errorf("expression %q must start with '.'", source)
+	}
+
+	e := &expr{source: source}
+	i := 0
+	for i < len(s) {
+		switch s[i] {
+		case '.':
+			i++
+			if i >= len(s) || s[i] == '[' {
+				continue
+			}
+			if s[i] == '"' {
+				key, n, err := readQuoted(s[i:])
+				if err != nil {
+					return nil, fmt.Errorf("expression %q: %w", source, err)
+				}
+				e.steps = append(e.steps, step{key: key})
+				i += n
+				continue
+			}
+			start := i
+			for i < len(s) && s[i] != '.' && s[i] != '[' {
+				i++
+			}
+			e.steps = append(e.steps, step{key: s[start:i]})
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("expression %q: unterminated '['", source)
+			}
+			inner := strings.TrimSpace(s[i+1 : i+end])
+			switch {
+			case inner == "":
+				e.steps = append(e.steps, step{iterate: true})
+			case inner[0] == '"':
+				key, _, err := readQuoted(inner)
+				if err != nil {
+					return nil, fmt.Errorf("expression %q: %w", source, err)
+				}
+				e.steps = append(e.steps, step{key: key})
+			default:
+				idx, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("expression %q: invalid index %q", source, inner)
+				}
+				e.steps = append(e.steps, step{index: &idx})
+			}
+			i += end + 1
+		default:
+			return nil, fmt.Errorf("expression %q: unexpected character %q at offset %d", source, s[i], i)
+		}
+	}
+
+	return e, nil
+}
+
+// readQuoted reads a double-quoted string at the start of s and returns the
+// unquoted value and the number of bytes consumed
+func readQuoted(s string) (string, int, error) {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == '"' {
+			v, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid quoted key %s", s[:i+1])
+			}
+			return v, i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quoted key")
+}
+
+// eval evaluates the expression against a decoded JSON document.
+// Missing keys and out-of-range indexes yield nil rather than an error.
+func (e *expr) eval(doc any) []any {
+	values := []any{doc}
+	for _, st := range e.steps {
+		next := make([]any, 0, len(values))
+		for _, v := range values {
+			switch {
+			case st.iterate:
+				if arr, ok := v.([]any); ok {
+					next = append(next, arr...)
+				}
+			case st.index != nil:
+				arr, ok := v.([]any)
+				if !ok {
+					next = append(next, nil)
+					continue
+				}
+				idx := *st.index
+				if idx < 0 {
+					idx += len(arr)
+				}
+				if idx < 0 || idx >= len(arr) {
+					next = append(next, nil)
+					continue
+				}
+				next = append(next, arr[idx])
+			default:
+				obj, ok := v.(map[string]any)
+				if !ok {
+					next = append(next, nil)
+					continue
+				}
+				next = append(next, obj[st.key])
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// first evaluates the expression and returns its first result, or nil
+func (e *expr) first(doc any) any {
+	values := e.eval(doc)
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+// toFloat converts a JSON value to float64, accepting numeric strings
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil {
+			return 0, false
+		}
+		return f, true
+	case bool:
+		if n {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// toString converts a JSON value to its display string
+func toString(v any) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	default:
+		return fmt.Sprint(s)
+	}
+}
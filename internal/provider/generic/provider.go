@@ -0,0 +1,456 @@
+// Package generic implements a declarative HTTP/JSON provider configured in YAML.
+package generic
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/config"
+	"github.com/denysvitali/llm-usage/internal/httpclient"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Credentials holds the values available to request templates
+type Credentials struct {
+	Account string
+	APIKey  string
+	Fields  map[string]string
+}
+
+// Provider implements the provider.Provider interface for a provider defined in configuration
+type Provider struct {
+	cfg     *config.ProviderConfig
+	creds   Credentials
+	http    *httpclient.Client
+	timeout time.Duration
+
+	request *request
+	probe   *request
+	windows []window
+	extra   map[string]*expr
+}
+
+// request is a compiled config.RequestConfig
+type request struct {
+	name    string
+	method  string
+	url     *template.Template
+	body    *template.Template
+	headers map[string]*template.Template
+}
+
+// window is a compiled config.WindowConfig
+type window struct {
+	cfg         config.WindowConfig
+	each        *expr
+	label       *expr
+	used        *expr
+	limit       *expr
+	remaining   *expr
+	utilization *expr
+	resetsAt    *expr
+}
+
+// templateFuncs are the helper functions available to request templates
+var templateFuncs = template.FuncMap{
+	"env": os.Getenv,
+	"base64": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+}
+
+// NewProvider compiles the provider configuration and returns a provider for the given credentials
+func NewProvider(cfg *config.ProviderConfig, creds Credentials) (*Provider, error) {
+	timeout := cfg.Request.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	p := &Provider{
+		cfg:     cfg,
+		creds:   creds,
+		http:    httpclient.Default(),
+		timeout: timeout,
+		extra:   make(map[string]*expr, len(cfg.Extra)),
+	}
+
+	var err error
+	if cfg.Request.URL != "" {
+		if p.request, err = compileRequest("request", cfg.Request); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Probe != nil {
+		if p.probe, err = compileRequest("probe", *cfg.Probe); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, wc := range cfg.Windows {
+		w, err := compileWindow(wc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: windows[%d]: %w", cfg.ID, i, err)
+		}
+		p.windows = append(p.windows, w)
+	}
+
+	for key, source := range cfg.Extra {
+		e, err := parseExpr(source)
+		if err != nil {
+			return nil, fmt.Errorf("%s: extra %q: %w", cfg.ID, key, err)
+		}
+		p.extra[key] = e
+	}
+
+	return p, nil
+}
+
+// compileRequest parses the templates of a request configuration
+func compileRequest(name string, rc config.RequestConfig) (*request, error) {
+	r := &request{
+		name:    name,
+		method:  strings.ToUpper(rc.Method),
+		headers: make(map[string]*template.Template, len(rc.Headers)),
+	}
+	if r.method == "" {
+		r.method = http.MethodGet
+	}
+
+	var err error
+	if r.url, err = parseTemplate(name+" url", rc.URL); err != nil {
+		return nil, err
+	}
+	if r.body, err = parseTemplate(name+" body", rc.Body); err != nil {
+		return nil, err
+	}
+	for header, value := range rc.Headers {
+		if r.headers[header], err = parseTemplate(name+" header "+header, value); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// parseTemplate parses a request template
+func parseTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+	return t, nil
+}
+
+// compileWindow parses all expressions of a window configuration
+func compileWindow(wc config.WindowConfig) (window, error) {
+	w := window{cfg: wc}
+	fields := []struct {
+		source string
+		target **expr
+	}{
+		{wc.Each, &w.each},
+		{wc.LabelExpr, &w.label},
+		{wc.Used, &w.used},
+		{wc.Limit, &w.limit},
+		{wc.Remaining, &w.remaining},
+		{wc.Utilization, &w.utilization},
+		{wc.ResetsAt, &w.resetsAt},
+	}
+	for _, f := range fields {
+		if f.source == "" {
+			continue
+		}
+		e, err := parseExpr(f.source)
+		if err != nil {
+			return window{}, err
+		}
+		*f.target = e
+	}
+	return w, nil
+}
+
+// Name returns the provider's display name
+func (p *Provider) Name() string {
+	return p.cfg.DisplayName()
+}
+
+// ID returns the provider's unique identifier
+func (p *Provider) ID() string {
+	return p.cfg.ID
+}
+
+// GetUsage fetches current usage statistics from the configured endpoint
+// and appends the rate limits reported by the probe, if one is configured
+func (p *Provider) GetUsage() (*provider.Usage, error) {
+	usage := &provider.Usage{
+		Provider: p.cfg.ID,
+		Windows:  make([]provider.UsageWindow, 0, len(p.windows)),
+	}
+
+	if p.request != nil {
+		doc, err := p.fetch()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, w := range p.windows {
+			parsed, err := w.parse(doc)
+			if err != nil {
+				return nil, err
+			}
+			usage.Windows = append(usage.Windows, parsed...)
+		}
+
+		if len(p.extra) > 0 {
+			usage.Extra = make(map[string]any, len(p.extra))
+			for key, e := range p.extra {
+				if v := e.first(doc); v != nil {
+					usage.Extra[key] = v
+				}
+			}
+		}
+	}
+
+	if p.probe != nil {
+		windows, err := p.runProbe()
+		switch {
+		case err == nil:
+			usage.Windows = append(usage.Windows, windows...)
+		case p.request == nil:
+			// The probe is the only source of data
+			return nil, err
+		default:
+			if usage.Extra == nil {
+				usage.Extra = make(map[string]any)
+			}
+			usage.Extra["rate_limit_error"] = err.Error()
+		}
+	}
+
+	return usage, nil
+}
+
+// runProbe renders the probe request and parses the rate-limit headers of its response
+func (p *Provider) runProbe() ([]provider.UsageWindow, error) {
+	url, body, header, err := p.renderRequest(p.probe)
+	if err != nil {
+		return nil, err
+	}
+
+	probe := &provider.RateLimitProbe{
+		Method: p.probe.method,
+		URL:    url,
+		Header: header,
+		Body:   body,
+		Client: p.http.WithoutRetries(),
+	}
+	return probe.Run()
+}
+
+// renderRequest renders the URL, body and headers of a compiled request
+func (p *Provider) renderRequest(r *request) (string, string, http.Header, error) {
+	url, err := p.render(r.url)
+	if err != nil {
+		return "", "", nil, err
+	}
+	body, err := p.render(r.body)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	if body != "" {
+		header.Set("Content-Type", "application/json")
+	}
+	for name, t := range r.headers {
+		value, err := p.render(t)
+		if err != nil {
+			return "", "", nil, err
+		}
+		header.Set(name, value)
+	}
+
+	return url, body, header, nil
+}
+
+// fetch renders and executes the configured request and decodes the JSON response
+func (p *Provider) fetch() (any, error) {
+	url, body, header, err := p.renderRequest(p.request)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, p.request.method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header = header
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := p.http.ReadBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(respBody))
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return doc, nil
+}
+
+// render executes a request template with the provider's credentials
+func (p *Provider) render(t *template.Template) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, p.creds); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", t.Name(), err)
+	}
+	return b.String(), nil
+}
+
+// parse evaluates the window expressions against the response document
+func (w *window) parse(doc any) ([]provider.UsageWindow, error) {
+	items := []any{doc}
+	if w.each != nil {
+		items = w.each.eval(doc)
+	}
+
+	windows := make([]provider.UsageWindow, 0, len(items))
+	for _, item := range items {
+		uw, err := w.parseItem(item)
+		if err != nil {
+			return nil, err
+		}
+		if uw != nil {
+			windows = append(windows, *uw)
+		}
+	}
+	return windows, nil
+}
+
+// parseItem builds a single UsageWindow from a response element.
+// It returns nil when the element does not contain enough data.
+func (w *window) parseItem(item any) (*provider.UsageWindow, error) {
+	label := w.cfg.Label
+	if w.label != nil {
+		if l := toString(w.label.first(item)); l != "" {
+			label = l
+		}
+	}
+
+	uw := &provider.UsageWindow{Label: label}
+	uw.Used = w.number(w.used, item)
+	uw.Limit = w.number(w.limit, item)
+	uw.Remaining = w.number(w.remaining, item)
+
+	if uw.Used == nil && uw.Limit != nil && uw.Remaining != nil {
+		used := *uw.Limit - *uw.Remaining
+		uw.Used = &used
+	}
+	if uw.Remaining == nil && uw.Limit != nil && uw.Used != nil {
+		remaining := *uw.Limit - *uw.Used
+		uw.Remaining = &remaining
+	}
+
+	switch {
+	case w.utilization != nil:
+		util := w.number(w.utilization, item)
+		if util == nil {
+			return nil, nil
+		}
+		uw.Utilization = *util
+		if w.cfg.Scale != 0 {
+			uw.Utilization *= w.cfg.Scale
+		}
+	case uw.Limit != nil && uw.Used != nil:
+		if *uw.Limit > 0 {
+			uw.Utilization = *uw.Used / *uw.Limit * 100
+		}
+	default:
+		return nil, nil
+	}
+
+	if w.resetsAt != nil {
+		if v := w.resetsAt.first(item); v != nil {
+			t, err := parseResetTime(v, w.cfg.ResetFormat)
+			if err != nil {
+				return nil, fmt.Errorf("window %q: %w", label, err)
+			}
+			uw.ResetsAt = t
+		}
+	}
+
+	return uw, nil
+}
+
+// number evaluates a numeric expression, returning nil if it is unset or not a number
+func (w *window) number(e *expr, item any) *float64 {
+	if e == nil {
+		return nil
+	}
+	f, ok := toFloat(e.first(item))
+	if !ok {
+		return nil
+	}
+	return &f
+}
+
+// parseResetTime converts a JSON value to a time using the configured format
+func parseResetTime(v any, format string) (*time.Time, error) {
+	switch format {
+	case "unix", "unix_ms":
+		n, ok := toFloat(v)
+		if !ok {
+			return nil, fmt.Errorf("reset time %v is not a number", v)
+		}
+		var t time.Time
+		if format == "unix" {
+			t = time.Unix(int64(n), 0)
+		} else {
+			t = time.UnixMilli(int64(n))
+		}
+		return &t, nil
+	case "", "rfc3339":
+		format = time.RFC3339Nano
+	}
+
+	s := toString(v)
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(format, s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse reset time %q: %w", s, err)
+	}
+	return &t, nil
+}
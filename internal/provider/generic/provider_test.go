@@ -0,0 +1,213 @@
+package generic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/config"
+)
+
+func TestParseExpr(t *testing.T) {
+	doc := map[string]any{
+		"data": map[string]any{
+			"items": []any{
+				map[string]any{"used": 1.0},
+				map[string]any{"used": 2.0},
+			},
+			"odd key": "value",
+		},
+	}
+
+	tests := []struct {
+		expr     string
+		expected []any
+	}{
+		{".data.items[0].used", []any{1.0}},
+		{".data.items[-1].used", []any{2.0}},
+		{".data.items[].used", []any{1.0, 2.0}},
+		{".data[\"odd key\"]", []any{"value"}},
+		{".data.\"odd key\"", []any{"value"}},
+		{".data.missing", []any{nil}},
+		{".data.items[5]", []any{nil}},
+	}
+
+	for _, tc := range tests {
+		e, err := parseExpr(tc.expr)
+		if err != nil {
+			t.Fatalf("parseExpr(%q) error = %v", tc.expr, err)
+		}
+		got := e.eval(doc)
+		if len(got) != len(tc.expected) {
+			t.Fatalf("eval(%q) = %v, want %v", tc.expr, got, tc.expected)
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Errorf("eval(%q)[%d] = %v, want %v", tc.expr, i, got[i], tc.expected[i])
+			}
+		}
+	}
+
+	for _, bad := range []string{"", "data", ".a[", ".a[x]", ".a.\"b"} {
+		if _, err := parseExpr(bad); err == nil {
+			t.Errorf("parseExpr(%q) expected error", bad)
+		}
+	}
+}
+
+func TestProvider_GetUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+		}
+		if r.Method != http.MethodPost {
+			t.Errorf("Method = %q, want POST", r.Method)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"plan": "pro",
+			"monthly": {"used": "250", "limit": 1000, "reset": 1767225600},
+			"quotas": [
+				{"name": "Chat", "ratio": 0.5, "resets": "2026-01-01T00:00:00Z"},
+				{"name": "Search", "remaining": 20, "limit": 80}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.ProviderConfig{
+		ID:   "acme",
+		Name: "Acme",
+		Type: config.TypeGeneric,
+		Request: config.RequestConfig{
+			URL:     server.URL + "/usage?account={{ .Account }}",
+			Method:  "post",
+			Headers: map[string]string{"Authorization": "Bearer {{ .APIKey }}"},
+			Body:    `{}`,
+		},
+		Windows: []config.WindowConfig{
+			{
+				Label:       "Monthly",
+				Used:        ".monthly.used",
+				Limit:       ".monthly.limit",
+				ResetsAt:    ".monthly.reset",
+				ResetFormat: "unix",
+			},
+			{
+				Each:        ".quotas[]",
+				LabelExpr:   ".name",
+				Utilization: ".ratio",
+				Scale:       100,
+				ResetsAt:    ".resets",
+			},
+			{
+				Each:      ".quotas[]",
+				LabelExpr: ".name",
+				Limit:     ".limit",
+				Remaining: ".remaining",
+			},
+		},
+		Extra: map[string]string{"plan": ".plan"},
+	}
+
+	p, err := NewProvider(cfg, Credentials{Account: "work", APIKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	usage, err := p.GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+
+	if usage.Provider != "acme" {
+		t.Errorf("Provider = %q, want acme", usage.Provider)
+	}
+	if usage.Extra["plan"] != "pro" {
+		t.Errorf("Extra[plan] = %v, want pro", usage.Extra["plan"])
+	}
+	if len(usage.Windows) != 3 {
+		t.Fatalf("expected 3 windows, got %d: %+v", len(usage.Windows), usage.Windows)
+	}
+
+	monthly := usage.Windows[0]
+	if monthly.Label != "Monthly" || monthly.Utilization != 25 {
+		t.Errorf("monthly window = %+v, want label Monthly and 25%% utilization", monthly)
+	}
+	if monthly.Remaining == nil || *monthly.Remaining != 750 {
+		t.Errorf("monthly remaining = %v, want 750", monthly.Remaining)
+	}
+	if monthly.ResetsAt == nil || !monthly.ResetsAt.Equal(time.Unix(1767225600, 0)) {
+		t.Errorf("monthly resets_at = %v", monthly.ResetsAt)
+	}
+
+	chat := usage.Windows[1]
+	if chat.Label != "Chat" || chat.Utilization != 50 || chat.ResetsAt == nil {
+		t.Errorf("chat window = %+v", chat)
+	}
+
+	search := usage.Windows[2]
+	if search.Label != "Search" || search.Utilization != 75 {
+		t.Errorf("search window = %+v, want label Search and 75%% utilization", search)
+	}
+}
+
+func TestProvider_GetUsageHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	cfg := &config.ProviderConfig{
+		ID:      "acme",
+		Type:    config.TypeGeneric,
+		Request: config.RequestConfig{URL: server.URL},
+		Windows: []config.WindowConfig{{Label: "Usage", Utilization: ".pct"}},
+	}
+
+	p, err := NewProvider(cfg, Credentials{})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	if _, err := p.GetUsage(); err == nil {
+		t.Error("GetUsage() expected error for 401 response")
+	}
+}
+
+func TestProvider_GetUsageProbeOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer gsk-test" {
+			t.Errorf("Authorization = %q", got)
+		}
+		w.Header().Set("x-ratelimit-limit-tokens", "6000")
+		w.Header().Set("x-ratelimit-remaining-tokens", "4500")
+		w.Header().Set("x-ratelimit-reset-tokens", "7.66s")
+	}))
+	defer server.Close()
+
+	cfg := &config.ProviderConfig{
+		ID:   "groq",
+		Type: config.TypeGeneric,
+		Probe: &config.RequestConfig{
+			URL:     server.URL + "/openai/v1/models",
+			Headers: map[string]string{"Authorization": "Bearer {{.APIKey}}"},
+		},
+	}
+	if err := (&config.Config{Providers: []config.ProviderConfig{*cfg}}).Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	p, err := NewProvider(cfg, Credentials{APIKey: "gsk-test"})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	usage, err := p.GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if len(usage.Windows) != 1 || usage.Windows[0].Utilization != 25 || usage.Windows[0].ResetsAt == nil {
+		t.Errorf("windows = %+v", usage.Windows)
+	}
+}
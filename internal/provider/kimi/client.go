@@ -5,31 +5,36 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"time"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/httpclient"
 )
 
 const (
-	baseURL              = "https://www.kimi.com"
+	// DefaultBaseURL is the Kimi API base URL
+	DefaultBaseURL       = "https://www.kimi.com"
 	usageEndpoint        = "/apiv2/kimi.gateway.billing.v1.BillingService/GetUsages"
 	subscriptionEndpoint = "/apiv2/kimi.gateway.order.v1.SubscriptionService/GetSubscription"
-	userAgent            = "llm-usage/1.0.0"
 )
 
 // Client is an HTTP client for the Kimi API
 type Client struct {
-	httpClient *http.Client
-	apiKey     string
+	http    *httpclient.Client
+	apiKey  string
+	baseURL string
 }
 
-// NewClient creates a new API client with the given API key
-func NewClient(apiKey string) *Client {
+// NewClient creates a new API client with the given API key.
+// An empty baseURL selects the public Kimi API.
+func NewClient(apiKey, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		apiKey: apiKey,
+		http:    httpclient.Default(),
+		apiKey:  apiKey,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
 	}
 }
 
@@ -49,34 +54,17 @@ func (c *Client) GetUsage() (*UsageResponse, error) {
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, baseURL+usageEndpoint, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, c.baseURL+usageEndpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", userAgent)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
 
 	var usage UsageResponse
-	if err := json.Unmarshal(body, &usage); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.http.Send(req, &usage); err != nil {
+		return nil, err
 	}
 
 	return &usage, nil
@@ -84,34 +72,17 @@ func (c *Client) GetUsage() (*UsageResponse, error) {
 
 // GetSubscription fetches the subscription details from the subscription endpoint
 func (c *Client) GetSubscription() (*SubscriptionResponse, error) {
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, baseURL+subscriptionEndpoint, bytes.NewBuffer([]byte("{}")))
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, c.baseURL+subscriptionEndpoint, bytes.NewBuffer([]byte("{}")))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", userAgent)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
 
 	var subscription SubscriptionResponse
-	if err := json.Unmarshal(body, &subscription); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.http.Send(req, &subscription); err != nil {
+		return nil, err
 	}
 
 	return &subscription, nil
@@ -21,10 +21,11 @@ type Provider struct {
 	cache  *cache.Manager
 }
 
-// NewProvider creates a new Kimi provider with the given API key
-func NewProvider(apiKey string) *Provider {
+// NewProvider creates a new Kimi provider with the given API key.
+// An empty baseURL selects the public Kimi API.
+func NewProvider(apiKey, baseURL string) *Provider {
 	return &Provider{
-		client: NewClient(apiKey),
+		client: NewClient(apiKey, baseURL),
 		cache:  cache.NewManager(),
 	}
 }
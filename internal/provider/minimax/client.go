@@ -2,43 +2,47 @@ package minimax
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
-	"time"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/httpclient"
 )
 
 const (
-	baseURL              = "https://platform.minimax.io"
+	// DefaultBaseURL is the international MiniMax platform base URL
+	DefaultBaseURL       = "https://platform.minimax.io"
 	codingPlanEndpoint   = "/v1/api/openplatform/coding_plan/remains"
 	subscriptionEndpoint = "/v1/api/openplatform/charge/combo/cycle_audio_resource_package"
-	userAgent            = "llm-usage/1.0.0"
 )
 
 // Client is an HTTP client for the MiniMax API
 type Client struct {
-	httpClient *http.Client
-	cookie     string
-	groupID    string
+	http    *httpclient.Client
+	cookie  string
+	groupID string
+	baseURL string
 }
 
-// NewClient creates a new API client with cookie-based authentication
-func NewClient(cookie, groupID string) *Client {
+// NewClient creates a new API client with cookie-based authentication.
+// An empty baseURL selects the international MiniMax platform.
+func NewClient(cookie, groupID, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		http:    httpclient.Default(),
 		cookie:  cookie,
 		groupID: groupID,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
 	}
 }
 
 // GetUsage fetches the current usage from the coding_plan/remains endpoint
 func (c *Client) GetUsage() (*CodingPlanResponse, error) {
 	// Build URL with GroupId query parameter
-	reqURL, err := url.Parse(baseURL + codingPlanEndpoint)
+	reqURL, err := url.Parse(c.baseURL + codingPlanEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse URL: %w", err)
 	}
@@ -53,27 +57,10 @@ func (c *Client) GetUsage() (*CodingPlanResponse, error) {
 	}
 
 	req.Header.Set("Cookie", c.cookie)
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", userAgent)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
 
 	var usage CodingPlanResponse
-	if err := json.Unmarshal(body, &usage); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.http.Send(req, &usage); err != nil {
+		return nil, err
 	}
 
 	return &usage, nil
@@ -82,7 +69,7 @@ func (c *Client) GetUsage() (*CodingPlanResponse, error) {
 // GetSubscription fetches the subscription details from the subscription endpoint
 func (c *Client) GetSubscription() (*SubscriptionResponse, error) {
 	// Build URL with query parameters
-	reqURL, err := url.Parse(baseURL + subscriptionEndpoint)
+	reqURL, err := url.Parse(c.baseURL + subscriptionEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse URL: %w", err)
 	}
@@ -100,27 +87,10 @@ func (c *Client) GetSubscription() (*SubscriptionResponse, error) {
 	}
 
 	req.Header.Set("Cookie", c.cookie)
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", userAgent)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
 
 	var subscription SubscriptionResponse
-	if err := json.Unmarshal(body, &subscription); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.http.Send(req, &subscription); err != nil {
+		return nil, err
 	}
 
 	return &subscription, nil
@@ -18,10 +18,11 @@ type Provider struct {
 	cache  *cache.Manager
 }
 
-// NewProvider creates a new MiniMax provider with the given cookie and group ID
-func NewProvider(cookie, groupID string) *Provider {
+// NewProvider creates a new MiniMax provider with the given cookie and group ID.
+// An empty baseURL selects the international MiniMax platform.
+func NewProvider(cookie, groupID, baseURL string) *Provider {
 	return &Provider{
-		client: NewClient(cookie, groupID),
+		client: NewClient(cookie, groupID, baseURL),
 		cache:  cache.NewManager(),
 	}
 }
@@ -0,0 +1,82 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/httpclient"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+const (
+	// DefaultBaseURL is the OpenAI API base URL
+	DefaultBaseURL      = "https://api.openai.com"
+	completionsEndpoint = "/v1/organization/usage/completions"
+	costsEndpoint       = "/v1/organization/costs"
+)
+
+// Client is an HTTP client for the OpenAI organization usage and costs API
+type Client struct {
+	http     *httpclient.Client
+	adminKey string
+	baseURL  string
+}
+
+// NewClient creates a new API client with the given admin API key.
+// An empty baseURL selects the public OpenAI API.
+func NewClient(adminKey, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		http:     httpclient.Default(),
+		adminKey: adminKey,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// GetCompletionsUsage fetches daily completions usage grouped by model since the given time
+func (c *Client) GetCompletionsUsage(since time.Time) ([]Bucket[CompletionsResult], error) {
+	query := url.Values{}
+	query.Set("start_time", strconv.FormatInt(since.Unix(), 10))
+	query.Set("bucket_width", "1d")
+	query.Set("group_by", "model")
+	query.Set("limit", "31")
+
+	return provider.GetAllPages(query, func(query url.Values, page *provider.Page[Bucket[CompletionsResult]]) error {
+		return c.get(completionsEndpoint, query, page)
+	})
+}
+
+// GetCosts fetches daily costs since the given time
+func (c *Client) GetCosts(since time.Time) ([]Bucket[CostResult], error) {
+	query := url.Values{}
+	query.Set("start_time", strconv.FormatInt(since.Unix(), 10))
+	query.Set("bucket_width", "1d")
+	query.Set("limit", "31")
+
+	return provider.GetAllPages(query, func(query url.Values, page *provider.Page[Bucket[CostResult]]) error {
+		return c.get(costsEndpoint, query, page)
+	})
+}
+
+// get performs an authenticated GET request and decodes the JSON response into target
+func (c *Client) get(endpoint string, query url.Values, target any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	reqURL := c.baseURL + endpoint + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.adminKey)
+
+	return c.http.Send(req, target)
+}
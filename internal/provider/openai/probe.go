@@ -0,0 +1,34 @@
+package openai
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+const (
+	chatCompletionsEndpoint = "/v1/chat/completions"
+	probeModel              = "gpt-4o-mini"
+)
+
+// NewRateLimitProbe returns a probe that reads the live rate limits of a regular
+// (non-admin) project API key. OpenAI only reports limits on inference responses,
+// so the probe issues a single-token completion on a small model.
+// An empty baseURL selects the public OpenAI API.
+func NewRateLimitProbe(apiKey, baseURL string) *provider.RateLimitProbe {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+apiKey)
+	header.Set("Content-Type", "application/json")
+
+	return &provider.RateLimitProbe{
+		Method: http.MethodPost,
+		URL:    strings.TrimSuffix(baseURL, "/") + chatCompletionsEndpoint,
+		Header: header,
+		Body:   `{"model":"` + probeModel + `","max_completion_tokens":1,"messages":[{"role":"user","content":"."}]}`,
+	}
+}
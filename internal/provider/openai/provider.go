@@ -0,0 +1,110 @@
+// Package openai implements the OpenAI organization usage and costs provider for llm-usage.
+package openai
+
+import (
+	"slices"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// modelUsage accumulates month-to-date usage for a single model
+type modelUsage struct {
+	InputTokens  int64
+	OutputTokens int64
+	Requests     int64
+}
+
+// Provider implements the provider.Provider interface for OpenAI
+type Provider struct {
+	client *Client
+	budget provider.Budget
+	now    func() time.Time
+}
+
+// NewProvider creates a new OpenAI provider with the given admin API key.
+// An empty baseURL selects the public OpenAI API.
+func NewProvider(adminKey, baseURL string, budget provider.Budget) *Provider {
+	return &Provider{
+		client: NewClient(adminKey, baseURL),
+		budget: budget,
+		now:    time.Now,
+	}
+}
+
+// Name returns the provider's display name
+func (p *Provider) Name() string {
+	return "OpenAI"
+}
+
+// ID returns the provider's unique identifier
+func (p *Provider) ID() string {
+	return "openai"
+}
+
+// GetUsage fetches the current billing month's token usage and costs
+func (p *Provider) GetUsage() (*provider.Usage, error) {
+	billing := provider.NewBilling(p.now())
+
+	costs, err := p.client.GetCosts(billing.MonthStart)
+	if err != nil {
+		return nil, err
+	}
+
+	completions, err := p.client.GetCompletionsUsage(billing.MonthStart)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range costs {
+		for _, r := range b.Results {
+			billing.AddCost(time.Unix(b.StartTime, 0), r.Amount.Value)
+		}
+	}
+
+	models := make(map[string]*modelUsage)
+	for _, b := range completions {
+		for _, r := range b.Results {
+			billing.AddTokens(time.Unix(b.StartTime, 0), float64(r.InputTokens+r.OutputTokens))
+
+			model := r.Model
+			if model == "" {
+				model = "unknown"
+			}
+			m, ok := models[model]
+			if !ok {
+				m = &modelUsage{}
+				models[model] = m
+			}
+			m.InputTokens += r.InputTokens
+			m.OutputTokens += r.OutputTokens
+			m.Requests += r.NumModelRequests
+		}
+	}
+
+	extra := billing.Extra()
+	if len(models) > 0 {
+		names := make([]string, 0, len(models))
+		for name := range models {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		breakdown := make([]map[string]any, 0, len(names))
+		for _, name := range names {
+			m := models[name]
+			breakdown = append(breakdown, map[string]any{
+				"model":         name,
+				"input_tokens":  m.InputTokens,
+				"output_tokens": m.OutputTokens,
+				"requests":      m.Requests,
+			})
+		}
+		extra["models"] = breakdown
+	}
+
+	return &provider.Usage{
+		Provider: "openai",
+		Windows:  billing.Windows(p.budget),
+		Extra:    extra,
+	}, nil
+}
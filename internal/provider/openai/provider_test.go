@@ -0,0 +1,99 @@
+package openai
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func TestProvider_GetUsage(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	monthStart := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC).Unix()
+	yesterday := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC).Unix()
+	today := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC).Unix()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+costsEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-admin" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if got := r.URL.Query().Get("start_time"); got != fmt.Sprint(monthStart) {
+			t.Errorf("start_time = %q, want %d", got, monthStart)
+		}
+		// Paginate to exercise next_page handling
+		if r.URL.Query().Get("page") == "" {
+			fmt.Fprintf(w, `{"object":"page","data":[{"start_time":%d,"results":[{"amount":{"value":30,"currency":"usd"}}]}],"has_more":true,"next_page":"p2"}`, yesterday)
+			return
+		}
+		fmt.Fprintf(w, `{"object":"page","data":[{"start_time":%d,"results":[{"amount":{"value":2.5,"currency":"usd"}}]}],"has_more":false}`, today)
+	})
+	mux.HandleFunc("GET "+completionsEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("group_by"); got != "model" {
+			t.Errorf("group_by = %q, want model", got)
+		}
+		fmt.Fprintf(w, `{"object":"page","data":[
+			{"start_time":%d,"results":[{"model":"gpt-4o","input_tokens":1000,"output_tokens":500,"num_model_requests":3}]},
+			{"start_time":%d,"results":[{"model":"gpt-4o","input_tokens":100,"output_tokens":50,"num_model_requests":1},{"model":"o3","input_tokens":10,"output_tokens":5,"num_model_requests":1}]}
+		],"has_more":false}`, yesterday, today)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	monthly := 100.0
+	p := NewProvider("sk-admin", server.URL, provider.Budget{Monthly: &monthly})
+	p.now = func() time.Time { return now }
+
+	usage, err := p.GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+
+	if len(usage.Windows) != 4 {
+		t.Fatalf("expected 4 windows, got %d", len(usage.Windows))
+	}
+
+	month := usage.Windows[0]
+	if *month.Used != 32.5 || *month.Limit != 100 || month.Utilization != 32.5 {
+		t.Errorf("month spend window = used %v limit %v util %v", *month.Used, *month.Limit, month.Utilization)
+	}
+	if !month.ResetsAt.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("month resets at %v, want 2026-04-01", month.ResetsAt)
+	}
+
+	day := usage.Windows[1]
+	if *day.Used != 2.5 || day.Limit != nil || !day.Unbounded {
+		t.Errorf("day spend window = %+v", day)
+	}
+
+	if got := *usage.Windows[2].Used; got != 1665 {
+		t.Errorf("month tokens = %v, want 1665", got)
+	}
+	if got := *usage.Windows[3].Used; got != 165 {
+		t.Errorf("day tokens = %v, want 165", got)
+	}
+
+	models, ok := usage.Extra["models"].([]map[string]any)
+	if !ok || len(models) != 2 {
+		t.Fatalf("Extra[models] = %v", usage.Extra["models"])
+	}
+	if models[0]["model"] != "gpt-4o" || models[0]["input_tokens"] != int64(1100) || models[0]["requests"] != int64(4) {
+		t.Errorf("gpt-4o breakdown = %v", models[0])
+	}
+}
+
+func TestProvider_GetUsageUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":{"message":"invalid key"}}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	p := NewProvider("bad", server.URL, provider.Budget{})
+	if _, err := p.GetUsage(); err == nil {
+		t.Error("GetUsage() expected error for 401 response")
+	}
+}
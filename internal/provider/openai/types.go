@@ -0,0 +1,33 @@
+package openai
+
+// Bucket aggregates results over a time bucket
+type Bucket[T any] struct {
+	Object    string `json:"object"`
+	StartTime int64  `json:"start_time"`
+	EndTime   int64  `json:"end_time"`
+	Results   []T    `json:"results"`
+}
+
+// CompletionsResult is a completions usage entry, grouped by model
+type CompletionsResult struct {
+	Object            string `json:"object"`
+	InputTokens       int64  `json:"input_tokens"`
+	OutputTokens      int64  `json:"output_tokens"`
+	InputCachedTokens int64  `json:"input_cached_tokens"`
+	NumModelRequests  int64  `json:"num_model_requests"`
+	Model             string `json:"model"`
+}
+
+// CostResult is a cost entry
+type CostResult struct {
+	Object    string `json:"object"`
+	Amount    Amount `json:"amount"`
+	LineItem  string `json:"line_item"`
+	ProjectID string `json:"project_id"`
+}
+
+// Amount is a monetary value
+type Amount struct {
+	Value    float64 `json:"value"`
+	Currency string  `json:"currency"`
+}
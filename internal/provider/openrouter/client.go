@@ -0,0 +1,81 @@
+package openrouter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/httpclient"
+)
+
+const (
+	// DefaultBaseURL is the OpenRouter API base URL
+	DefaultBaseURL  = "https://openrouter.ai"
+	keyEndpoint     = "/api/v1/key"
+	creditsEndpoint = "/api/v1/credits"
+	keysEndpoint    = "/api/v1/keys"
+)
+
+// Client is an HTTP client for the OpenRouter API
+type Client struct {
+	http    *httpclient.Client
+	apiKey  string
+	baseURL string
+}
+
+// NewClient creates a new API client with the given API key.
+// An empty baseURL selects the public OpenRouter API.
+func NewClient(apiKey, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		http:    httpclient.Default(),
+		apiKey:  apiKey,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// GetKeyInfo fetches usage, limits and rate limit of the current API key
+func (c *Client) GetKeyInfo() (*KeyInfo, error) {
+	var resp KeyInfoResponse
+	if err := c.get(keyEndpoint, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// GetCredits fetches the account's total purchased and used credits
+func (c *Client) GetCredits() (*Credits, error) {
+	var resp CreditsResponse
+	if err := c.get(creditsEndpoint, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// ListKeys fetches the API keys managed by a provisioning key
+func (c *Client) ListKeys() ([]Key, error) {
+	var resp KeysResponse
+	if err := c.get(keysEndpoint, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// get performs an authenticated GET request and decodes the JSON response into target
+func (c *Client) get(endpoint string, target any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	return c.http.Send(req, target)
+}
@@ -0,0 +1,188 @@
+// Package openrouter implements the OpenRouter credits and key limits provider for llm-usage.
+package openrouter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/httpclient"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// Provider implements the provider.Provider interface for OpenRouter
+type Provider struct {
+	client *Client
+	now    func() time.Time
+}
+
+// NewProvider creates a new OpenRouter provider with the given API key.
+// An empty baseURL selects the public OpenRouter API.
+func NewProvider(apiKey, baseURL string) *Provider {
+	return &Provider{
+		client: NewClient(apiKey, baseURL),
+		now:    time.Now,
+	}
+}
+
+// Name returns the provider's display name
+func (p *Provider) Name() string {
+	return "OpenRouter"
+}
+
+// ID returns the provider's unique identifier
+func (p *Provider) ID() string {
+	return "openrouter"
+}
+
+// GetUsage fetches the credit balance, key limits and, for provisioning keys,
+// the limits of every managed key
+func (p *Provider) GetUsage() (*provider.Usage, error) {
+	info, err := p.client.GetKeyInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	credits, err := p.client.GetCredits()
+	if err != nil && !forbidden(err) {
+		return nil, err
+	}
+
+	now := p.now().UTC()
+	windows := make([]provider.UsageWindow, 0)
+
+	// Credits are only visible to keys allowed to read account information
+	if credits != nil {
+		windows = append(windows, limitWindow("Credits", credits.TotalUsage, &credits.TotalCredits, nil, nil))
+	}
+
+	label := "Key Usage"
+	if info.Limit != nil {
+		label = "Key Limit"
+	}
+	windows = append(windows, limitWindow(label, info.Usage, info.Limit, info.LimitRemaining, resetTime(info.LimitReset, now)))
+
+	keyInfo := map[string]any{
+		"label":               info.Label,
+		"is_free_tier":        info.IsFreeTier,
+		"is_provisioning_key": info.IsProvisioningKey,
+		"usage_daily":         info.UsageDaily,
+		"usage_weekly":        info.UsageWeekly,
+		"usage_monthly":       info.UsageMonthly,
+	}
+	if info.LimitReset != nil {
+		keyInfo["limit_reset"] = *info.LimitReset
+	}
+	if info.RateLimit != nil {
+		keyInfo["rate_limit"] = fmt.Sprintf("%d requests / %s", info.RateLimit.Requests, info.RateLimit.Interval)
+	}
+
+	extra := map[string]any{
+		"key_info": keyInfo,
+	}
+	if credits != nil {
+		extra["credits_remaining_usd"] = credits.TotalCredits - credits.TotalUsage
+	}
+
+	if info.IsProvisioningKey {
+		keys, err := p.client.ListKeys()
+		if err != nil {
+			return nil, err
+		}
+
+		keyList := make([]map[string]any, 0, len(keys))
+		for _, k := range keys {
+			keyList = append(keyList, map[string]any{
+				"name":     keyName(k),
+				"hash":     k.Hash,
+				"disabled": k.Disabled,
+				"usage":    k.Usage,
+				"limit":    k.Limit,
+			})
+			// Only keys with a limit are worth a window; unlimited keys stay in Extra
+			if k.Limit == nil || k.Disabled {
+				continue
+			}
+			windows = append(windows, limitWindow("Key: "+keyName(k), k.Usage, k.Limit, k.LimitRemaining, resetTime(k.LimitReset, now)))
+		}
+		extra["keys"] = keyList
+	}
+
+	return &provider.Usage{
+		Provider: "openrouter",
+		Windows:  windows,
+		Extra:    extra,
+	}, nil
+}
+
+// forbidden reports whether err is an authorization failure, which happens
+// when the key is not allowed to access an endpoint
+func forbidden(err error) bool {
+	var statusErr *httpclient.StatusError
+	return errors.As(err, &statusErr) &&
+		(statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden)
+}
+
+// keyName returns a human-readable name for a managed key
+func keyName(k Key) string {
+	if k.Name != "" {
+		return k.Name
+	}
+	if k.Label != "" {
+		return k.Label
+	}
+	return k.Hash
+}
+
+// limitWindow builds a dollar window, measured against limit when there is one.
+// The API's remaining amount is preferred since usage may cover a longer period
+// than the current limit window.
+func limitWindow(label string, used float64, limit, remaining *float64, resetsAt *time.Time) provider.UsageWindow {
+	w := provider.UsageWindow{
+		Label:    label,
+		ResetsAt: resetsAt,
+		Used:     &used,
+	}
+	if limit == nil || *limit <= 0 {
+		return w
+	}
+
+	l := *limit
+	r := l - used
+	if remaining != nil {
+		r = *remaining
+		used = l - r
+	}
+	w.Limit = &l
+	w.Remaining = &r
+	w.Used = &used
+	w.Utilization = used / l * 100
+	return w
+}
+
+// resetTime returns when a key limit with the given reset period resets.
+// Resets happen at midnight UTC and weeks start on Monday.
+func resetTime(period *string, now time.Time) *time.Time {
+	if period == nil {
+		return nil
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var t time.Time
+	switch *period {
+	case "daily":
+		t = today.AddDate(0, 0, 1)
+	case "weekly":
+		daysUntilMonday := (8 - int(today.Weekday())) % 7
+		if daysUntilMonday == 0 {
+			daysUntilMonday = 7
+		}
+		t = today.AddDate(0, 0, daysUntilMonday)
+	case "monthly":
+		t = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	default:
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,138 @@
+package openrouter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProvider_GetUsage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+keyEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-or-key" {
+			t.Errorf("Authorization = %q", got)
+		}
+		fmt.Fprint(w, `{"data":{"label":"sk-or-v1-abc","limit":20,"limit_remaining":15,"limit_reset":"monthly","usage":42,"is_free_tier":false,"rate_limit":{"requests":200,"interval":"10s"}}}`)
+	})
+	mux.HandleFunc("GET "+creditsEndpoint, func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"data":{"total_credits":100,"total_usage":25}}`)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	p := NewProvider("sk-or-key", server.URL)
+	p.now = func() time.Time { return time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC) }
+
+	usage, err := p.GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if len(usage.Windows) != 2 {
+		t.Fatalf("expected 2 windows, got %d", len(usage.Windows))
+	}
+
+	credits := usage.Windows[0]
+	if credits.Label != "Credits" || *credits.Used != 25 || *credits.Limit != 100 || *credits.Remaining != 75 || credits.Utilization != 25 {
+		t.Errorf("credits window = %+v", credits)
+	}
+
+	key := usage.Windows[1]
+	if key.Label != "Key Limit" || *key.Used != 5 || *key.Remaining != 15 || key.Utilization != 25 {
+		t.Errorf("key window = %+v", key)
+	}
+	if key.ResetsAt == nil || !key.ResetsAt.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("key resets at %v, want 2026-04-01", key.ResetsAt)
+	}
+
+	info, ok := usage.Extra["key_info"].(map[string]any)
+	if !ok || info["rate_limit"] != "200 requests / 10s" {
+		t.Errorf("Extra[key_info] = %v", usage.Extra["key_info"])
+	}
+}
+
+func TestProvider_GetUsageProvisioningKey(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+keyEndpoint, func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"data":{"label":"provisioning","limit":null,"usage":0,"is_provisioning_key":true}}`)
+	})
+	mux.HandleFunc("GET "+creditsEndpoint, func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"data":{"total_credits":50,"total_usage":10}}`)
+	})
+	mux.HandleFunc("GET "+keysEndpoint, func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"data":[
+			{"hash":"h1","name":"ci","limit":10,"limit_remaining":4,"limit_reset":"daily","usage":6},
+			{"hash":"h2","name":"dev","limit":null,"usage":3},
+			{"hash":"h3","name":"old","limit":5,"usage":5,"disabled":true}
+		]}`)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	usage, err := NewProvider("sk-or-prov", server.URL).GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+
+	// Credits, the provisioning key itself and the one enabled key with a limit
+	if len(usage.Windows) != 3 {
+		t.Fatalf("expected 3 windows, got %d: %+v", len(usage.Windows), usage.Windows)
+	}
+	if w := usage.Windows[1]; w.Label != "Key Usage" || w.Limit != nil {
+		t.Errorf("provisioning key window = %+v", w)
+	}
+	if w := usage.Windows[2]; w.Label != "Key: ci" || w.Utilization != 60 || w.ResetsAt == nil {
+		t.Errorf("managed key window = %+v", w)
+	}
+	if keys, ok := usage.Extra["keys"].([]map[string]any); !ok || len(keys) != 3 {
+		t.Errorf("Extra[keys] = %v", usage.Extra["keys"])
+	}
+}
+
+func TestProvider_GetUsageCreditsForbidden(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+keyEndpoint, func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"data":{"label":"free","limit":null,"usage":1.5,"is_free_tier":true}}`)
+	})
+	mux.HandleFunc("GET "+creditsEndpoint, func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":{"message":"forbidden"}}`, http.StatusForbidden)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	usage, err := NewProvider("sk-or-free", server.URL).GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if len(usage.Windows) != 1 || usage.Windows[0].Label != "Key Usage" {
+		t.Errorf("windows = %+v", usage.Windows)
+	}
+}
+
+func TestResetTime(t *testing.T) {
+	// 2026-03-15 is a Sunday
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		period string
+		want   time.Time
+	}{
+		{"daily", time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"weekly", time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		got := resetTime(&tc.period, now)
+		if got == nil || !got.Equal(tc.want) {
+			t.Errorf("resetTime(%q) = %v, want %v", tc.period, got, tc.want)
+		}
+	}
+
+	if got := resetTime(nil, now); got != nil {
+		t.Errorf("resetTime(nil) = %v, want nil", got)
+	}
+}
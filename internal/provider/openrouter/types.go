@@ -0,0 +1,55 @@
+package openrouter
+
+// KeyInfoResponse is the response from the key info endpoint
+type KeyInfoResponse struct {
+	Data KeyInfo `json:"data"`
+}
+
+// KeyInfo describes the API key used to authenticate
+type KeyInfo struct {
+	Label             string     `json:"label"`
+	Limit             *float64   `json:"limit"`           // Credit limit in USD, nil if unlimited
+	LimitRemaining    *float64   `json:"limit_remaining"` // Remaining credit limit in USD, nil if unlimited
+	LimitReset        *string    `json:"limit_reset"`     // daily, weekly, monthly or nil
+	Usage             float64    `json:"usage"`           // All-time credits used in USD
+	UsageDaily        float64    `json:"usage_daily"`
+	UsageWeekly       float64    `json:"usage_weekly"`
+	UsageMonthly      float64    `json:"usage_monthly"`
+	IsFreeTier        bool       `json:"is_free_tier"`
+	IsProvisioningKey bool       `json:"is_provisioning_key"`
+	RateLimit         *RateLimit `json:"rate_limit"`
+}
+
+// RateLimit describes the request rate limit of a key
+type RateLimit struct {
+	Requests int    `json:"requests"`
+	Interval string `json:"interval"`
+}
+
+// CreditsResponse is the response from the credits endpoint
+type CreditsResponse struct {
+	Data Credits `json:"data"`
+}
+
+// Credits holds the account's purchased and used credits in USD
+type Credits struct {
+	TotalCredits float64 `json:"total_credits"`
+	TotalUsage   float64 `json:"total_usage"`
+}
+
+// KeysResponse is the response from the keys endpoint, available to provisioning keys
+type KeysResponse struct {
+	Data []Key `json:"data"`
+}
+
+// Key describes an API key managed by a provisioning key
+type Key struct {
+	Hash           string   `json:"hash"`
+	Name           string   `json:"name"`
+	Label          string   `json:"label"`
+	Disabled       bool     `json:"disabled"`
+	Limit          *float64 `json:"limit"`
+	LimitRemaining *float64 `json:"limit_remaining"`
+	LimitReset     *string  `json:"limit_reset"`
+	Usage          float64  `json:"usage"`
+}
@@ -23,6 +23,9 @@ type Usage struct {
 	// Provider name
 	Provider string `json:"provider"`
 
+	// Display name of the provider (set when fetched through usage.FetchAllUsage)
+	Name string `json:"name,omitempty"`
+
 	// Usage windows (provider-specific, can be nil)
 	Windows []UsageWindow `json:"windows"`
 
@@ -43,6 +46,10 @@ type UsageWindow struct {
 	Limit     *float64 `json:"limit,omitempty"`     // Usage limit (e.g., token count)
 	Used      *float64 `json:"used,omitempty"`      // Amount used
 	Remaining *float64 `json:"remaining,omitempty"` // Amount remaining
+
+	// Unbounded windows only count usage, such as spend without a budget, so their
+	// utilization is meaningless and left out of percentages
+	Unbounded bool `json:"unbounded,omitempty"`
 }
 
 // TimeUntilReset returns the duration until the window resets
@@ -57,6 +64,49 @@ func (w *UsageWindow) TimeUntilReset() *time.Duration {
 // UsageStats aggregates results from multiple providers
 type UsageStats struct {
 	Providers []Usage `json:"providers"`
+
+	// Tags summarizes the usage of the accounts sharing each tag, by tag name
+	Tags []TagSummary `json:"tags,omitempty"`
+
+	// Aggregates pools the windows of providers with several accounts (with --all-accounts)
+	Aggregates []Aggregate `json:"aggregates,omitempty"`
+}
+
+// Aggregate combines the usage of all accounts of a provider
+type Aggregate struct {
+	Provider string            `json:"provider"`
+	Name     string            `json:"name,omitempty"`
+	Accounts int               `json:"accounts"`
+	Errors   int               `json:"errors"` // Accounts whose usage could not be fetched
+	Windows  []AggregateWindow `json:"windows"`
+}
+
+// Bases of an aggregate window's utilization
+const (
+	// BasisAbsolute pools the limits and amounts used of every account
+	BasisAbsolute = "absolute"
+	// BasisPercentage averages the utilization of accounts that only report percentages
+	BasisPercentage = "percentage"
+)
+
+// AggregateWindow combines the windows with the same label across accounts. Utilization,
+// Used, Limit and Remaining describe the pooled capacity, and ResetsAt is the earliest reset.
+type AggregateWindow struct {
+	UsageWindow
+	Basis          string  `json:"basis"`           // BasisAbsolute or BasisPercentage
+	MaxUtilization float64 `json:"max_utilization"` // Utilization of the account with the least headroom
+	Accounts       int     `json:"accounts"`        // Accounts reporting the window
+	Available      int     `json:"available"`       // Accounts below the threshold
+	Threshold      float64 `json:"threshold"`       // Utilization at which an account is no longer available
+}
+
+// TagSummary aggregates the usage of the accounts with a tag
+type TagSummary struct {
+	Tag            string  `json:"tag"`
+	Accounts       int     `json:"accounts"`
+	Errors         int     `json:"errors"`          // Accounts whose usage could not be fetched
+	MaxUtilization float64 `json:"max_utilization"` // Highest utilization of any window
+	Class          string  `json:"class"`           // As returned by UtilizationClass
 }
 
 // MaxUtilization returns the maximum utilization across all providers
@@ -77,7 +127,11 @@ func (s *UsageStats) MaxUtilization() float64 {
 
 // GetClass returns the CSS class based on maximum utilization
 func (s *UsageStats) GetClass() string {
-	maxUtil := s.MaxUtilization()
+	return UtilizationClass(s.MaxUtilization())
+}
+
+// UtilizationClass returns the CSS class of a utilization percentage: normal, warning or critical
+func UtilizationClass(maxUtil float64) string {
 	if maxUtil >= 90 {
 		return "critical"
 	} else if maxUtil >= 75 {
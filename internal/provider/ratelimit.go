@@ -0,0 +1,183 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/httpclient"
+)
+
+// rateLimitKinds lists the limit kinds reported in rate-limit headers, in display order
+var rateLimitKinds = []struct {
+	key   string
+	label string
+}{
+	{"requests", "Requests (rate limit)"},
+	{"tokens", "Tokens (rate limit)"},
+	{"input-tokens", "Input Tokens (rate limit)"},
+	{"output-tokens", "Output Tokens (rate limit)"},
+}
+
+// RateLimitProbe describes a cheap request whose response headers carry the
+// vendor's live rate limits, such as a count_tokens or minimal completion call
+type RateLimitProbe struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   string
+	Client *httpclient.Client // Defaults to the shared client without retries
+}
+
+// Run issues the probe request and parses the rate-limit headers of the response.
+// Rate-limited (429) responses are accepted, since they carry the same headers.
+func (p *RateLimitProbe) Run() ([]UsageWindow, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	method := p.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if p.Body != "" {
+		body = strings.NewReader(p.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create probe request: %w", err)
+	}
+	for k, values := range p.Header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+
+	client := p.Client
+	if client == nil {
+		// Rate-limited responses carry the headers we are after, so don't retry them
+		client = httpclient.Default().WithoutRetries()
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute probe request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusTooManyRequests {
+		return nil, fmt.Errorf("probe request failed with status %d", resp.StatusCode)
+	}
+
+	windows := ParseRateLimitHeaders(resp.Header, time.Now())
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("probe response contains no rate-limit headers")
+	}
+	return windows, nil
+}
+
+// ParseRateLimitHeaders converts rate-limit response headers into usage windows.
+// Both the Anthropic style (anthropic-ratelimit-tokens-remaining, RFC 3339 resets)
+// and the OpenAI style used by OpenAI, Groq and others (x-ratelimit-remaining-tokens,
+// duration resets such as "6m0s") are recognized.
+func ParseRateLimitHeaders(h http.Header, now time.Time) []UsageWindow {
+	var windows []UsageWindow
+	for _, kind := range rateLimitKinds {
+		limit := h.Get("anthropic-ratelimit-" + kind.key + "-limit")
+		remaining := h.Get("anthropic-ratelimit-" + kind.key + "-remaining")
+		reset := h.Get("anthropic-ratelimit-" + kind.key + "-reset")
+		if limit == "" {
+			limit = h.Get("x-ratelimit-limit-" + kind.key)
+			remaining = h.Get("x-ratelimit-remaining-" + kind.key)
+			reset = h.Get("x-ratelimit-reset-" + kind.key)
+		}
+
+		if w, ok := rateLimitWindow(kind.label, limit, remaining, reset, now); ok {
+			windows = append(windows, w)
+		}
+	}
+	return windows
+}
+
+// rateLimitWindow builds a window from raw header values
+func rateLimitWindow(label, limitStr, remainingStr, resetStr string, now time.Time) (UsageWindow, bool) {
+	limit, err := strconv.ParseFloat(strings.TrimSpace(limitStr), 64)
+	if err != nil || limit <= 0 {
+		return UsageWindow{}, false
+	}
+	remaining, err := strconv.ParseFloat(strings.TrimSpace(remainingStr), 64)
+	if err != nil {
+		return UsageWindow{}, false
+	}
+
+	used := limit - remaining
+	return UsageWindow{
+		Label:       label,
+		Utilization: used / limit * 100,
+		ResetsAt:    parseRateLimitReset(resetStr, now),
+		Limit:       &limit,
+		Used:        &used,
+		Remaining:   &remaining,
+	}, true
+}
+
+// parseRateLimitReset parses a reset header given as an RFC 3339 timestamp,
+// a Go-style duration ("1m30s", "20ms"), a number of seconds or a Unix timestamp
+func parseRateLimitReset(s string, now time.Time) *time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		t := now.Add(d)
+		return &t
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		// Values this large can only be Unix timestamps
+		if v > 1e9 {
+			t := time.Unix(int64(v), 0)
+			return &t
+		}
+		t := now.Add(time.Duration(v * float64(time.Second)))
+		return &t
+	}
+	return nil
+}
+
+// ProbingProvider wraps a provider and appends the windows reported by a rate-limit probe.
+// A failing probe does not fail the wrapped provider; the error is reported in Extra.
+type ProbingProvider struct {
+	Provider
+	Probe *RateLimitProbe
+}
+
+// GetUsage fetches the wrapped provider's usage and appends the probed rate limits
+func (p *ProbingProvider) GetUsage() (*Usage, error) {
+	usage, err := p.Provider.GetUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	windows, err := p.Probe.Run()
+	if err != nil {
+		if usage.Extra == nil {
+			usage.Extra = make(map[string]any)
+		}
+		usage.Extra["rate_limit_error"] = err.Error()
+		return usage, nil
+	}
+
+	usage.Windows = append(usage.Windows, windows...)
+	return usage, nil
+}
@@ -0,0 +1,145 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	t.Run("anthropic", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("anthropic-ratelimit-requests-limit", "50")
+		h.Set("anthropic-ratelimit-requests-remaining", "40")
+		h.Set("anthropic-ratelimit-requests-reset", "2026-03-15T12:00:30Z")
+		h.Set("anthropic-ratelimit-input-tokens-limit", "40000")
+		h.Set("anthropic-ratelimit-input-tokens-remaining", "40000")
+
+		windows := ParseRateLimitHeaders(h, now)
+		if len(windows) != 2 {
+			t.Fatalf("expected 2 windows, got %d", len(windows))
+		}
+		if w := windows[0]; w.Label != "Requests (rate limit)" || w.Utilization != 20 || *w.Used != 10 {
+			t.Errorf("requests window = %+v", w)
+		}
+		if w := windows[0]; w.ResetsAt == nil || !w.ResetsAt.Equal(now.Add(30*time.Second)) {
+			t.Errorf("requests resets at %v", w.ResetsAt)
+		}
+		if w := windows[1]; w.Label != "Input Tokens (rate limit)" || w.Utilization != 0 || w.ResetsAt != nil {
+			t.Errorf("input tokens window = %+v", w)
+		}
+	})
+
+	t.Run("openai", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("x-ratelimit-limit-requests", "10000")
+		h.Set("x-ratelimit-remaining-requests", "9999")
+		h.Set("x-ratelimit-reset-requests", "6ms")
+		h.Set("x-ratelimit-limit-tokens", "200000")
+		h.Set("x-ratelimit-remaining-tokens", "150000")
+		h.Set("x-ratelimit-reset-tokens", "1m30s")
+
+		windows := ParseRateLimitHeaders(h, now)
+		if len(windows) != 2 {
+			t.Fatalf("expected 2 windows, got %d", len(windows))
+		}
+		if w := windows[1]; w.Label != "Tokens (rate limit)" || w.Utilization != 25 || !w.ResetsAt.Equal(now.Add(90*time.Second)) {
+			t.Errorf("tokens window = %+v", w)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("x-ratelimit-limit-requests", "many")
+		h.Set("x-ratelimit-remaining-requests", "1")
+		if windows := ParseRateLimitHeaders(h, now); len(windows) != 0 {
+			t.Errorf("expected no windows, got %+v", windows)
+		}
+	})
+}
+
+func TestParseRateLimitReset(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input string
+		want  *time.Time
+	}{
+		{"", nil},
+		{"garbage", nil},
+		{"2026-03-15T13:00:00Z", ptr(now.Add(time.Hour))},
+		{"2m59.5s", ptr(now.Add(2*time.Minute + 59500*time.Millisecond))},
+		{"7.5", ptr(now.Add(7500 * time.Millisecond))},
+		{"1773579600", ptr(time.Unix(1773579600, 0))},
+	}
+
+	for _, tc := range tests {
+		got := parseRateLimitReset(tc.input, now)
+		if (got == nil) != (tc.want == nil) || (got != nil && !got.Equal(*tc.want)) {
+			t.Errorf("parseRateLimitReset(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestRateLimitProbe_Run(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("X-Api-Key") != "key" {
+			t.Errorf("unexpected request %s with key %q", r.Method, r.Header.Get("X-Api-Key"))
+		}
+		w.Header().Set("anthropic-ratelimit-requests-limit", "5")
+		w.Header().Set("anthropic-ratelimit-requests-remaining", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	probe := &RateLimitProbe{
+		Method: http.MethodPost,
+		URL:    server.URL,
+		Header: http.Header{"X-Api-Key": []string{"key"}},
+		Body:   "{}",
+	}
+	windows, err := probe.Run()
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(windows) != 1 || windows[0].Utilization != 100 {
+		t.Errorf("windows = %+v", windows)
+	}
+}
+
+// staticProvider returns fixed usage
+type staticProvider struct{}
+
+func (staticProvider) Name() string { return "Static" }
+func (staticProvider) ID() string   { return "static" }
+func (staticProvider) GetUsage() (*Usage, error) {
+	return &Usage{Provider: "static", Windows: []UsageWindow{{Label: "Monthly"}}}, nil
+}
+
+func TestProbingProvider_ProbeFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	p := &ProbingProvider{Provider: staticProvider{}, Probe: &RateLimitProbe{URL: server.URL}}
+	usage, err := p.GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if len(usage.Windows) != 1 {
+		t.Errorf("expected only the wrapped provider's window, got %+v", usage.Windows)
+	}
+	if _, ok := usage.Extra["rate_limit_error"]; !ok {
+		t.Error("Extra[rate_limit_error] should be set")
+	}
+	if p.ID() != "static" {
+		t.Errorf("ID() = %q, want the wrapped provider's ID", p.ID())
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
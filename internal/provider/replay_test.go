@@ -0,0 +1,74 @@
+package provider_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/denysvitali/llm-usage/internal/httpclient"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/provider/claude"
+	"github.com/denysvitali/llm-usage/internal/provider/openrouter"
+)
+
+// replay points the shared HTTP client at the recorded fixtures in testdata/<id>,
+// which are recorded with `llm-usage --record DIR`
+func replay(t *testing.T, id string) {
+	t.Helper()
+	retries := 0
+	if err := httpclient.Configure(httpclient.Options{Replay: filepath.Join("testdata", id), MaxRetries: &retries}); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	t.Cleanup(func() { _ = httpclient.Configure(httpclient.Options{}) })
+}
+
+// findWindow returns the window with the given label
+func findWindow(t *testing.T, usage *provider.Usage, label string) provider.UsageWindow {
+	t.Helper()
+	for _, w := range usage.Windows {
+		if w.Label == label {
+			return w
+		}
+	}
+	t.Fatalf("window %q not found in %+v", label, usage.Windows)
+	return provider.UsageWindow{}
+}
+
+func TestReplay_Claude(t *testing.T) {
+	replay(t, "claude")
+
+	usage, err := claude.NewProvider("redacted-token", "").GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if len(usage.Windows) != 3 {
+		t.Fatalf("expected 3 windows, got %d", len(usage.Windows))
+	}
+	if w := findWindow(t, usage, "5-Hour"); w.Utilization != 37 || w.ResetsAt == nil {
+		t.Errorf("5-Hour window = %+v", w)
+	}
+	if w := findWindow(t, usage, "7-Day Opus"); w.Utilization != 4 {
+		t.Errorf("7-Day Opus window = %+v", w)
+	}
+	if _, ok := usage.Extra["extra_usage"]; !ok {
+		t.Error("expected extra_usage in Extra")
+	}
+}
+
+func TestReplay_OpenRouter(t *testing.T) {
+	replay(t, "openrouter")
+
+	usage, err := openrouter.NewProvider("redacted-key", "").GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	// Credits are forbidden for regular keys, leaving only the key limit
+	if len(usage.Windows) != 1 {
+		t.Fatalf("expected 1 window, got %d", len(usage.Windows))
+	}
+	if w := findWindow(t, usage, "Key Limit"); *w.Remaining != 12.5 || *w.Limit != 20 || w.ResetsAt == nil {
+		t.Errorf("Key Limit window = %+v", w)
+	}
+	if _, ok := usage.Extra["credits_remaining_usd"]; ok {
+		t.Error("credits_remaining_usd should be absent when credits are forbidden")
+	}
+}
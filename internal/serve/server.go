@@ -10,8 +10,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
+	"github.com/denysvitali/llm-usage/internal/config"
 	"github.com/denysvitali/llm-usage/internal/credentials"
 	"github.com/denysvitali/llm-usage/internal/usage"
 )
@@ -20,9 +22,13 @@ import (
 var embeddedFS embed.FS
 
 const (
-	providerClaude = "claude"
-	providerKimi   = "kimi"
-	providerZAi    = "zai"
+	providerClaude       = "claude"
+	providerKimi         = "kimi"
+	providerZAi          = "zai"
+	providerMiniMax      = "minimax"
+	providerOpenAI       = "openai"
+	providerAnthropicAPI = "anthropic-api"
+	providerOpenRouter   = "openrouter"
 )
 
 // Config holds the server configuration
@@ -30,6 +36,9 @@ type Config struct {
 	Host   string
 	Port   int
 	WebDir string
+
+	// AppConfig holds the providers defined in the configuration file (may be nil)
+	AppConfig *config.Config
 }
 
 // Server represents the HTTP server
@@ -106,7 +115,7 @@ func (s *Server) Start(ctx context.Context) error {
 
 // loadProviders loads all configured providers
 func (s *Server) loadProviders() {
-	s.providers = usage.GetProviders("", "", true, s.credsMgr)
+	s.providers = usage.GetProviders("", "", true, s.credsMgr, s.config.AppConfig)
 }
 
 // handleIndex serves the frontend HTML
@@ -134,15 +143,25 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "no-cache")
+	s.credsMgr.Refresh()
 
 	// Parse query parameters
 	providerFilter := r.URL.Query().Get("provider")
 	accountFilter := r.URL.Query().Get("account")
+	tags, err := credentials.NormalizeTags(r.URL.Query()["tag"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Always fetch fresh providers on each request
-	providers := usage.GetProviders(providerFilter, accountFilter, accountFilter == "", s.credsMgr)
+	providers := usage.GetProviders(providerFilter, accountFilter, accountFilter == "", s.credsMgr, s.config.AppConfig)
+	providers = usage.FilterByTags(providers, tags)
 
 	stats := usage.FetchAllUsage(providers)
+	if r.URL.Query().Get("aggregate") == "true" {
+		stats.Aggregates = usage.Aggregate(stats, usage.DefaultThreshold)
+	}
 
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
@@ -154,35 +173,33 @@ func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
 // handleProviders returns list of available providers
 func (s *Server) handleProviders(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	s.credsMgr.Refresh()
 
 	type ProviderInfo struct {
-		ID       string   `json:"id"`
-		Name     string   `json:"name"`
-		Accounts []string `json:"accounts"`
+		ID       string              `json:"id"`
+		Name     string              `json:"name"`
+		Accounts []string            `json:"accounts"`
+		Sources  map[string]string   `json:"sources,omitempty"` // Where each account was found
+		Tags     map[string][]string `json:"tags,omitempty"`    // Tags of each account that has some
 	}
 
 	providerIDs := s.credsMgr.ListAvailable()
+	for _, id := range s.config.AppConfig.ProviderIDs() {
+		if !slices.Contains(providerIDs, id) {
+			providerIDs = append(providerIDs, id)
+		}
+	}
 	providerList := make([]ProviderInfo, 0, len(providerIDs))
 
 	for _, pid := range providerIDs {
 		var accounts []string
+		var sources map[string]string
 		switch pid {
 		case providerClaude:
-			if creds, err := s.credsMgr.LoadClaude(); err == nil {
-				accounts = creds.ListAccounts()
-			}
-			// Check for keychain credentials (only add "default" if not already present)
-			if _, _, err := usage.LoadClaudeFromKeychain(); err == nil {
-				found := false
-				for _, acc := range accounts {
-					if acc == "default" {
-						found = true
-						break
-					}
-				}
-				if !found {
-					accounts = append(accounts, "default")
-				}
+			sources = make(map[string]string)
+			for _, d := range s.credsMgr.DiscoverClaude() {
+				accounts = append(accounts, d.Name)
+				sources[d.Name] = d.Source()
 			}
 		case providerKimi:
 			if creds, err := s.credsMgr.LoadKimi(); err == nil {
@@ -192,13 +209,57 @@ func (s *Server) handleProviders(w http.ResponseWriter, _ *http.Request) {
 			if creds, err := s.credsMgr.LoadZAi(); err == nil {
 				accounts = creds.ListAccounts()
 			}
+		case providerMiniMax:
+			if creds, err := s.credsMgr.LoadMiniMax(); err == nil {
+				accounts = creds.ListAccounts()
+			}
+		case providerOpenAI:
+			if creds, err := s.credsMgr.LoadOpenAI(); err == nil {
+				accounts = creds.ListAccounts()
+			}
+		case providerAnthropicAPI:
+			if creds, err := s.credsMgr.LoadAnthropicAPI(); err == nil {
+				accounts = creds.ListAccounts()
+			}
+		case providerOpenRouter:
+			if creds, err := s.credsMgr.LoadOpenRouter(); err == nil {
+				accounts = creds.ListAccounts()
+			}
+		default:
+			if creds, err := s.credsMgr.LoadGeneric(pid); err == nil {
+				accounts = creds.ListAccounts()
+			}
+		}
+
+		if sources == nil && len(accounts) > 0 {
+			sources = make(map[string]string, len(accounts))
+			for _, acc := range accounts {
+				sources[acc] = s.credsMgr.AccountSource(pid, acc)
+			}
+		}
+
+		var tags map[string][]string
+		if meta, err := s.credsMgr.ReadAccountsMeta(pid); err == nil {
+			for _, acc := range accounts {
+				if m := meta.Meta(acc); m != nil && len(m.Tags) > 0 {
+					if tags == nil {
+						tags = make(map[string][]string)
+					}
+					tags[acc] = m.Tags
+				}
+			}
 		}
 
 		name := providerName(pid)
+		if pc := s.config.AppConfig.Provider(pid); pc != nil {
+			name = pc.DisplayName()
+		}
 		providerList = append(providerList, ProviderInfo{
 			ID:       pid,
 			Name:     name,
 			Accounts: accounts,
+			Sources:  sources,
+			Tags:     tags,
 		})
 	}
 
@@ -215,6 +276,14 @@ func providerName(id string) string {
 		return "Kimi"
 	case providerZAi:
 		return "Z.AI"
+	case providerMiniMax:
+		return "MiniMax"
+	case providerOpenAI:
+		return "OpenAI"
+	case providerAnthropicAPI:
+		return "Anthropic API"
+	case providerOpenRouter:
+		return "OpenRouter"
 	default:
 		return id
 	}
@@ -0,0 +1,328 @@
+package setup
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/config"
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+// ErrAccountExists is returned when adding an account that exists without AddOptions.Force
+var ErrAccountExists = errors.New("account already exists")
+
+// Credentials are the secrets of an account added with SaveAccount
+type Credentials struct {
+	APIKey  string // API key; the admin key for OpenAI and the Anthropic API
+	Cookie  string // MiniMax session cookie
+	GroupID string // MiniMax group ID
+
+	MonthlyBudget *float64 // Optional budget in USD for OpenAI and the Anthropic API
+}
+
+// AddOptions control how an account is added
+type AddOptions struct {
+	Force    bool     // Replace an existing account
+	Validate bool     // Fetch usage with the credentials, and only save them if that succeeds
+	Label    string   // Display name kept in the account's metadata; empty keeps the current one
+	Tags     []string // Tags grouping the account, as normalized by credentials.NormalizeTags; none keeps the current ones
+}
+
+// Result describes an account saved by SaveAccount
+type Result struct {
+	Provider  string `json:"provider"`
+	Account   string `json:"account"`
+	Replaced  bool   `json:"replaced"`  // An existing account was replaced
+	Validated bool   `json:"validated"` // The provider accepted the credentials
+}
+
+// SaveAccount saves an account without prompting or printing. It is the code path shared
+// by interactive and scripted `setup add` and the setup TUI. Replaced accounts keep the
+// settings that are not given, such as their base URL and secret store.
+func SaveAccount(mgr *credentials.Manager, providerID, accountName string, creds Credentials, opts AddOptions) (*Result, error) {
+	accountName = cmp.Or(accountName, "default")
+	if err := credentials.ValidateAccountName(accountName); err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	save := saveOptions{accountName: accountName, force: opts.Force, label: opts.Label, tags: opts.Tags}
+	if opts.Validate {
+		save.validate = func(account any) error {
+			return validateAccount(providerID, accountName, account, cfg)
+		}
+	}
+
+	var replaced bool
+	switch providerID {
+	case providerClaude:
+		return nil, errors.New("claude accounts are added by logging in; run 'llm-usage setup add claude' in a terminal")
+	case providerMiniMax:
+		if creds.Cookie == "" {
+			return nil, errors.New("cookie is required")
+		}
+		if creds.GroupID == "" {
+			return nil, errors.New("group ID is required")
+		}
+		replaced, err = saveMiniMaxCredentials(mgr, &credentials.MiniMaxAccount{Cookie: creds.Cookie, GroupID: creds.GroupID}, save)
+	case providerOpenAI, providerAnthropicAPI:
+		if creds.APIKey == "" {
+			return nil, errors.New("admin API key is required")
+		}
+		if providerID == providerOpenAI {
+			replaced, err = saveOpenAICredentials(mgr, &credentials.OpenAIAccount{AdminKey: creds.APIKey, MonthlyBudget: creds.MonthlyBudget}, save)
+		} else {
+			replaced, err = saveAnthropicAPICredentials(mgr, &credentials.AnthropicAPIAccount{AdminKey: creds.APIKey, MonthlyBudget: creds.MonthlyBudget}, save)
+		}
+	default:
+		if providerID != providerKimi && providerID != providerZAi && providerID != providerOpenRouter && cfg.Provider(providerID) == nil {
+			return nil, fmt.Errorf("unknown provider: %s", providerID)
+		}
+		if creds.APIKey == "" {
+			return nil, errors.New("API key is required")
+		}
+		replaced, err = saveAPIKeyCredentials(mgr, providerID, creds.APIKey, save)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Provider:  providerID,
+		Account:   accountName,
+		Replaced:  replaced,
+		Validated: opts.Validate,
+	}, nil
+}
+
+// validateAccount fetches usage with an account's credentials
+func validateAccount(providerID, accountName string, account any, cfg *config.Config) error {
+	p, err := usage.NewAccountProvider(providerID, accountName, account, cfg)
+	if err != nil {
+		return err
+	}
+	u, err := p.GetUsage()
+	if err == nil && u.Error != nil {
+		err = u.Error
+	}
+	if err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return nil
+}
+
+// saveOptions is passed by SaveAccount to the save*Credentials functions
+type saveOptions struct {
+	accountName string
+	force       bool
+	label       string                  // Replaces the account's label if set
+	tags        []string                // Replace the account's tags if set
+	validate    func(account any) error // Nil skips validation
+}
+
+// check decides whether an account may be saved over an existing one
+func (o saveOptions) check(exists bool) error {
+	if exists && !o.force {
+		return fmt.Errorf("%w: '%s'", ErrAccountExists, o.accountName)
+	}
+	return nil
+}
+
+// promptAccountName asks for the account name if it was not given, and for confirmation
+// before replacing an existing account
+func promptAccountName(mgr *credentials.Manager, providerID, accountName string, opts AddOptions) (string, AddOptions, error) {
+	if accountName == "" {
+		fmt.Print("Enter account name (default): ")
+		accountName = cmp.Or(readLine(), "default")
+	}
+	if err := credentials.ValidateAccountName(accountName); err != nil {
+		return "", opts, err
+	}
+
+	if !opts.Force && accountExists(mgr, providerID, accountName) {
+		fmt.Printf("Account '%s' already exists. Replace it? [y/N]: ", accountName)
+		if !confirm() {
+			return "", opts, errors.New("cancelled")
+		}
+		opts.Force = true
+	}
+	return accountName, opts, nil
+}
+
+// accountExists reports whether a provider has an account
+func accountExists(mgr *credentials.Manager, providerID, accountName string) bool {
+	accounts, err := mgr.ListAccounts(providerID)
+	return err == nil && slices.Contains(accounts, accountName)
+}
+
+// saveAndReport saves an account entered interactively and prints the outcome
+func saveAndReport(mgr *credentials.Manager, providerID, displayName, accountName string, creds Credentials, opts AddOptions) error {
+	if opts.Validate {
+		fmt.Println("Validating credentials...")
+	}
+	if _, err := SaveAccount(mgr, providerID, accountName, creds, opts); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully added %s account '%s'!\n", displayName, accountName)
+	return nil
+}
+
+// saveAPIKeyCredentials saves credentials for API key-based providers
+func saveAPIKeyCredentials(mgr *credentials.Manager, providerID, apiKey string, opts saveOptions) (bool, error) {
+	switch providerID {
+	case providerKimi:
+		var creds credentials.KimiCredentials
+		return saveCredentials(mgr, providerID, &creds, &creds.AccountSet, &credentials.KimiAccount{APIKey: apiKey},
+			func(account, existing *credentials.KimiAccount) {
+				account.BaseURL = existing.BaseURL
+				account.SecretStore = existing.SecretStore
+			}, opts)
+	case providerZAi:
+		var creds credentials.ZAiCredentials
+		return saveCredentials(mgr, providerID, &creds, &creds.AccountSet, &credentials.ZAiAccount{APIKey: apiKey},
+			func(account, existing *credentials.ZAiAccount) {
+				account.SecretStore = existing.SecretStore
+			}, opts)
+	case providerOpenRouter:
+		var creds credentials.OpenRouterCredentials
+		return saveCredentials(mgr, providerID, &creds, &creds.AccountSet, &credentials.OpenRouterAccount{APIKey: apiKey},
+			func(account, existing *credentials.OpenRouterAccount) {
+				account.BaseURL = existing.BaseURL
+				account.SecretStore = existing.SecretStore
+			}, opts)
+	default:
+		var creds credentials.GenericCredentials
+		return saveCredentials(mgr, providerID, &creds, &creds.AccountSet, &credentials.GenericAccount{APIKey: apiKey},
+			func(account, existing *credentials.GenericAccount) {
+				account.Fields = existing.Fields
+				account.SecretStore = existing.SecretStore
+			}, opts)
+	}
+}
+
+// saveOpenAICredentials saves an OpenAI account and reports whether it replaced an existing one
+func saveOpenAICredentials(mgr *credentials.Manager, account *credentials.OpenAIAccount, opts saveOptions) (bool, error) {
+	var creds credentials.OpenAICredentials
+	return saveCredentials(mgr, providerOpenAI, &creds, &creds.AccountSet, account,
+		func(account, existing *credentials.OpenAIAccount) {
+			account.BaseURL = existing.BaseURL
+			account.SecretStore = existing.SecretStore
+			account.ProbeKey = existing.ProbeKey
+			account.DailyBudget = existing.DailyBudget
+			if account.MonthlyBudget == nil {
+				account.MonthlyBudget = existing.MonthlyBudget
+			}
+		}, opts)
+}
+
+// saveAnthropicAPICredentials saves an Anthropic Admin API account and reports whether it
+// replaced an existing one
+func saveAnthropicAPICredentials(mgr *credentials.Manager, account *credentials.AnthropicAPIAccount, opts saveOptions) (bool, error) {
+	var creds credentials.AnthropicAPICredentials
+	return saveCredentials(mgr, providerAnthropicAPI, &creds, &creds.AccountSet, account,
+		func(account, existing *credentials.AnthropicAPIAccount) {
+			account.BaseURL = existing.BaseURL
+			account.SecretStore = existing.SecretStore
+			account.ProbeKey = existing.ProbeKey
+			account.DailyBudget = existing.DailyBudget
+			if account.MonthlyBudget == nil {
+				account.MonthlyBudget = existing.MonthlyBudget
+			}
+		}, opts)
+}
+
+// saveMiniMaxCredentials saves a MiniMax account and reports whether it replaced an existing one
+func saveMiniMaxCredentials(mgr *credentials.Manager, account *credentials.MiniMaxAccount, opts saveOptions) (bool, error) {
+	var creds credentials.MiniMaxCredentials
+	return saveCredentials(mgr, providerMiniMax, &creds, &creds.AccountSet, account,
+		func(account, existing *credentials.MiniMaxAccount) {
+			account.BaseURL = existing.BaseURL
+			account.SecretStore = existing.SecretStore
+		}, opts)
+}
+
+// saveCredentials adds an account to a provider's credentials file and reports whether it
+// replaced an existing one. The file is loaded into creds, whose accounts are set. keep
+// copies the settings of a replaced account that are not given; its metadata is kept too.
+// The credentials stay locked from loading to saving, so concurrent setups are not lost.
+// Validation fetches usage, which can take longer than other setups wait for the lock, so
+// it runs before locking, on the account merged with the file as it was then.
+func saveCredentials[A any](mgr *credentials.Manager, providerID string, creds credentials.ProviderConfig, set *credentials.AccountSet[A], account *A, keep func(account, existing *A), opts saveOptions) (bool, error) {
+	load := func() (*A, error) {
+		*set = credentials.AccountSet[A]{}
+		if mgr.ProviderExists(providerID) {
+			if err := mgr.LoadProvider(providerID, creds); err != nil {
+				return nil, fmt.Errorf("failed to load existing credentials: %w", err)
+			}
+		}
+		return set.Accounts[opts.accountName], nil
+	}
+
+	if opts.validate != nil {
+		existing, err := load()
+		if err != nil {
+			return false, err
+		}
+		if err := opts.check(existing != nil); err != nil {
+			return false, err
+		}
+		merged := *account
+		if existing != nil {
+			keep(&merged, existing)
+		}
+		if err := opts.validate(&merged); err != nil {
+			return false, err
+		}
+	}
+
+	var replaced bool
+	err := mgr.Update(func() error {
+		existing, err := load()
+		if err != nil {
+			return err
+		}
+		// Another setup may have added the account while it was validated
+		if err := opts.check(existing != nil); err != nil {
+			return err
+		}
+		if set.Accounts == nil {
+			set.Accounts = make(map[string]*A)
+		}
+
+		now := time.Now().UTC()
+		meta := any(account).(credentials.Account).Meta()
+		if existing != nil {
+			keep(account, existing)
+			*meta = *set.Meta(opts.accountName)
+			meta.LastValidated = nil // The new credentials have not been validated yet
+		} else {
+			meta.CreatedAt = &now
+		}
+		if opts.label != "" {
+			meta.Label = opts.label
+		}
+		if len(opts.tags) > 0 {
+			meta.Tags = opts.tags
+		}
+
+		if opts.validate != nil {
+			meta.LastValidated = &now
+		}
+		set.Accounts[opts.accountName] = account
+
+		if err := mgr.SaveProvider(providerID, creds); err != nil {
+			return fmt.Errorf("failed to save credentials: %w", err)
+		}
+		replaced = existing != nil
+		return nil
+	})
+	return replaced, err
+}
@@ -0,0 +1,159 @@
+package setup
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/fsutil"
+)
+
+// newTestManager returns a credentials manager whose config directory is in t.TempDir
+func newTestManager(t *testing.T) *credentials.Manager {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+
+	mgr := credentials.NewManager()
+	if err := mgr.EnsureConfigDir(); err != nil {
+		t.Fatal(err)
+	}
+	return mgr
+}
+
+func TestSaveAccount(t *testing.T) {
+	mgr := newTestManager(t)
+	path := mgr.ProviderPath("kimi")
+	legacy := `{"apiKey":"sk-legacy"}`
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The legacy account becomes "default", which is not replaced without Force
+	if _, err := SaveAccount(mgr, "kimi", "", Credentials{APIKey: "sk-new"}, AddOptions{}); !errors.Is(err, ErrAccountExists) {
+		t.Fatalf("SaveAccount() error = %v, want %v", err, ErrAccountExists)
+	}
+
+	result, err := SaveAccount(mgr, "kimi", "work", Credentials{APIKey: "sk-work"}, AddOptions{})
+	if err != nil {
+		t.Fatalf("SaveAccount(work) error = %v", err)
+	}
+	if *result != (Result{Provider: "kimi", Account: "work"}) {
+		t.Errorf("result = %+v", result)
+	}
+
+	// Replacing an account keeps its settings
+	creds, err := mgr.LoadKimi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := creds.Meta("work").CreatedAt
+	if created == nil {
+		t.Fatal("work account has no creation time")
+	}
+	creds.Accounts["work"].BaseURL = "https://gateway.example/kimi"
+	if err := mgr.SaveProvider("kimi", creds); err != nil {
+		t.Fatal(err)
+	}
+	result, err = SaveAccount(mgr, "kimi", "work", Credentials{APIKey: "sk-rotated"}, AddOptions{Force: true, Label: "Work", Tags: []string{"work"}})
+	if err != nil || !result.Replaced {
+		t.Fatalf("SaveAccount(work, force) = %+v, %v", result, err)
+	}
+
+	creds, err = mgr.LoadKimi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc := creds.GetAccount("default"); acc == nil || acc.APIKey != "sk-legacy" {
+		t.Errorf("default account = %+v", acc)
+	}
+	if acc := creds.GetAccount("work"); acc == nil || acc.APIKey != "sk-rotated" || acc.BaseURL != "https://gateway.example/kimi" {
+		t.Errorf("work account = %+v", acc)
+	}
+	if meta := creds.Meta("work"); meta.Label != "Work" || !slices.Equal(meta.Tags, []string{"work"}) || meta.CreatedAt == nil || !meta.CreatedAt.Equal(*created) {
+		t.Errorf("work metadata = %+v, want label, tags and creation time %v", meta, created)
+	}
+
+	for _, tt := range []struct {
+		providerID string
+		creds      Credentials
+		want       string
+	}{
+		{"claude", Credentials{APIKey: "sk-ant"}, "logging in"},
+		{"minimax", Credentials{Cookie: "session=1"}, "group ID is required"},
+		{"openai", Credentials{}, "admin API key is required"},
+		{"acme", Credentials{APIKey: "sk-acme"}, "unknown provider"},
+	} {
+		if _, err := SaveAccount(mgr, tt.providerID, "ci", tt.creds, AddOptions{}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("SaveAccount(%s) error = %v, want %q", tt.providerID, err, tt.want)
+		}
+	}
+
+	for _, name := range []string{"../x", "a/b", ".."} {
+		if _, err := SaveAccount(mgr, "kimi", name, Credentials{APIKey: "sk-x"}, AddOptions{}); err == nil || !strings.Contains(err.Error(), "invalid account name") {
+			t.Errorf("SaveAccount(%q) error = %v, want invalid account name", name, err)
+		}
+	}
+}
+
+func TestSaveAccount_Validate(t *testing.T) {
+	mgr := newTestManager(t)
+	var locked bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Validation must not keep other setups waiting for the credentials lock
+		if lock, err := fsutil.TryLock(filepath.Join(mgr.ConfigDir(), ".lock")); err != nil {
+			locked = true
+		} else {
+			_ = lock.Unlock()
+		}
+		if r.Header.Get("Authorization") != "Bearer sk-or-good" {
+			http.Error(w, `{"error":{"message":"invalid key"}}`, http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"label":"ci","usage":1.5,"limit":10}}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	config := "endpoints:\n  openrouter: " + ts.URL + "\n"
+	if err := os.WriteFile(filepath.Join(mgr.ConfigDir(), "config.yaml"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := SaveAccount(mgr, "openrouter", "ci", Credentials{APIKey: "sk-or-bad"}, AddOptions{Validate: true}); err == nil || !strings.Contains(err.Error(), "validation failed") {
+		t.Fatalf("SaveAccount(bad key) error = %v", err)
+	}
+	if mgr.ProviderExists("openrouter") {
+		t.Error("credentials saved despite failed validation")
+	}
+
+	result, err := SaveAccount(mgr, "openrouter", "ci", Credentials{APIKey: "sk-or-good"}, AddOptions{Validate: true})
+	if err != nil || !result.Validated {
+		t.Fatalf("SaveAccount(good key) = %+v, %v", result, err)
+	}
+	if locked {
+		t.Error("credentials locked during validation")
+	}
+}
+
+func TestMigrateSecrets_Errors(t *testing.T) {
+	mgr := newTestManager(t)
+	if _, err := SaveAccount(mgr, "kimi", "work", Credentials{APIKey: "sk-kimi"}, AddOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Swapped arguments name a provider as the store
+	if err := MigrateSecrets(mgr, "kimi", "age", ""); err == nil || !strings.HasPrefix(err.Error(), `unknown secret store "kimi"`) {
+		t.Errorf("MigrateSecrets(kimi, age) error = %v, want unknown secret store", err)
+	}
+	if err := MigrateSecrets(mgr, credentials.StoreFile, "zai", ""); err == nil || !strings.Contains(err.Error(), `"zai"`) {
+		t.Errorf("MigrateSecrets(file, zai) error = %v, want the provider ID", err)
+	}
+}
@@ -0,0 +1,112 @@
+package setup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+)
+
+// BundlePassphraseEnv holds the passphrase of export bundles, which is prompted for otherwise
+const BundlePassphraseEnv = "LLM_USAGE_BUNDLE_PASSPHRASE"
+
+// Export writes the selected accounts to an encrypted bundle at path, or to w if path is
+// empty or "-"
+func Export(mgr *credentials.Manager, selectors []string, path string, w io.Writer) error {
+	bundle, err := mgr.Export(selectors)
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := bundlePassphrase(true)
+	if err != nil {
+		return err
+	}
+	data, err := credentials.EncryptBundle(bundle, passphrase)
+	if err != nil {
+		return err
+	}
+
+	if path == "" || path == "-" {
+		_, err = w.Write(data)
+		return err
+	}
+	if err := credentials.WriteBundleFile(path, data); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	count := 0
+	for _, accounts := range bundle.Providers {
+		count += len(accounts)
+	}
+	fmt.Printf("Exported %d account(s) from %d provider(s) to %s\n", count, len(bundle.Providers), path)
+	return nil
+}
+
+// Import decrypts a bundle and merges its accounts into the credentials files
+func Import(mgr *credentials.Manager, data []byte, opts credentials.ImportOptions) ([]credentials.ImportedAccount, error) {
+	passphrase, err := bundlePassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := credentials.DecryptBundle(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.EnsureConfigDir(); err != nil {
+		return nil, err
+	}
+	return mgr.Import(bundle, opts)
+}
+
+// PrintImported reports the accounts handled by Import
+func PrintImported(imported []credentials.ImportedAccount) {
+	if len(imported) == 0 {
+		fmt.Println("No matching accounts in the bundle.")
+		return
+	}
+	for _, acc := range imported {
+		name := providerName(acc.Provider)
+		switch acc.Action {
+		case credentials.ImportSkipped:
+			fmt.Printf("Skipped %s account '%s' (already exists)\n", name, acc.Account)
+		case credentials.ImportRenamed:
+			fmt.Printf("Imported %s account '%s' as '%s'\n", name, acc.Source, acc.Account)
+		case credentials.ImportOverwritten:
+			fmt.Printf("Replaced %s account '%s'\n", name, acc.Account)
+		default:
+			fmt.Printf("Imported %s account '%s'\n", name, acc.Account)
+		}
+	}
+}
+
+// bundlePassphrase returns the bundle passphrase from the environment or the terminal,
+// asking for it twice when it is chosen for a new bundle
+func bundlePassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv(BundlePassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	passphrase, err := credentials.PromptPassphrase("Bundle passphrase: ")
+	if errors.Is(err, credentials.ErrNoTerminal) {
+		return "", fmt.Errorf("%w; set %s", err, BundlePassphraseEnv)
+	}
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("passphrase must not be empty")
+	}
+	if confirm {
+		again, err := credentials.PromptPassphrase("Confirm passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
@@ -3,20 +3,32 @@ package setup
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/denysvitali/llm-usage/internal/config"
 	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/provider/claude"
 )
 
 const (
-	providerClaude  = "claude"
-	providerKimi    = "kimi"
-	providerZAi     = "zai"
-	providerMiniMax = "minimax"
+	providerClaude       = "claude"
+	providerKimi         = "kimi"
+	providerZAi          = "zai"
+	providerMiniMax      = "minimax"
+	providerOpenAI       = "openai"
+	providerAnthropicAPI = "anthropic-api"
+	providerOpenRouter   = "openrouter"
 )
 
+// loginTimeout limits how long the Claude login waits for the browser
+const loginTimeout = 10 * time.Minute
+
 // Wizard runs an interactive setup wizard for first-time users
 func Wizard(mgr *credentials.Manager) error {
 	fmt.Println("Welcome to llm-usage setup!")
@@ -31,12 +43,15 @@ func Wizard(mgr *credentials.Manager) error {
 		{providerKimi, "Kimi"},
 		{providerZAi, "Z.AI"},
 		{providerMiniMax, "MiniMax"},
+		{providerOpenAI, "OpenAI"},
+		{providerAnthropicAPI, "Anthropic API"},
+		{providerOpenRouter, "OpenRouter"},
 	}
 
 	for _, p := range providers {
 		fmt.Printf("\nWould you like to set up %s? [y/N]: ", p.name)
 		if confirm() {
-			if err := AddAccount(mgr, p.id, ""); err != nil {
+			if err := AddAccount(mgr, p.id, "", AddOptions{}); err != nil {
 				fmt.Fprintf(os.Stderr, "Error setting up %s: %v\n", p.name, err)
 			}
 		}
@@ -46,66 +61,171 @@ func Wizard(mgr *credentials.Manager) error {
 	return nil
 }
 
-// AddAccount adds a new account for a provider
-func AddAccount(mgr *credentials.Manager, providerID, accountName string) error {
+// AddAccount adds a new account for a provider, prompting for its credentials
+func AddAccount(mgr *credentials.Manager, providerID, accountName string, opts AddOptions) error {
 	// Validate provider
 	switch providerID {
 	case providerClaude:
-		return addClaudeAccount(mgr, accountName)
+		return addClaudeAccount(mgr, accountName, opts)
 	case providerKimi:
-		return addAPIKeyAccount(mgr, providerKimi, "Kimi", accountName)
+		return addAPIKeyAccount(mgr, providerKimi, "Kimi", accountName, opts)
 	case providerZAi:
-		return addAPIKeyAccount(mgr, providerZAi, "Z.AI", accountName)
+		return addAPIKeyAccount(mgr, providerZAi, "Z.AI", accountName, opts)
 	case providerMiniMax:
-		return addMiniMaxAccount(mgr, accountName)
+		return addMiniMaxAccount(mgr, accountName, opts)
+	case providerOpenAI:
+		return addOpenAIAccount(mgr, accountName, opts)
+	case providerAnthropicAPI:
+		return addAnthropicAPIAccount(mgr, accountName, opts)
+	case providerOpenRouter:
+		return addAPIKeyAccount(mgr, providerOpenRouter, "OpenRouter", accountName, opts)
 	default:
+		// Providers defined in the configuration file authenticate with an API key
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if pc := cfg.Provider(providerID); pc != nil {
+			return addAPIKeyAccount(mgr, providerID, pc.DisplayName(), accountName, opts)
+		}
 		return fmt.Errorf("unknown provider: %s", providerID)
 	}
 }
 
-// addClaudeAccount adds a Claude account
-func addClaudeAccount(mgr *credentials.Manager, _ string) error {
+// addClaudeAccount logs in to a Claude account with OAuth and saves its tokens
+func addClaudeAccount(mgr *credentials.Manager, accountName string, opts AddOptions) error {
 	fmt.Println("\nClaude (Anthropic) Setup")
 	fmt.Println("========================")
 	fmt.Println()
-	fmt.Println("Claude uses OAuth authentication which requires a browser flow.")
-	fmt.Println("Please follow these steps:")
-	fmt.Println()
-	fmt.Println("1. Ensure you have the Claude CLI installed and authenticated:")
-	fmt.Println("   npm install -g @anthropic-ai/claude-cli")
-	fmt.Println("   claude login")
-	fmt.Println()
-	fmt.Println("2. Run the migration command to copy your credentials:")
-	fmt.Println("   llm-usage setup migrate-claude")
-	fmt.Println()
-	fmt.Println("Or manually copy ~/.claude/.credentials.json to $XDG_CONFIG_HOME/llm-usage/claude.json")
+	fmt.Println("Log in with your Claude Pro/Max account in the browser.")
+	fmt.Println("Claude CLI logins are picked up automatically; see 'llm-usage setup list'.")
 	fmt.Println()
 
-	// Check if they want to migrate now
-	fmt.Print("Would you like to migrate Claude CLI credentials now? [y/N]: ")
-	if confirm() {
-		if err := mgr.MigrateFromClaudeCLI(); err != nil {
-			return fmt.Errorf("migration failed: %w", err)
+	// Get account name if not provided
+	if accountName == "" {
+		fmt.Print("Enter account name (default): ")
+		accountName = readLine()
+		if accountName == "" {
+			accountName = "default"
 		}
-		fmt.Println("Successfully migrated Claude credentials!")
+	}
+	if err := credentials.ValidateAccountName(accountName); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	oauth := claude.OAuthConfig{
+		ClientID:     cfg.ClaudeOAuth.ClientID,
+		AuthorizeURL: cfg.ClaudeOAuth.AuthorizeURL,
+		TokenURL:     cfg.ClaudeOAuth.TokenURL,
 	}
 
+	token, err := loginClaude(oauth)
+	if err != nil {
+		return err
+	}
+	save := saveOptions{accountName: accountName, force: true, label: opts.Label, tags: opts.Tags}
+	if opts.Validate {
+		save.validate = func(account any) error {
+			return validateAccount(providerClaude, accountName, account, cfg)
+		}
+	}
+	if err := saveClaudeCredentials(mgr, token, save); err != nil {
+		return err
+	}
+
+	fmt.Printf("Successfully added Claude account '%s'!\n", accountName)
 	return nil
 }
 
-// addAPIKeyAccount adds an account for API key-based providers (Kimi, Z.AI)
-func addAPIKeyAccount(mgr *credentials.Manager, providerID, displayName, accountName string) error {
+// loginClaude runs the OAuth authorization code flow with PKCE. The code is received by a
+// loopback callback server, or pasted when the browser runs on another machine.
+func loginClaude(oauth claude.OAuthConfig) (*claude.Token, error) {
+	pkce, err := claude.NewPKCE()
+	if err != nil {
+		return nil, err
+	}
+	callback, err := claude.ListenCallback(pkce.State)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = callback.Close() }()
+
+	authURL := oauth.AuthCodeURL(pkce, callback.RedirectURI)
+	fmt.Println("Open this URL to log in:")
+	fmt.Println()
+	fmt.Println("  " + authURL)
+	fmt.Println()
+	if err := openBrowser(authURL); err == nil {
+		fmt.Println("Your browser has been opened.")
+	}
+	fmt.Println("If the browser runs on another machine, paste the URL it was redirected to here.")
+	fmt.Print("Waiting for login... ")
+
+	ctx, cancel := context.WithTimeout(context.Background(), loginTimeout)
+	defer cancel()
+
+	type callbackResult struct {
+		code string
+		err  error
+	}
+	received := make(chan callbackResult, 1)
+	go func() {
+		code, err := callback.Wait(ctx)
+		received <- callbackResult{code, err}
+	}()
+	pasted := make(chan string, 1)
+	go func() { pasted <- readLine() }()
+
+	var code string
+	select {
+	case r := <-received:
+		if r.err != nil {
+			fmt.Println()
+			return nil, fmt.Errorf("login failed: %w", r.err)
+		}
+		code = r.code
+		fmt.Print("\nLogin received. Press Enter to continue.")
+		<-pasted
+	case code = <-pasted:
+		if code == "" {
+			return nil, fmt.Errorf("no authorization code entered")
+		}
+	}
+
+	return oauth.Exchange(context.Background(), code, pkce, callback.RedirectURI)
+}
+
+// saveClaudeCredentials saves Claude OAuth tokens as a named account
+func saveClaudeCredentials(mgr *credentials.Manager, token *claude.Token, opts saveOptions) error {
+	var creds credentials.ClaudeCredentials
+	account := &credentials.ClaudeAccount{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		ExpiresAt:    token.ExpiresAt(time.Now()),
+		Scopes:       token.Scopes(),
+	}
+	// Logging in again keeps the account's settings
+	_, err := saveCredentials(mgr, providerClaude, &creds, &creds.AccountSet, account,
+		func(account, existing *credentials.ClaudeAccount) {
+			account.BaseURL = existing.BaseURL
+			account.SecretStore = existing.SecretStore
+		}, opts)
+	return err
+}
+
+// addAPIKeyAccount adds an account for API key-based providers (Kimi, Z.AI, configured providers)
+func addAPIKeyAccount(mgr *credentials.Manager, providerID, displayName, accountName string, opts AddOptions) error {
 	fmt.Printf("\n%s Setup\n", displayName)
 	fmt.Println(strings.Repeat("=", len(displayName)+6))
 	fmt.Println()
 
-	// Get account name if not provided
-	if accountName == "" {
-		fmt.Print("Enter account name (default): ")
-		accountName = readLine()
-		if accountName == "" {
-			accountName = "default"
-		}
+	accountName, opts, err := promptAccountName(mgr, providerID, accountName, opts)
+	if err != nil {
+		return err
 	}
 
 	// Get API key
@@ -115,24 +235,20 @@ func addAPIKeyAccount(mgr *credentials.Manager, providerID, displayName, account
 		return fmt.Errorf("API key is required")
 	}
 
-	return saveAPIKeyCredentials(mgr, providerID, accountName, apiKey)
+	return saveAndReport(mgr, providerID, displayName, accountName, Credentials{APIKey: apiKey}, opts)
 }
 
 // addMiniMaxAccount adds a MiniMax account
-func addMiniMaxAccount(mgr *credentials.Manager, accountName string) error {
+func addMiniMaxAccount(mgr *credentials.Manager, accountName string, opts AddOptions) error {
 	fmt.Println("\nMiniMax Setup")
 	fmt.Println("=============")
 	fmt.Println()
 	fmt.Println("MiniMax uses cookie-based authentication.")
 	fmt.Println()
 
-	// Get account name if not provided
-	if accountName == "" {
-		fmt.Print("Enter account name (default): ")
-		accountName = readLine()
-		if accountName == "" {
-			accountName = "default"
-		}
+	accountName, opts, err := promptAccountName(mgr, providerMiniMax, accountName, opts)
+	if err != nil {
+		return err
 	}
 
 	// Get Group ID
@@ -149,101 +265,82 @@ func addMiniMaxAccount(mgr *credentials.Manager, accountName string) error {
 		return fmt.Errorf("cookie is required")
 	}
 
-	return saveMiniMaxCredentials(mgr, accountName, cookie, groupID)
+	return saveAndReport(mgr, providerMiniMax, "MiniMax", accountName, Credentials{Cookie: cookie, GroupID: groupID}, opts)
 }
 
-// saveAPIKeyCredentials saves credentials for API key-based providers
-func saveAPIKeyCredentials(mgr *credentials.Manager, providerID, accountName, apiKey string) error {
-	switch providerID {
-	case providerKimi:
-		return saveKimiCredentials(mgr, accountName, apiKey)
-	case providerZAi:
-		return saveZAiCredentials(mgr, accountName, apiKey)
-	default:
-		return fmt.Errorf("unsupported provider: %s", providerID)
-	}
-}
+// addOpenAIAccount adds an OpenAI account
+func addOpenAIAccount(mgr *credentials.Manager, accountName string, opts AddOptions) error {
+	fmt.Println("\nOpenAI Setup")
+	fmt.Println("============")
+	fmt.Println()
+	fmt.Println("OpenAI usage and costs require an organization admin API key.")
+	fmt.Println("Create one at https://platform.openai.com/settings/organization/admin-keys")
+	fmt.Println()
 
-// saveKimiCredentials saves Kimi credentials
-func saveKimiCredentials(mgr *credentials.Manager, accountName, apiKey string) error {
-	var creds credentials.KimiCredentials
-	if mgr.ProviderExists(providerKimi) {
-		if err := mgr.LoadProvider(providerKimi, &creds); err != nil {
-			creds = credentials.KimiCredentials{}
-		}
+	accountName, opts, err := promptAccountName(mgr, providerOpenAI, accountName, opts)
+	if err != nil {
+		return err
 	}
 
-	if creds.Accounts == nil {
-		creds.Accounts = make(map[string]*credentials.KimiAccount)
-		if creds.APIKey != "" {
-			creds.Accounts["default"] = &credentials.KimiAccount{APIKey: creds.APIKey}
-			creds.APIKey = ""
-		}
+	// Get admin key
+	fmt.Print("Enter your OpenAI admin API key: ")
+	adminKey := readLine()
+	if adminKey == "" {
+		return fmt.Errorf("admin API key is required")
 	}
 
-	creds.Accounts[accountName] = &credentials.KimiAccount{APIKey: apiKey}
-
-	if err := mgr.SaveProvider(providerKimi, creds); err != nil {
-		return fmt.Errorf("failed to save credentials: %w", err)
+	// Get optional budget
+	fmt.Print("Enter a monthly budget in USD (optional): ")
+	budget, err := parseOptionalAmount(readLine())
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Successfully added Kimi account '%s'!\n", accountName)
-	return nil
+	return saveAndReport(mgr, providerOpenAI, "OpenAI", accountName, Credentials{APIKey: adminKey, MonthlyBudget: budget}, opts)
 }
 
-// saveZAiCredentials saves Z.AI credentials
-func saveZAiCredentials(mgr *credentials.Manager, accountName, apiKey string) error {
-	var creds credentials.ZAiCredentials
-	if mgr.ProviderExists(providerZAi) {
-		if err := mgr.LoadProvider(providerZAi, &creds); err != nil {
-			creds = credentials.ZAiCredentials{}
-		}
-	}
+// addAnthropicAPIAccount adds an Anthropic Admin API account
+func addAnthropicAPIAccount(mgr *credentials.Manager, accountName string, opts AddOptions) error {
+	fmt.Println("\nAnthropic API Setup")
+	fmt.Println("===================")
+	fmt.Println()
+	fmt.Println("Anthropic API usage and cost reports require an Admin API key (sk-ant-admin...).")
+	fmt.Println("Create one at https://console.anthropic.com/settings/admin-keys")
+	fmt.Println()
 
-	if creds.Accounts == nil {
-		creds.Accounts = make(map[string]*credentials.ZAiAccount)
-		if creds.APIKey != "" {
-			creds.Accounts["default"] = &credentials.ZAiAccount{APIKey: creds.APIKey}
-			creds.APIKey = ""
-		}
+	accountName, opts, err := promptAccountName(mgr, providerAnthropicAPI, accountName, opts)
+	if err != nil {
+		return err
 	}
 
-	creds.Accounts[accountName] = &credentials.ZAiAccount{APIKey: apiKey}
+	// Get admin key
+	fmt.Print("Enter your Anthropic Admin API key: ")
+	adminKey := readLine()
+	if adminKey == "" {
+		return fmt.Errorf("admin API key is required")
+	}
 
-	if err := mgr.SaveProvider(providerZAi, creds); err != nil {
-		return fmt.Errorf("failed to save credentials: %w", err)
+	// Get optional budget
+	fmt.Print("Enter a monthly budget in USD (optional): ")
+	budget, err := parseOptionalAmount(readLine())
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Successfully added Z.AI account '%s'!\n", accountName)
-	return nil
+	return saveAndReport(mgr, providerAnthropicAPI, "Anthropic API", accountName, Credentials{APIKey: adminKey, MonthlyBudget: budget}, opts)
 }
 
-// saveMiniMaxCredentials saves MiniMax credentials
-func saveMiniMaxCredentials(mgr *credentials.Manager, accountName, cookie, groupID string) error {
-	var creds credentials.MiniMaxCredentials
-	if mgr.ProviderExists(providerMiniMax) {
-		if err := mgr.LoadProvider(providerMiniMax, &creds); err != nil {
-			creds = credentials.MiniMaxCredentials{}
-		}
-	}
-
-	if creds.Accounts == nil {
-		creds.Accounts = make(map[string]*credentials.MiniMaxAccount)
-		if creds.Cookie != "" {
-			creds.Accounts["default"] = &credentials.MiniMaxAccount{Cookie: creds.Cookie, GroupID: creds.GroupID}
-			creds.Cookie = ""
-			creds.GroupID = ""
-		}
+// parseOptionalAmount parses an optional, non-negative dollar amount
+func parseOptionalAmount(s string) (*float64, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "$")
+	if s == "" {
+		return nil, nil
 	}
-
-	creds.Accounts[accountName] = &credentials.MiniMaxAccount{Cookie: cookie, GroupID: groupID}
-
-	if err := mgr.SaveProvider(providerMiniMax, creds); err != nil {
-		return fmt.Errorf("failed to save credentials: %w", err)
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return nil, fmt.Errorf("invalid amount: %q", s)
 	}
-
-	fmt.Printf("Successfully added MiniMax account '%s'!\n", accountName)
-	return nil
+	return &v, nil
 }
 
 // ListAccounts lists all configured accounts
@@ -278,12 +375,31 @@ func listProviderAccounts(mgr *credentials.Manager, providerID string) error {
 		return err
 	}
 
+	// Metadata and the default account come from the credentials file, if there is one
+	meta, err := mgr.ReadAccountsMeta(providerID)
+	if err != nil {
+		meta = &credentials.AccountSet[credentials.AccountMeta]{}
+	}
+
 	fmt.Printf("\n%s:\n", providerName(providerID))
 	if len(accounts) == 0 {
 		fmt.Println("  (no accounts configured)")
 	} else {
 		for _, acc := range accounts {
-			fmt.Printf("  - %s\n", acc)
+			details := mgr.AccountSource(providerID, acc)
+			if acc == meta.DefaultAccountName() {
+				details += ", default"
+			}
+			name := acc
+			if m := meta.Meta(acc); m != nil {
+				if m.Label != "" {
+					name = fmt.Sprintf("%s %q", acc, m.Label)
+				}
+				for _, tag := range m.Tags {
+					name += " #" + tag
+				}
+			}
+			fmt.Printf("  - %s (%s)\n", name, details)
 		}
 	}
 	return nil
@@ -294,103 +410,7 @@ func RemoveAccount(mgr *credentials.Manager, providerID, accountName string) err
 	if accountName == "" {
 		return fmt.Errorf("account name is required")
 	}
-
-	switch providerID {
-	case "claude":
-		return removeClaudeAccount(mgr, accountName)
-	case "kimi":
-		return removeKimiAccount(mgr, accountName)
-	case "zai":
-		return removeZaiAccount(mgr, accountName)
-	case "minimax":
-		return removeMiniMaxAccount(mgr, accountName)
-	default:
-		return fmt.Errorf("unknown provider: %s", providerID)
-	}
-}
-
-// removeClaudeAccount removes a Claude account
-func removeClaudeAccount(mgr *credentials.Manager, accountName string) error {
-	var creds credentials.ClaudeCredentials
-	if err := mgr.LoadProvider("claude", &creds); err != nil {
-		return err
-	}
-
-	if creds.Accounts == nil || creds.Accounts[accountName] == nil {
-		return fmt.Errorf("account '%s' not found", accountName)
-	}
-
-	delete(creds.Accounts, accountName)
-
-	// If no accounts left, delete the file
-	if len(creds.Accounts) == 0 {
-		return mgr.DeleteProvider("claude")
-	}
-
-	return mgr.SaveProvider("claude", creds)
-}
-
-// removeKimiAccount removes a Kimi account
-func removeKimiAccount(mgr *credentials.Manager, accountName string) error {
-	var creds credentials.KimiCredentials
-	if err := mgr.LoadProvider("kimi", &creds); err != nil {
-		return err
-	}
-
-	if creds.Accounts == nil || creds.Accounts[accountName] == nil {
-		return fmt.Errorf("account '%s' not found", accountName)
-	}
-
-	delete(creds.Accounts, accountName)
-
-	// If no accounts left, delete the file
-	if len(creds.Accounts) == 0 {
-		return mgr.DeleteProvider("kimi")
-	}
-
-	return mgr.SaveProvider("kimi", creds)
-}
-
-// removeZaiAccount removes a Z.AI account
-func removeZaiAccount(mgr *credentials.Manager, accountName string) error {
-	var creds credentials.ZAiCredentials
-	if err := mgr.LoadProvider("zai", &creds); err != nil {
-		return err
-	}
-
-	if creds.Accounts == nil || creds.Accounts[accountName] == nil {
-		return fmt.Errorf("account '%s' not found", accountName)
-	}
-
-	delete(creds.Accounts, accountName)
-
-	// If no accounts left, delete the file
-	if len(creds.Accounts) == 0 {
-		return mgr.DeleteProvider("zai")
-	}
-
-	return mgr.SaveProvider("zai", creds)
-}
-
-// removeMiniMaxAccount removes a MiniMax account
-func removeMiniMaxAccount(mgr *credentials.Manager, accountName string) error {
-	var creds credentials.MiniMaxCredentials
-	if err := mgr.LoadProvider("minimax", &creds); err != nil {
-		return err
-	}
-
-	if creds.Accounts == nil || creds.Accounts[accountName] == nil {
-		return fmt.Errorf("account '%s' not found", accountName)
-	}
-
-	delete(creds.Accounts, accountName)
-
-	// If no accounts left, delete the file
-	if len(creds.Accounts) == 0 {
-		return mgr.DeleteProvider("minimax")
-	}
-
-	return mgr.SaveProvider("minimax", creds)
+	return mgr.RemoveAccount(providerID, accountName)
 }
 
 // RenameAccount renames an account for a provider
@@ -398,112 +418,77 @@ func RenameAccount(mgr *credentials.Manager, providerID, oldName, newName string
 	if oldName == "" || newName == "" {
 		return fmt.Errorf("both old and new account names are required")
 	}
-
-	switch providerID {
-	case "claude":
-		return renameClaudeAccount(mgr, oldName, newName)
-	case "kimi":
-		return renameKimiAccount(mgr, oldName, newName)
-	case "zai":
-		return renameZaiAccount(mgr, oldName, newName)
-	case "minimax":
-		return renameMiniMaxAccount(mgr, oldName, newName)
-	default:
-		return fmt.Errorf("unknown provider: %s", providerID)
-	}
+	return mgr.RenameAccount(providerID, oldName, newName)
 }
 
-// renameClaudeAccount renames a Claude account
-func renameClaudeAccount(mgr *credentials.Manager, oldName, newName string) error {
-	var creds credentials.ClaudeCredentials
-	if err := mgr.LoadProvider("claude", &creds); err != nil {
+// SetDefaultAccount sets the account a provider uses when none is named
+func SetDefaultAccount(mgr *credentials.Manager, providerID, accountName string) error {
+	if err := mgr.SetDefaultAccount(providerID, accountName); err != nil {
 		return err
 	}
-
-	if creds.Accounts == nil || creds.Accounts[oldName] == nil {
-		return fmt.Errorf("account '%s' not found", oldName)
-	}
-
-	if creds.Accounts[newName] != nil {
-		return fmt.Errorf("account '%s' already exists", newName)
-	}
-
-	creds.Accounts[newName] = creds.Accounts[oldName]
-	delete(creds.Accounts, oldName)
-
-	return mgr.SaveProvider("claude", creds)
+	fmt.Printf("%s now uses account '%s' by default\n", providerName(providerID), accountName)
+	return nil
 }
 
-// renameKimiAccount renames a Kimi account
-func renameKimiAccount(mgr *credentials.Manager, oldName, newName string) error {
-	var creds credentials.KimiCredentials
-	if err := mgr.LoadProvider("kimi", &creds); err != nil {
+// SetAccountTags replaces the tags of an account; no tags removes them
+func SetAccountTags(mgr *credentials.Manager, providerID, accountName string, tags []string) error {
+	if err := mgr.SetAccountTags(providerID, accountName, tags); err != nil {
 		return err
 	}
-
-	if creds.Accounts == nil || creds.Accounts[oldName] == nil {
-		return fmt.Errorf("account '%s' not found", oldName)
-	}
-
-	if creds.Accounts[newName] != nil {
-		return fmt.Errorf("account '%s' already exists", newName)
+	if len(tags) == 0 {
+		fmt.Printf("Removed the tags of %s account '%s'\n", providerName(providerID), accountName)
+	} else {
+		fmt.Printf("Tagged %s account '%s' with %s\n", providerName(providerID), accountName, strings.Join(tags, ", "))
 	}
-
-	creds.Accounts[newName] = creds.Accounts[oldName]
-	delete(creds.Accounts, oldName)
-
-	return mgr.SaveProvider("kimi", creds)
+	return nil
 }
 
-// renameZaiAccount renames a Z.AI account
-func renameZaiAccount(mgr *credentials.Manager, oldName, newName string) error {
-	var creds credentials.ZAiCredentials
-	if err := mgr.LoadProvider("zai", &creds); err != nil {
+// MigrateClaudeCLI migrates credentials from the Claude CLI
+func MigrateClaudeCLI(mgr *credentials.Manager) error {
+	if err := mgr.MigrateFromClaudeCLI(); err != nil {
 		return err
 	}
-
-	if creds.Accounts == nil || creds.Accounts[oldName] == nil {
-		return fmt.Errorf("account '%s' not found", oldName)
-	}
-
-	if creds.Accounts[newName] != nil {
-		return fmt.Errorf("account '%s' already exists", newName)
-	}
-
-	creds.Accounts[newName] = creds.Accounts[oldName]
-	delete(creds.Accounts, oldName)
-
-	return mgr.SaveProvider("zai", creds)
+	fmt.Println("Successfully migrated Claude CLI credentials!")
+	fmt.Printf("Credentials saved to: %s/claude.json\n", mgr.ConfigDir())
+	return nil
 }
 
-// renameMiniMaxAccount renames a MiniMax account
-func renameMiniMaxAccount(mgr *credentials.Manager, oldName, newName string) error {
-	var creds credentials.MiniMaxCredentials
-	if err := mgr.LoadProvider("minimax", &creds); err != nil {
-		return err
+// MigrateSecrets moves accounts to a secret store. Without a provider, the accounts of all
+// providers with a credentials file are migrated.
+func MigrateSecrets(mgr *credentials.Manager, storeName, providerID, accountName string) error {
+	if !slices.Contains(credentials.SecretStores, storeName) {
+		return fmt.Errorf("unknown secret store %q (valid: %s)", storeName, strings.Join(credentials.SecretStores, ", "))
 	}
 
-	if creds.Accounts == nil || creds.Accounts[oldName] == nil {
-		return fmt.Errorf("account '%s' not found", oldName)
+	providers := []string{providerID}
+	if providerID == "" {
+		providers = nil
+		for _, pid := range mgr.ListAvailable() {
+			if mgr.ProviderExists(pid) {
+				providers = append(providers, pid)
+			}
+		}
 	}
 
-	if creds.Accounts[newName] != nil {
-		return fmt.Errorf("account '%s' already exists", newName)
+	total := 0
+	for _, pid := range providers {
+		migrated, err := mgr.MigrateSecrets(pid, accountName, storeName)
+		if err != nil {
+			// Errors of a provider given on the command line already name it
+			if providerID == "" {
+				err = fmt.Errorf("%s: %w", pid, err)
+			}
+			return err
+		}
+		for _, acc := range migrated {
+			fmt.Printf("Moved %s account '%s' to %s\n", providerName(pid), acc, storeName)
+		}
+		total += len(migrated)
 	}
 
-	creds.Accounts[newName] = creds.Accounts[oldName]
-	delete(creds.Accounts, oldName)
-
-	return mgr.SaveProvider("minimax", creds)
-}
-
-// MigrateClaudeCLI migrates credentials from the Claude CLI
-func MigrateClaudeCLI(mgr *credentials.Manager) error {
-	if err := mgr.MigrateFromClaudeCLI(); err != nil {
-		return err
+	if total == 0 {
+		fmt.Printf("All accounts already use %s.\n", storeName)
 	}
-	fmt.Println("Successfully migrated Claude CLI credentials!")
-	fmt.Printf("Credentials saved to: %s/claude.json\n", mgr.ConfigDir())
 	return nil
 }
 
@@ -518,6 +503,12 @@ func providerName(id string) string {
 		return "Z.AI"
 	case providerMiniMax:
 		return "MiniMax"
+	case providerOpenAI:
+		return "OpenAI"
+	case providerAnthropicAPI:
+		return "Anthropic API"
+	case providerOpenRouter:
+		return "OpenRouter"
 	default:
 		return strings.ToUpper(id)
 	}
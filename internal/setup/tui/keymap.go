@@ -2,13 +2,12 @@
 package tui
 
 const (
-	keyDown        = "down"
-	keyEnter       = "enter"
-	keyUp          = "up"
-	keyEsc         = "esc"
-	keyLeft        = "left"
-	keyRight       = "right"
-	accountDefault = "default"
+	keyDown  = "down"
+	keyEnter = "enter"
+	keyUp    = "up"
+	keyEsc   = "esc"
+	keyLeft  = "left"
+	keyRight = "right"
 )
 
 // KeyMap defines key bindings for the TUI
@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/denysvitali/llm-usage/internal/credentials"
 )
 
 // updateRemoveProviderSelect handles updates for the provider selection (remove) screen
@@ -173,116 +172,7 @@ func (m Model) updateRemoveConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // doRemoveAccount performs the actual account removal
 func (m Model) doRemoveAccount() (tea.Model, tea.Cmd) {
-	var err error
-
-	switch m.selectedProvider {
-	case "claude":
-		var creds credentials.ClaudeCredentials
-		if loadErr := m.credsMgr.LoadProvider("claude", &creds); loadErr != nil {
-			err = loadErr
-		} else {
-			// Handle legacy format (single ClaudeAiOauth field)
-			if creds.Accounts == nil {
-				if creds.ClaudeAiOauth != nil && m.selectedAccount == accountDefault {
-					// Delete the entire provider file for legacy format
-					err = m.credsMgr.DeleteProvider("claude")
-				} else {
-					err = fmt.Errorf("account '%s' not found", m.selectedAccount)
-				}
-			} else {
-				if creds.Accounts[m.selectedAccount] == nil {
-					err = fmt.Errorf("account '%s' not found", m.selectedAccount)
-				} else {
-					delete(creds.Accounts, m.selectedAccount)
-					if len(creds.Accounts) == 0 {
-						err = m.credsMgr.DeleteProvider("claude")
-					} else {
-						err = m.credsMgr.SaveProvider("claude", creds)
-					}
-				}
-			}
-		}
-	case "kimi":
-		var creds credentials.KimiCredentials
-		if loadErr := m.credsMgr.LoadProvider("kimi", &creds); loadErr != nil {
-			err = loadErr
-		} else {
-			// Handle legacy format (single APIKey field)
-			if creds.Accounts == nil {
-				if creds.APIKey != "" && m.selectedAccount == accountDefault {
-					// Delete the entire provider file for legacy format
-					err = m.credsMgr.DeleteProvider("kimi")
-				} else {
-					err = fmt.Errorf("account '%s' not found", m.selectedAccount)
-				}
-			} else {
-				if creds.Accounts[m.selectedAccount] == nil {
-					err = fmt.Errorf("account '%s' not found", m.selectedAccount)
-				} else {
-					delete(creds.Accounts, m.selectedAccount)
-					if len(creds.Accounts) == 0 {
-						err = m.credsMgr.DeleteProvider("kimi")
-					} else {
-						err = m.credsMgr.SaveProvider("kimi", creds)
-					}
-				}
-			}
-		}
-	case "zai":
-		var creds credentials.ZAiCredentials
-		if loadErr := m.credsMgr.LoadProvider("zai", &creds); loadErr != nil {
-			err = loadErr
-		} else {
-			// Handle legacy format (single APIKey field)
-			if creds.Accounts == nil {
-				if creds.APIKey != "" && m.selectedAccount == accountDefault {
-					// Delete the entire provider file for legacy format
-					err = m.credsMgr.DeleteProvider("zai")
-				} else {
-					err = fmt.Errorf("account '%s' not found", m.selectedAccount)
-				}
-			} else {
-				if creds.Accounts[m.selectedAccount] == nil {
-					err = fmt.Errorf("account '%s' not found", m.selectedAccount)
-				} else {
-					delete(creds.Accounts, m.selectedAccount)
-					if len(creds.Accounts) == 0 {
-						err = m.credsMgr.DeleteProvider("zai")
-					} else {
-						err = m.credsMgr.SaveProvider("zai", creds)
-					}
-				}
-			}
-		}
-	case "minimax":
-		var creds credentials.MiniMaxCredentials
-		if loadErr := m.credsMgr.LoadProvider("minimax", &creds); loadErr != nil {
-			err = loadErr
-		} else {
-			// Handle legacy format (single Cookie field)
-			if creds.Accounts == nil {
-				if creds.Cookie != "" && m.selectedAccount == accountDefault {
-					err = m.credsMgr.DeleteProvider("minimax")
-				} else {
-					err = fmt.Errorf("account '%s' not found", m.selectedAccount)
-				}
-			} else {
-				if creds.Accounts[m.selectedAccount] == nil {
-					err = fmt.Errorf("account '%s' not found", m.selectedAccount)
-				} else {
-					delete(creds.Accounts, m.selectedAccount)
-					if len(creds.Accounts) == 0 {
-						err = m.credsMgr.DeleteProvider("minimax")
-					} else {
-						err = m.credsMgr.SaveProvider("minimax", creds)
-					}
-				}
-			}
-		}
-	default:
-		err = fmt.Errorf("unsupported provider: %s", m.selectedProvider)
-	}
-
+	err := m.credsMgr.RemoveAccount(m.selectedProvider, m.selectedAccount)
 	if err != nil {
 		m.errorMsg = err.Error()
 		return m, nil
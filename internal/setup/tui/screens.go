@@ -7,6 +7,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/setup"
 )
 
 // updateProviderSelect handles updates for the provider selection screen
@@ -73,6 +74,10 @@ func (m Model) updateAddAccountName(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if accountName == "" {
 			accountName = "default"
 		}
+		if err := credentials.ValidateAccountName(accountName); err != nil {
+			m.errorMsg = err.Error()
+			return m, nil
+		}
 		// Check if account already exists
 		if err := m.checkAccountExists(accountName); err != nil {
 			m.errorMsg = err.Error()
@@ -180,41 +185,20 @@ func (m Model) updateAddAPIKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // saveAccount saves the account credentials
 func (m Model) saveAccount() (tea.Model, tea.Cmd) {
-	accountName := m.accountName
-	apiKey := m.inputText
-	var err error
-
-	switch m.selectedProvider {
-	case "kimi":
-		var creds credentials.KimiCredentials
-		if m.credsMgr.ProviderExists("kimi") {
-			_ = m.credsMgr.LoadProvider("kimi", &creds)
-		}
-		if creds.Accounts == nil {
-			creds.Accounts = make(map[string]*credentials.KimiAccount)
-		}
-		creds.Accounts[accountName] = &credentials.KimiAccount{APIKey: apiKey}
-		err = m.credsMgr.SaveProvider("kimi", creds)
-	case "zai":
-		var creds credentials.ZAiCredentials
-		if m.credsMgr.ProviderExists("zai") {
-			_ = m.credsMgr.LoadProvider("zai", &creds)
-		}
-		if creds.Accounts == nil {
-			creds.Accounts = make(map[string]*credentials.ZAiAccount)
-		}
-		creds.Accounts[accountName] = &credentials.ZAiAccount{APIKey: apiKey}
-		err = m.credsMgr.SaveProvider("zai", creds)
-	default:
-		err = fmt.Errorf("unsupported provider: %s", m.selectedProvider)
+	// Claude logs in with OAuth and MiniMax needs a cookie, which this screen does not ask for
+	if m.selectedProvider == "claude" || m.selectedProvider == "minimax" {
+		m.errorMsg = fmt.Sprintf("unsupported provider: %s", m.selectedProvider)
+		return m, nil
 	}
 
+	// The account name screen refuses existing accounts
+	result, err := setup.SaveAccount(m.credsMgr, m.selectedProvider, m.accountName, setup.Credentials{APIKey: m.inputText}, setup.AddOptions{})
 	if err != nil {
 		m.errorMsg = err.Error()
 		return m, nil
 	}
 
-	m.successMsg = fmt.Sprintf("Successfully added %s account '%s'", m.selectedProvider, accountName)
+	m.successMsg = fmt.Sprintf("Successfully added %s account '%s'", result.Provider, result.Account)
 	m.screen = screenSuccess
 	return m, nil
 }
@@ -233,7 +217,13 @@ func (m Model) viewAddAPIKey() string {
 
 	b.WriteString(titleStyle.Render(fmt.Sprintf("Add %s Account", providerName)))
 	b.WriteString("\n\n")
-	b.WriteString(normalStyle.Render("Enter your API key"))
+	if m.selectedProvider == "openai" || m.selectedProvider == "anthropic-api" {
+		b.WriteString(normalStyle.Render("Enter your organization admin API key"))
+		b.WriteString("\n\n")
+		b.WriteString(dimStyle.Render("(Set a monthly budget with: llm-usage setup add " + m.selectedProvider + ")"))
+	} else {
+		b.WriteString(normalStyle.Render("Enter your API key"))
+	}
 	b.WriteString("\n\n")
 
 	cursor := cursorStyle.Render("▶")
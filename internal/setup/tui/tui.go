@@ -18,9 +18,12 @@ type Provider struct {
 
 // AllProviders contains all providers that can be configured.
 var AllProviders = []Provider{
+	{ID: "anthropic-api", Name: "Anthropic API"},
 	{ID: "claude", Name: "Claude (Anthropic)"},
 	{ID: "kimi", Name: "Kimi"},
 	{ID: "minimax", Name: "MiniMax"},
+	{ID: "openai", Name: "OpenAI"},
+	{ID: "openrouter", Name: "OpenRouter"},
 	{ID: "zai", Name: "Z.AI"},
 }
 
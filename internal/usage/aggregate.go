@@ -0,0 +1,103 @@
+package usage
+
+import (
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// DefaultThreshold is the utilization at which an account no longer counts as available in
+// aggregates, the same as the critical class
+const DefaultThreshold = 90.0
+
+// Aggregate pools the usage of the accounts of each provider that has more than one, in the
+// order the providers appear in stats. Windows with the same label are combined: their
+// limits and amounts used are summed when every account reports them, and their
+// utilization is averaged otherwise.
+func Aggregate(stats *provider.UsageStats, threshold float64) []provider.Aggregate {
+	var order []string
+	groups := make(map[string][]*provider.Usage)
+	for i := range stats.Providers {
+		u := &stats.Providers[i]
+		if groups[u.Provider] == nil {
+			order = append(order, u.Provider)
+		}
+		groups[u.Provider] = append(groups[u.Provider], u)
+	}
+
+	var aggregates []provider.Aggregate
+	for _, id := range order {
+		accounts := groups[id]
+		if len(accounts) < 2 {
+			continue
+		}
+		agg := provider.Aggregate{Provider: id, Name: displayName(accounts[0]), Accounts: len(accounts)}
+
+		var labels []string
+		windows := make(map[string][]provider.UsageWindow)
+		for _, u := range accounts {
+			if u.Error != nil {
+				agg.Errors++
+				continue
+			}
+			for _, w := range u.Windows {
+				if windows[w.Label] == nil {
+					labels = append(labels, w.Label)
+				}
+				windows[w.Label] = append(windows[w.Label], w)
+			}
+		}
+		agg.Windows = make([]provider.AggregateWindow, 0, len(labels))
+		for _, label := range labels {
+			agg.Windows = append(agg.Windows, aggregateWindows(label, windows[label], threshold))
+		}
+		aggregates = append(aggregates, agg)
+	}
+	return aggregates
+}
+
+// aggregateWindows combines the windows with the same label of several accounts
+func aggregateWindows(label string, windows []provider.UsageWindow, threshold float64) provider.AggregateWindow {
+	agg := provider.AggregateWindow{
+		UsageWindow: provider.UsageWindow{Label: label},
+		Basis:       provider.BasisAbsolute,
+		Accounts:    len(windows),
+		Threshold:   threshold,
+	}
+
+	var limit, used, utilization float64
+	for _, w := range windows {
+		if w.Unbounded {
+			// Windows that only count usage pool their amounts, without a limit
+			agg.Unbounded = true
+			if w.Used != nil {
+				used += *w.Used
+			}
+		} else if w.Limit == nil || w.Used == nil {
+			agg.Basis = provider.BasisPercentage
+		} else {
+			limit += *w.Limit
+			used += *w.Used
+		}
+		utilization += w.Utilization
+		agg.MaxUtilization = max(agg.MaxUtilization, w.Utilization)
+		if w.Utilization < threshold {
+			agg.Available++
+		}
+		if w.ResetsAt != nil && (agg.ResetsAt == nil || w.ResetsAt.Before(*agg.ResetsAt)) {
+			agg.ResetsAt = w.ResetsAt
+		}
+	}
+
+	if agg.Unbounded {
+		agg.Basis = provider.BasisPercentage
+		agg.Used = &used
+		agg.Available = agg.Accounts
+	} else if agg.Basis == provider.BasisAbsolute && limit > 0 {
+		remaining := max(limit-used, 0)
+		agg.Limit, agg.Used, agg.Remaining = &limit, &used, &remaining
+		agg.Utilization = used / limit * 100
+	} else {
+		agg.Basis = provider.BasisPercentage
+		agg.Utilization = utilization / float64(len(windows))
+	}
+	return agg
+}
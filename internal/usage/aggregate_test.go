@@ -0,0 +1,54 @@
+package usage
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestAggregate(t *testing.T) {
+	soon := time.Now().Add(time.Hour)
+	later := time.Now().Add(48 * time.Hour)
+	stats := &provider.UsageStats{Providers: []provider.Usage{
+		{Provider: "kimi", Windows: []provider.UsageWindow{
+			{Label: "Weekly", Utilization: 20, Used: float(200), Limit: float(1000), ResetsAt: &later},
+			{Label: "5-Hour", Utilization: 95},
+		}},
+		{Provider: "kimi", Windows: []provider.UsageWindow{
+			{Label: "Weekly", Utilization: 90, Used: float(900), Limit: float(1000), ResetsAt: &soon},
+			{Label: "5-Hour", Utilization: 25},
+		}},
+		{Provider: "kimi", Error: errors.New("unauthorized")},
+		{Provider: "zai", Windows: []provider.UsageWindow{{Label: "Daily", Utilization: 50}}},
+	}}
+
+	aggregates := Aggregate(stats, DefaultThreshold)
+	if len(aggregates) != 1 {
+		t.Fatalf("Aggregate() = %+v, want only kimi, which has several accounts", aggregates)
+	}
+	kimi := aggregates[0]
+	if kimi.Provider != "kimi" || kimi.Accounts != 3 || kimi.Errors != 1 || len(kimi.Windows) != 2 {
+		t.Fatalf("kimi aggregate = %+v", kimi)
+	}
+
+	weekly := kimi.Windows[0]
+	if weekly.Label != "Weekly" || weekly.Basis != provider.BasisAbsolute || *weekly.Used != 1100 || *weekly.Limit != 2000 || *weekly.Remaining != 900 {
+		t.Errorf("weekly = %+v", weekly)
+	}
+	if math.Abs(weekly.Utilization-55) > 1e-9 || weekly.MaxUtilization != 90 || weekly.Available != 1 || weekly.Accounts != 2 {
+		t.Errorf("weekly utilization = %.1f, max %.1f, available %d of %d", weekly.Utilization, weekly.MaxUtilization, weekly.Available, weekly.Accounts)
+	}
+	if weekly.ResetsAt == nil || !weekly.ResetsAt.Equal(soon) {
+		t.Errorf("weekly resets at %v, want the earliest reset %v", weekly.ResetsAt, soon)
+	}
+
+	fiveHour := kimi.Windows[1]
+	if fiveHour.Basis != provider.BasisPercentage || fiveHour.Utilization != 60 || fiveHour.Used != nil || fiveHour.Available != 1 {
+		t.Errorf("5-hour = %+v", fiveHour)
+	}
+}
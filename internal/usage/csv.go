@@ -0,0 +1,72 @@
+package usage
+
+import (
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// csvHeader names the columns written by the CSV format
+var csvHeader = []string{"provider", "name", "account", "window", "utilization", "used", "limit", "remaining", "resets_at", "error"}
+
+// renderCSV writes one row per usage window, and one row without a window for results
+// that failed or have no windows
+func renderCSV(w io.Writer, stats *provider.UsageStats) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for i := range stats.Providers {
+		u := &stats.Providers[i]
+		prefix := []string{u.Provider, displayName(u), usageAccount(u)}
+		if u.Error != nil || len(u.Windows) == 0 {
+			row := append(prefix, "", "", "", "", "", "", usageError(u))
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, win := range u.Windows {
+			utilization := strconv.FormatFloat(win.Utilization, 'f', -1, 64)
+			if win.Unbounded {
+				utilization = ""
+			}
+			row := append(slices.Clone(prefix),
+				win.Label,
+				utilization,
+				csvAmount(win.Used),
+				csvAmount(win.Limit),
+				csvAmount(win.Remaining),
+				csvTime(win.ResetsAt),
+				"",
+			)
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvAmount formats an optional amount, empty when unknown
+func csvAmount(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+// csvTime formats an optional time as RFC 3339, empty when unknown
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
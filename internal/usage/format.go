@@ -0,0 +1,203 @@
+package usage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/config"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"go.yaml.in/yaml/v3"
+)
+
+// Output formats built into the registry
+const (
+	FormatPretty     = "pretty"
+	FormatJSON       = "json"
+	FormatWaybar     = "waybar"
+	FormatYAML       = "yaml"
+	FormatCSV        = "csv"
+	FormatPrometheus = "prometheus"
+	FormatTemplate   = "template"
+	FormatTable      = "table"
+
+	// Status bar formats
+	FormatPolybar  = "polybar"
+	FormatI3blocks = "i3blocks"
+	FormatI3status = "i3status-rust"
+	FormatTmux     = "tmux"
+	FormatXbar     = "xbar"
+	FormatSwiftBar = "swiftbar"
+)
+
+// statusBarFormats are the formats laid out with FormatOptions.StatusText
+var statusBarFormats = []string{FormatWaybar, FormatPolybar, FormatI3blocks, FormatI3status, FormatTmux, FormatXbar, FormatSwiftBar}
+
+// Renderer writes usage stats in an output format
+type Renderer interface {
+	Render(w io.Writer, stats *provider.UsageStats) error
+}
+
+// ErrorRenderer is implemented by renderers that report errors in their own format, such as
+// status bar modules that must always print a valid line
+type ErrorRenderer interface {
+	RenderError(w io.Writer, msg string) error
+}
+
+// RenderFunc adapts a function to the Renderer interface
+type RenderFunc func(w io.Writer, stats *provider.UsageStats) error
+
+// Render calls f
+func (f RenderFunc) Render(w io.Writer, stats *provider.UsageStats) error {
+	return f(w, stats)
+}
+
+// FormatOptions configure the renderers created by NewRenderer
+type FormatOptions struct {
+	// Template is the text/template source of the template format
+	Template string
+	// StatusText is a text/template laying out the text of the status bar formats, with
+	// the same data and functions as Template; empty shows the utilization of each provider
+	StatusText string
+	// Windows chooses the window shown by the status bar formats for each provider ID, with
+	// the empty ID for the others: WindowFirst (default), WindowTightest or a window label
+	Windows map[string]string
+	// Warning and Critical are the utilizations at which the status bar, pretty and table
+	// formats switch class; zero uses DefaultWarning and DefaultCritical
+	Warning, Critical float64
+	// Classes renames the waybar classes (normal, warning, critical and error)
+	Classes map[string]string
+	// Color is the color mode of the pretty and table formats: config.ColorAuto (default),
+	// config.ColorAlways or config.ColorNever
+	Color string
+	// Theme names the theme of the pretty and table formats, from Themes or the built-in
+	// ones; empty uses ThemeDefault
+	Theme string
+	// Themes holds user themes, as defined in the configuration file
+	Themes map[string]config.Theme
+	// Module shows a single provider in the status bar formats as its bare utilization, for
+	// a module per provider or account
+	Module bool
+}
+
+// formats maps the output format names to their renderer constructors
+var formats = map[string]func(opts FormatOptions) (Renderer, error){
+	FormatPretty:     newPrettyRenderer(false),
+	FormatTable:      newPrettyRenderer(true),
+	FormatJSON:       func(FormatOptions) (Renderer, error) { return RenderFunc(renderJSON), nil },
+	FormatYAML:       func(FormatOptions) (Renderer, error) { return RenderFunc(renderYAML), nil },
+	FormatCSV:        func(FormatOptions) (Renderer, error) { return RenderFunc(renderCSV), nil },
+	FormatPrometheus: func(FormatOptions) (Renderer, error) { return RenderFunc(renderPrometheus), nil },
+	FormatTemplate:   newTemplateRenderer,
+	FormatWaybar:     newStatusBarRenderer(func(b statusBar) Renderer { return waybarRenderer{b} }),
+	FormatPolybar:    newStatusBarRenderer(func(b statusBar) Renderer { return polybarRenderer{b} }),
+	FormatI3blocks:   newStatusBarRenderer(func(b statusBar) Renderer { return i3blocksRenderer{b} }),
+	FormatI3status:   newStatusBarRenderer(func(b statusBar) Renderer { return i3statusRenderer{b} }),
+	FormatTmux:       newStatusBarRenderer(func(b statusBar) Renderer { return tmuxRenderer{b} }),
+	FormatXbar:       newStatusBarRenderer(func(b statusBar) Renderer { return xbarRenderer{b} }),
+	FormatSwiftBar:   newStatusBarRenderer(func(b statusBar) Renderer { return xbarRenderer{b} }),
+}
+
+// newStatusBarRenderer returns the constructor of a status bar format's renderer
+func newStatusBarRenderer(newRenderer func(b statusBar) Renderer) func(opts FormatOptions) (Renderer, error) {
+	return func(opts FormatOptions) (Renderer, error) {
+		b, err := newStatusBar(opts)
+		if err != nil {
+			return nil, err
+		}
+		return newRenderer(b), nil
+	}
+}
+
+// RegisterFormat adds an output format, replacing any format with the same name
+func RegisterFormat(name string, newRenderer func(opts FormatOptions) (Renderer, error)) {
+	formats[name] = newRenderer
+}
+
+// Formats returns the names of the output formats in sorted order
+func Formats() []string {
+	return sortedKeys(formats)
+}
+
+// NewRenderer creates the renderer of an output format
+func NewRenderer(format string, opts FormatOptions) (Renderer, error) {
+	newRenderer, ok := formats[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q (valid: %s)", format, strings.Join(Formats(), ", "))
+	}
+	if opts.Template != "" && format != FormatTemplate {
+		return nil, fmt.Errorf("a template requires the %s format, not %s", FormatTemplate, format)
+	}
+	if (opts.StatusText != "" || len(opts.Windows) > 0) && !slices.Contains(statusBarFormats, format) {
+		return nil, fmt.Errorf("status text and windows only apply to the status bar formats (%s), not %s", strings.Join(statusBarFormats, ", "), format)
+	}
+	if len(opts.Classes) > 0 && format != FormatWaybar {
+		return nil, fmt.Errorf("classes only apply to the %s format, not %s", FormatWaybar, format)
+	}
+	return newRenderer(opts)
+}
+
+// renderYAML writes usage stats as YAML, with the same fields and order as the JSON format
+func renderYAML(w io.Writer, stats *provider.UsageStats) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	// JSON is YAML in flow style; decode it to a node tree to keep the field order
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// blockStyle clears the flow and quoting styles of decoded JSON, so that it is encoded as
+// block YAML; strings that would be read as another type stay quoted
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// usageError returns the message of a failed result, or an empty string
+func usageError(u *provider.Usage) string {
+	if u.Error == nil {
+		return ""
+	}
+	return u.Error.Error()
+}
+
+// usageAccount returns the account FetchAllUsage recorded for a result
+func usageAccount(u *provider.Usage) string {
+	account, _ := u.Extra["account"].(string)
+	return account
+}
+
+// errNoTemplate is returned for the template format without a template
+var errNoTemplate = errors.New("the template format requires a template")
+
+// ReportError writes err with renderers that report errors in their own format, such as
+// waybar, and returns nil; with other renderers it returns err
+func ReportError(r Renderer, w io.Writer, err error) error {
+	if er, ok := r.(ErrorRenderer); ok {
+		return er.RenderError(w, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package usage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/config"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func testStats() *provider.UsageStats {
+	resets := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &provider.UsageStats{Providers: []provider.Usage{
+		{
+			Provider: "kimi",
+			Name:     "Kimi",
+			Windows:  []provider.UsageWindow{{Label: "Weekly", Utilization: 42.5, Used: float(425), Limit: float(1000), ResetsAt: &resets}},
+			Extra:    map[string]any{"account": `work "eu"`},
+		},
+		{Provider: "zai", Name: "Z.AI", Error: errors.New("zai: unauthorized")},
+	}}
+}
+
+func render(t *testing.T, format string, opts FormatOptions) string {
+	t.Helper()
+	r, err := NewRenderer(format, opts)
+	if err != nil {
+		t.Fatalf("NewRenderer(%s) error = %v", format, err)
+	}
+	var buf bytes.Buffer
+	if err := r.Render(&buf, testStats()); err != nil {
+		t.Fatalf("Render(%s) error = %v", format, err)
+	}
+	return buf.String()
+}
+
+func TestRenderers(t *testing.T) {
+	for _, tt := range []struct {
+		format string
+		opts   FormatOptions
+		want   []string
+	}{
+		{FormatPretty, FormatOptions{}, []string{"Kimi (work \"eu\"):", "42.5%", "Error: zai: unauthorized"}},
+		{FormatJSON, FormatOptions{}, []string{`"provider": "kimi"`, `"utilization": 42.5`}},
+		{FormatWaybar, FormatOptions{}, []string{`"text":"K:42%"`, `"class":"normal"`}},
+		{FormatYAML, FormatOptions{}, []string{"providers:\n  - provider: kimi\n", "utilization: 42.5", "resets_at: \"2026-01-02T03:04:05Z\""}},
+		{FormatCSV, FormatOptions{}, []string{
+			"provider,name,account,window,utilization,used,limit,remaining,resets_at,error\n",
+			`kimi,Kimi,"work ""eu""",Weekly,42.5,425,1000,,2026-01-02T03:04:05Z,` + "\n",
+			"zai,Z.AI,,,,,,,,zai: unauthorized\n",
+		}},
+		{FormatPrometheus, FormatOptions{}, []string{
+			"# TYPE llm_usage_up gauge\n",
+			`llm_usage_up{provider="zai",account=""} 0`,
+			`llm_usage_utilization_percent{provider="kimi",account="work \"eu\"",window="Weekly"} 42.5`,
+			`llm_usage_resets_at_seconds{provider="kimi",account="work \"eu\"",window="Weekly"} 1767323045`,
+		}},
+		{FormatTemplate, FormatOptions{Template: `{{range .Providers}}{{.Provider}}{{range .Windows}} {{pct .Utilization}} {{pct .Remaining}} [{{bar .Utilization}}] {{color .Utilization .Label}}{{end}};{{end}}`}, []string{
+			"kimi 42.5% N/A [████████░░░░░░░░░░░░] Weekly;zai;",
+		}},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			got := render(t, tt.format, tt.opts)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("output does not contain %q:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplate_Color(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	const tmpl = `{{range .Providers}}{{range .Windows}}{{color .Utilization .Label}}{{end}}{{end}}`
+	if got := render(t, FormatTemplate, FormatOptions{Template: tmpl, Color: config.ColorNever}); got != "Weekly" {
+		t.Errorf("color never: output = %q, want Weekly", got)
+	}
+
+	opts := FormatOptions{Template: tmpl, Color: config.ColorAlways, Theme: ThemeColorblind, Warning: 40, Critical: 50}
+	want := classStyles(colorRenderer(io.Discard, config.ColorAlways), themes[ThemeColorblind])["warning"].Render("Weekly")
+	if got := render(t, FormatTemplate, opts); got != want {
+		t.Errorf("42.5%% with a 40%% warning threshold: output = %q, want %q", got, want)
+	}
+
+	if _, err := NewRenderer(FormatTemplate, FormatOptions{Template: tmpl, Theme: "missing"}); err == nil {
+		t.Error("NewRenderer() with an unknown theme succeeded")
+	}
+}
+
+func TestNewRenderer_Errors(t *testing.T) {
+	if _, err := NewRenderer("xml", FormatOptions{}); err == nil || !strings.Contains(err.Error(), "prometheus") {
+		t.Errorf("unknown format error = %v, want the valid formats", err)
+	}
+	if _, err := NewRenderer(FormatTemplate, FormatOptions{}); !errors.Is(err, errNoTemplate) {
+		t.Errorf("missing template error = %v", err)
+	}
+	if _, err := NewRenderer(FormatTemplate, FormatOptions{Template: "{{range}}"}); err == nil {
+		t.Error("expected error for an invalid template")
+	}
+	if _, err := NewRenderer(FormatJSON, FormatOptions{Template: "{{.}}"}); err == nil {
+		t.Error("expected error for a template with another format")
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	RegisterFormat("names", func(FormatOptions) (Renderer, error) {
+		return RenderFunc(func(w io.Writer, stats *provider.UsageStats) error {
+			for _, u := range stats.Providers {
+				fmt.Fprintln(w, u.Name)
+			}
+			return nil
+		}), nil
+	})
+	t.Cleanup(func() { delete(formats, "names") })
+
+	if !slices.Contains(Formats(), "names") {
+		t.Errorf("Formats() = %v, want the registered format", Formats())
+	}
+	if got := render(t, "names", FormatOptions{}); got != "Kimi\nZ.AI\n" {
+		t.Errorf("output = %q", got)
+	}
+}
+
+func TestReportError(t *testing.T) {
+	var buf bytes.Buffer
+	waybar, _ := NewRenderer(FormatWaybar, FormatOptions{})
+	if err := ReportError(waybar, &buf, errors.New("no providers")); err != nil || !strings.Contains(buf.String(), `"class":"error"`) {
+		t.Errorf("waybar ReportError() = %v, output %q", err, buf.String())
+	}
+	pretty, _ := NewRenderer(FormatPretty, FormatOptions{})
+	if err := ReportError(pretty, &buf, errors.New("no providers")); err == nil {
+		t.Error("pretty ReportError() should return the error")
+	}
+}
@@ -0,0 +1,564 @@
+package usage
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/denysvitali/llm-usage/internal/config"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+)
+
+const (
+	barWidth    = 20 // Progress bar width when the terminal width is unknown
+	minBarWidth = 5
+	maxBarWidth = 40
+	barFull     = "█"
+	barEmpty    = "░"
+)
+
+// Built-in themes of the pretty and table formats
+const (
+	ThemeDefault    = "default"
+	ThemeColorblind = "colorblind"
+)
+
+// themes are the built-in themes of the pretty and table formats
+var themes = map[string]config.Theme{
+	ThemeDefault: {Normal: "70", Warning: "214", Critical: "196", Title: "86", Accent: "75", Dim: "241"},
+	// Okabe-Ito colors, which stay apart with the common forms of color blindness
+	ThemeColorblind: {Normal: "#0072B2", Warning: "#E69F00", Critical: "#D55E00", Title: "#56B4E9", Accent: "#009E73", Dim: "241"},
+}
+
+// colorModes are the valid values of FormatOptions.Color
+var colorModes = []string{config.ColorAuto, config.ColorAlways, config.ColorNever}
+
+// prettyRenderer writes usage stats for people: a report per account, or a table with a row
+// per window
+type prettyRenderer struct {
+	thresholds
+	table bool
+	color string
+	theme config.Theme
+}
+
+// newPrettyRenderer returns the constructor of the pretty or table format's renderer
+func newPrettyRenderer(table bool) func(opts FormatOptions) (Renderer, error) {
+	return func(opts FormatOptions) (Renderer, error) {
+		color, theme, err := resolveColors(opts)
+		if err != nil {
+			return nil, err
+		}
+		t, err := newThresholds(opts)
+		if err != nil {
+			return nil, err
+		}
+		return prettyRenderer{thresholds: t, table: table, color: color, theme: theme}, nil
+	}
+}
+
+// resolveColors returns the color mode and theme of the formats that color their output
+func resolveColors(opts FormatOptions) (string, config.Theme, error) {
+	color := cmp.Or(opts.Color, config.ColorAuto)
+	if !slices.Contains(colorModes, color) {
+		return "", config.Theme{}, fmt.Errorf("invalid color %q (valid: %s)", color, strings.Join(colorModes, ", "))
+	}
+	theme, err := resolveTheme(opts.Theme, opts.Themes)
+	if err != nil {
+		return "", config.Theme{}, err
+	}
+	return color, theme, nil
+}
+
+// resolveTheme returns a user or built-in theme, with its empty colors taken from the default
+// theme; user themes take precedence
+func resolveTheme(name string, userThemes map[string]config.Theme) (config.Theme, error) {
+	name = cmp.Or(name, ThemeDefault)
+	theme, ok := userThemes[name]
+	if !ok {
+		if theme, ok = themes[name]; !ok {
+			names := append(sortedKeys(themes), sortedKeys(userThemes)...)
+			return config.Theme{}, fmt.Errorf("unknown theme %q (valid: %s)", name, strings.Join(names, ", "))
+		}
+	}
+
+	def := themes[ThemeDefault]
+	return config.Theme{
+		Normal:   cmp.Or(theme.Normal, def.Normal),
+		Warning:  cmp.Or(theme.Warning, def.Warning),
+		Critical: cmp.Or(theme.Critical, def.Critical),
+		Title:    cmp.Or(theme.Title, def.Title),
+		Accent:   cmp.Or(theme.Accent, def.Accent),
+		Dim:      cmp.Or(theme.Dim, def.Dim),
+	}, nil
+}
+
+func (r prettyRenderer) Render(w io.Writer, stats *provider.UsageStats) error {
+	p := r.printer(w)
+	if r.table {
+		p.printTable(stats)
+	} else {
+		p.printReport(stats)
+	}
+	p.printTagSummaries(stats.Tags)
+	return nil
+}
+
+// colorRenderer returns a lipgloss renderer for w: lipgloss detects whether it is a color
+// terminal and honours NO_COLOR, unless the color mode forces a choice
+func colorRenderer(w io.Writer, color string) *lipgloss.Renderer {
+	lg := lipgloss.NewRenderer(w)
+	switch color {
+	case config.ColorNever:
+		lg.SetColorProfile(termenv.Ascii)
+	case config.ColorAlways:
+		profile := termenv.NewOutput(w, termenv.WithTTY(true)).ColorProfile()
+		if profile == termenv.Ascii {
+			profile = termenv.ANSI256
+		}
+		lg.SetColorProfile(profile)
+	}
+	return lg
+}
+
+// classStyles returns the styles of a theme's utilization classes
+func classStyles(lg *lipgloss.Renderer, theme config.Theme) map[string]lipgloss.Style {
+	return map[string]lipgloss.Style{
+		"normal":   lg.NewStyle().Foreground(lipgloss.Color(theme.Normal)),
+		"warning":  lg.NewStyle().Foreground(lipgloss.Color(theme.Warning)),
+		"critical": lg.NewStyle().Foreground(lipgloss.Color(theme.Critical)),
+	}
+}
+
+// printer returns a printer styled for w
+func (r prettyRenderer) printer(w io.Writer) *prettyPrinter {
+	lg := colorRenderer(w, r.color)
+	p := &prettyPrinter{
+		thresholds: r.thresholds,
+		w:          w,
+		width:      terminalWidth(w),
+		barWidth:   barWidth,
+		title:      lg.NewStyle().Foreground(lipgloss.Color(r.theme.Title)).Bold(true),
+		accent:     lg.NewStyle().Foreground(lipgloss.Color(r.theme.Accent)),
+		dim:        lg.NewStyle().Foreground(lipgloss.Color(r.theme.Dim)),
+		classes:    classStyles(lg, r.theme),
+	}
+	if p.width > 0 {
+		p.barWidth = max(minBarWidth, min(p.width/4, maxBarWidth))
+	}
+	return p
+}
+
+// terminalWidth returns the width of the terminal w writes to, or of COLUMNS when w is not a
+// terminal; zero when unknown
+func terminalWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) { //nolint:gosec // File descriptors fit in an int
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 { //nolint:gosec // File descriptors fit in an int
+			return width
+		}
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return 0
+}
+
+// prettyPrinter writes the pretty and table formats with the styles of a theme
+type prettyPrinter struct {
+	thresholds
+	w        io.Writer
+	width    int // Terminal width, or zero when unknown
+	barWidth int
+
+	title, accent, dim lipgloss.Style
+	classes            map[string]lipgloss.Style // By utilization class
+}
+
+// style returns the style of a utilization
+func (p *prettyPrinter) style(util float64) lipgloss.Style {
+	return p.classes[p.class(util)]
+}
+
+// bar renders a progress bar colored after the utilization
+func (p *prettyPrinter) bar(util float64) string {
+	return p.style(util).Render(progressBar(util, p.barWidth))
+}
+
+// heading prints an underlined heading
+func (p *prettyPrinter) heading(text string) {
+	fmt.Fprintln(p.w, p.title.Render(text))
+	fmt.Fprintln(p.w, p.dim.Render(strings.Repeat("-", lipgloss.Width(text))))
+}
+
+// printReport prints a section per account
+func (p *prettyPrinter) printReport(stats *provider.UsageStats) {
+	fmt.Fprintln(p.w, p.title.Render("LLM Usage Statistics"))
+	fmt.Fprintln(p.w, p.dim.Render("===================="))
+	fmt.Fprintln(p.w)
+
+	for _, u := range stats.Providers {
+		if u.Error != nil {
+			fmt.Fprintln(p.w, p.title.Render(displayName(&u)+":"))
+			fmt.Fprintf(p.w, "  %s\n", p.classes["critical"].Render(fmt.Sprintf("Error: %s", u.Error)))
+			fmt.Fprintln(p.w)
+			continue
+		}
+
+		// Get account name if available
+		accountSuffix := ""
+		if acc, ok := u.Extra["account"]; ok && acc != "" {
+			accountSuffix = fmt.Sprintf(" (%s)", acc)
+		}
+		p.heading(displayName(&u) + accountSuffix + ":")
+
+		for _, win := range u.Windows {
+			p.printUsageWindow(win.Label, &win)
+		}
+
+		// Print extra usage if available (for Claude)
+		if extra, ok := u.Extra["extra_usage"]; ok {
+			p.printExtraUsageFromMap(extra)
+		}
+
+		// Print subscription info if available (for Kimi)
+		if sub, ok := u.Extra["subscription"]; ok {
+			p.printKimiSubscription(sub)
+		}
+
+		// Print key info if available (for OpenRouter)
+		if info, ok := u.Extra["key_info"]; ok {
+			p.printOpenRouterKeyInfo(info)
+		}
+
+		fmt.Fprintln(p.w)
+	}
+
+	p.printAggregates(stats.Aggregates)
+}
+
+// printAggregates prints the usage pooled across the accounts of each provider
+func (p *prettyPrinter) printAggregates(aggregates []provider.Aggregate) {
+	for _, a := range aggregates {
+		p.heading(fmt.Sprintf("%s (all %d accounts):", a.Name, a.Accounts))
+		if a.Errors > 0 {
+			fmt.Fprintf(p.w, "  %s\n", p.dim.Render(fmt.Sprintf("%d of %d accounts failed and are not included", a.Errors, a.Accounts)))
+		}
+
+		for _, win := range a.Windows {
+			fmt.Fprintf(p.w, "  %s:\n", win.Label)
+			if win.Unbounded {
+				fmt.Fprintf(p.w, "    Used:      %s pooled\n", formatAmount(*win.Used))
+				if resetDur := win.TimeUntilReset(); resetDur != nil {
+					fmt.Fprintf(p.w, "    Resets:    in %s (earliest)\n", FormatDuration(*resetDur))
+				}
+				continue
+			}
+			bar := p.bar(win.Utilization)
+			if win.Basis == provider.BasisAbsolute {
+				fmt.Fprintf(p.w, "    Usage:     %s  %.1f%% pooled\n", bar, win.Utilization)
+				fmt.Fprintf(p.w, "    Used:      %s / %s\n", formatAmount(*win.Used), formatAmount(*win.Limit))
+			} else {
+				fmt.Fprintf(p.w, "    Usage:     %s  %.1f%% average, %.1f%% highest\n", bar, win.Utilization, win.MaxUtilization)
+			}
+			if resetDur := win.TimeUntilReset(); resetDur != nil {
+				fmt.Fprintf(p.w, "    Resets:    in %s (earliest)\n", FormatDuration(*resetDur))
+			}
+			fmt.Fprintf(p.w, "    Available: %d of %d accounts below %.0f%%\n", win.Available, win.Accounts, win.Threshold)
+		}
+		fmt.Fprintln(p.w)
+	}
+}
+
+// printTagSummaries prints the usage aggregated by account tag
+func (p *prettyPrinter) printTagSummaries(tags []provider.TagSummary) {
+	if len(tags) == 0 {
+		return
+	}
+
+	p.heading("Tags:")
+	width := 0
+	for _, t := range tags {
+		width = max(width, len(t.Tag))
+	}
+	for _, t := range tags {
+		fmt.Fprintf(p.w, "  %-*s  %s  %5.1f%%  %s\n", width, t.Tag, p.bar(t.MaxUtilization), t.MaxUtilization, p.dim.Render(tagDetails(t)))
+	}
+	fmt.Fprintln(p.w)
+}
+
+func (p *prettyPrinter) printExtraUsageFromMap(extra any) {
+	extraMap, ok := extra.(map[string]any)
+	if !ok {
+		return
+	}
+
+	fmt.Fprintln(p.w, "Extra Usage Credits:")
+	if utilization, ok := extraMap["utilization"]; ok {
+		if util, ok := utilization.(float64); ok {
+			fmt.Fprintf(p.w, "  Usage:    %s  %.1f%%\n", p.bar(util), util)
+		}
+	}
+	if used, ok := extraMap["used_credits"]; ok {
+		if limit, ok := extraMap["monthly_limit"]; ok {
+			if usedFloat, ok := used.(float64); ok {
+				if limitFloat, ok := limit.(float64); ok {
+					fmt.Fprintf(p.w, "  Credits:  $%.2f / $%.2f\n", usedFloat, limitFloat)
+				}
+			}
+		}
+	}
+}
+
+func (p *prettyPrinter) printUsageWindow(label string, window *provider.UsageWindow) {
+	fmt.Fprintf(p.w, "  %s:\n", label)
+	if !window.Unbounded {
+		fmt.Fprintf(p.w, "    Usage:    %s  %.1f%%\n", p.bar(window.Utilization), window.Utilization)
+	}
+
+	if window.Used != nil {
+		if window.Limit != nil {
+			fmt.Fprintf(p.w, "    Used:     %s / %s\n", formatAmount(*window.Used), formatAmount(*window.Limit))
+		} else {
+			fmt.Fprintf(p.w, "    Used:     %s\n", formatAmount(*window.Used))
+		}
+	}
+
+	if resetDur := window.TimeUntilReset(); resetDur != nil {
+		fmt.Fprintf(p.w, "    Resets:   in %s\n", FormatDuration(*resetDur))
+	} else {
+		fmt.Fprintf(p.w, "    Resets:   N/A\n")
+	}
+}
+
+// printKimiSubscription prints Kimi subscription info with colors
+func (p *prettyPrinter) printKimiSubscription(sub any) {
+	subMap, ok := sub.(map[string]any)
+	if !ok {
+		return
+	}
+
+	fmt.Fprintln(p.w, p.title.Render("Subscription:"))
+
+	// Print plan info
+	if plan, ok := subMap["plan"].(map[string]any); ok {
+		title := getStringValue(plan, "title")
+		level := getStringValue(plan, "level")
+		status := getStringValue(plan, "status")
+
+		// Style the status based on its value
+		var styledStatus string
+		switch status {
+		case "Active":
+			styledStatus = p.classes["normal"].Render(status)
+		case "Cancelled":
+			styledStatus = p.classes["warning"].Render(status)
+		case "Expired":
+			styledStatus = p.classes["critical"].Render(status)
+		default:
+			styledStatus = status
+		}
+
+		fmt.Fprintf(p.w, "  Plan:     %s %s %s\n", title, p.dim.Render("("+level+")"), styledStatus)
+	}
+
+	// Print expiry info
+	if expiresAt, ok := subMap["expires_at"].(string); ok && expiresAt != "" {
+		if t, err := time.Parse(time.RFC3339, expiresAt); err == nil {
+			remaining := time.Until(t)
+			var expiryStr string
+			if remaining > 0 {
+				expiryStr = fmt.Sprintf("%s %s", t.Format("2006-01-02"), p.dim.Render("("+FormatDuration(remaining)+" remaining)"))
+			} else {
+				expiryStr = p.classes["critical"].Render(t.Format("2006-01-02") + " (expired)")
+			}
+			fmt.Fprintf(p.w, "  Expires:  %s\n", expiryStr)
+		}
+	}
+
+	// Print features/quotas
+	if features, ok := subMap["features"].([]any); ok && len(features) > 0 {
+		fmt.Fprintln(p.w, "  Features:")
+		for _, f := range features {
+			if feature, ok := f.(map[string]any); ok {
+				name := getStringValue(feature, "feature")
+				left := getIntValue(feature, "left")
+				total := getIntValue(feature, "total")
+
+				// Calculate percentage for progress bar
+				var percentage float64
+				if total > 0 {
+					percentage = float64(total-left) / float64(total) * 100
+				}
+
+				fmt.Fprintf(p.w, "    %s: %s %s\n",
+					p.accent.Render(name),
+					p.bar(percentage),
+					p.dim.Render(fmt.Sprintf("%d/%d left", left, total)))
+			}
+		}
+	}
+}
+
+// printOpenRouterKeyInfo prints OpenRouter key details such as the rate limit and free tier status
+func (p *prettyPrinter) printOpenRouterKeyInfo(info any) {
+	infoMap, ok := info.(map[string]any)
+	if !ok {
+		return
+	}
+
+	fmt.Fprintln(p.w, p.title.Render("Key:"))
+
+	if label := getStringValue(infoMap, "label"); label != "" {
+		fmt.Fprintf(p.w, "  Label:      %s\n", label)
+	}
+	if rateLimit := getStringValue(infoMap, "rate_limit"); rateLimit != "" {
+		fmt.Fprintf(p.w, "  Rate limit: %s\n", rateLimit)
+	}
+	if reset := getStringValue(infoMap, "limit_reset"); reset != "" {
+		fmt.Fprintf(p.w, "  Resets:     %s\n", reset)
+	}
+	if freeTier, ok := infoMap["is_free_tier"].(bool); ok && freeTier {
+		fmt.Fprintf(p.w, "  Tier:       %s\n", p.dim.Render("free"))
+	}
+	if provisioning, ok := infoMap["is_provisioning_key"].(bool); ok && provisioning {
+		fmt.Fprintf(p.w, "  Type:       %s\n", p.dim.Render("provisioning"))
+	}
+}
+
+// tableRow is a row of the table format; the usage column is drawn from the utilization
+type tableRow struct {
+	cells     []string // Provider, account, window, usage, used and resets; usage holds the error of failed rows
+	util      float64
+	unbounded bool // Windows without a limit leave the usage column empty
+	err       bool
+}
+
+// tableHeader holds the column titles of the table format
+var tableHeader = []string{"PROVIDER", "ACCOUNT", "WINDOW", "USAGE", "USED", "RESETS"}
+
+// tableUsageColumn is the index of the usage column
+const tableUsageColumn = 3
+
+// printTable prints a row per window of each account, then of each pooled provider
+func (p *prettyPrinter) printTable(stats *provider.UsageStats) {
+	var rows []tableRow
+	for _, u := range stats.Providers {
+		name, account := displayName(&u), usageAccount(&u)
+		if u.Error != nil {
+			rows = append(rows, tableRow{cells: []string{name, account, "", "Error: " + u.Error.Error(), "", ""}, err: true})
+			continue
+		}
+		for _, win := range u.Windows {
+			used := ""
+			if win.Used != nil {
+				used = formatAmount(*win.Used)
+				if win.Limit != nil {
+					used += " / " + formatAmount(*win.Limit)
+				}
+			}
+			rows = append(rows, tableRow{cells: []string{name, account, win.Label, "", used, tableReset(win.TimeUntilReset())}, util: win.Utilization, unbounded: win.Unbounded})
+		}
+	}
+	for _, a := range stats.Aggregates {
+		account := fmt.Sprintf("all %d", a.Accounts)
+		for _, win := range a.Windows {
+			used := fmt.Sprintf("%d/%d available", win.Available, win.Accounts)
+			if win.Unbounded {
+				used = formatAmount(*win.Used)
+			} else if win.Basis == provider.BasisAbsolute {
+				used = formatAmount(*win.Used) + " / " + formatAmount(*win.Limit) + ", " + used
+			}
+			rows = append(rows, tableRow{cells: []string{a.Name, account, win.Label, "", used, tableReset(win.TimeUntilReset())}, util: win.Utilization, unbounded: win.Unbounded})
+		}
+	}
+
+	widths := make([]int, len(tableHeader))
+	for i, h := range tableHeader {
+		widths[i] = len(h)
+	}
+	// Errors overflow the usage column, as the columns after it are empty
+	for _, row := range rows {
+		for i, cell := range row.cells {
+			if i != tableUsageColumn {
+				widths[i] = max(widths[i], lipgloss.Width(cell))
+			}
+		}
+	}
+
+	// Narrow the bars to fit the terminal: the usage column is a bar and " 100.0%"
+	const pctWidth = 7
+	if p.width > 0 {
+		others := 2 * (len(widths) - 1)
+		for i, w := range widths {
+			if i != tableUsageColumn {
+				others += w
+			}
+		}
+		p.barWidth = max(minBarWidth, min(p.barWidth, p.width-others-pctWidth))
+	}
+	widths[tableUsageColumn] = max(widths[tableUsageColumn], p.barWidth+pctWidth)
+
+	header := make([]string, len(tableHeader))
+	for i, h := range tableHeader {
+		header[i] = p.title.Render(h)
+	}
+	p.printTableRow(header, widths)
+	for _, row := range rows {
+		cells := slices.Clone(row.cells)
+		if row.err {
+			cells[tableUsageColumn] = p.classes["critical"].Render(cells[tableUsageColumn])
+		} else if !row.unbounded {
+			cells[tableUsageColumn] = fmt.Sprintf("%s %5.1f%%", p.bar(row.util), row.util)
+		}
+		p.printTableRow(cells, widths)
+	}
+	fmt.Fprintln(p.w)
+}
+
+// printTableRow prints cells padded to the column widths
+func (p *prettyPrinter) printTableRow(cells []string, widths []int) {
+	var b strings.Builder
+	for i, cell := range cells {
+		if i > 0 {
+			b.WriteString("  ")
+		}
+		b.WriteString(cell)
+		if i < len(cells)-1 {
+			b.WriteString(strings.Repeat(" ", max(0, widths[i]-lipgloss.Width(cell))))
+		}
+	}
+	fmt.Fprintln(p.w, strings.TrimRight(b.String(), " "))
+}
+
+// tableReset formats the time until a reset for the table format
+func tableReset(d *time.Duration) string {
+	switch {
+	case d == nil:
+		return ""
+	case *d < 0:
+		return FormatDuration(*d)
+	default:
+		return "in " + FormatDuration(*d)
+	}
+}
+
+// RenderProgressBar renders a progress bar for the given percentage
+func RenderProgressBar(percentage float64) string {
+	return progressBar(percentage, barWidth)
+}
+
+// progressBar renders a progress bar of a width for the given percentage
+func progressBar(percentage float64, width int) string {
+	filled := int(percentage / 100 * float64(width))
+	filled = max(0, min(filled, width))
+
+	return strings.Repeat(barFull, filled) + strings.Repeat(barEmpty, width-filled)
+}
@@ -0,0 +1,119 @@
+package usage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/denysvitali/llm-usage/internal/config"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func renderStats(t *testing.T, format string, opts FormatOptions, stats *provider.UsageStats) string {
+	t.Helper()
+	r, err := NewRenderer(format, opts)
+	if err != nil {
+		t.Fatalf("NewRenderer(%s) error = %v", format, err)
+	}
+	var buf bytes.Buffer
+	if err := r.Render(&buf, stats); err != nil {
+		t.Fatalf("Render(%s) error = %v", format, err)
+	}
+	return buf.String()
+}
+
+func TestPretty_Color(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	for _, tt := range []struct {
+		color string
+		want  bool
+	}{
+		{"", false},
+		{config.ColorNever, false},
+		{config.ColorAlways, true},
+	} {
+		got := renderStats(t, FormatPretty, FormatOptions{Color: tt.color}, statusStats())
+		if colored := strings.Contains(got, "\x1b["); colored != tt.want {
+			t.Errorf("color %q: colored = %v, want %v:\n%s", tt.color, colored, tt.want, got)
+		}
+	}
+
+	if _, err := NewRenderer(FormatTable, FormatOptions{Color: "sometimes"}); err == nil {
+		t.Error("NewRenderer() with an invalid color succeeded")
+	}
+}
+
+func TestPretty_Width(t *testing.T) {
+	stats := &provider.UsageStats{Providers: []provider.Usage{
+		{Provider: "kimi", Name: "Kimi", Windows: []provider.UsageWindow{{Label: "Weekly", Utilization: 50}}},
+	}}
+
+	t.Setenv("COLUMNS", "")
+	if got := renderStats(t, FormatPretty, FormatOptions{}, stats); !strings.Contains(got, progressBar(50, barWidth)+"  50.0%") {
+		t.Errorf("unknown width: output does not contain a %d-wide bar:\n%s", barWidth, got)
+	}
+	t.Setenv("COLUMNS", "160")
+	if got := renderStats(t, FormatPretty, FormatOptions{}, stats); !strings.Contains(got, progressBar(50, maxBarWidth)+"  50.0%") {
+		t.Errorf("160 columns: output does not contain a %d-wide bar:\n%s", maxBarWidth, got)
+	}
+}
+
+func TestTable(t *testing.T) {
+	t.Setenv("COLUMNS", "")
+	got := renderStats(t, FormatTable, FormatOptions{}, testStats())
+	lines := strings.Split(strings.TrimRight(got, "\n"), "\n")
+	want := []string{
+		"PROVIDER  ACCOUNT    WINDOW  USAGE                        USED        RESETS",
+		"Kimi      work \"eu\"  Weekly  ████████░░░░░░░░░░░░  42.5%  425 / 1000  expired",
+		"Z.AI                         Error: zai: unauthorized",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("table =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// The bars narrow to fit the terminal
+	t.Setenv("COLUMNS", "64")
+	got = renderStats(t, FormatTable, FormatOptions{}, testStats())
+	for _, line := range strings.Split(strings.TrimRight(got, "\n"), "\n") {
+		if w := len([]rune(line)); w > 64 {
+			t.Errorf("line is %d columns wide, want at most 64: %q", w, line)
+		}
+	}
+}
+
+func TestPretty_Unbounded(t *testing.T) {
+	tokens := 1500.0
+	stats := &provider.UsageStats{Providers: []provider.Usage{
+		{Provider: "openai", Name: "OpenAI", Windows: []provider.UsageWindow{{Label: "Month Tokens", Used: &tokens, Unbounded: true}}},
+	}}
+
+	for _, format := range []string{FormatPretty, FormatTable} {
+		got := renderStats(t, format, FormatOptions{}, stats)
+		if strings.Contains(got, "%") || !strings.Contains(got, "1500") {
+			t.Errorf("%s: window without a limit shows a percentage or no amount:\n%s", format, got)
+		}
+	}
+}
+
+func TestThemes(t *testing.T) {
+	userThemes := map[string]config.Theme{"mine": {Critical: "#ff00ff"}}
+	theme, err := resolveTheme("mine", userThemes)
+	if err != nil {
+		t.Fatalf("resolveTheme() error = %v", err)
+	}
+	if theme.Critical != "#ff00ff" || theme.Normal != themes[ThemeDefault].Normal {
+		t.Errorf("resolveTheme() = %+v, want the user color and the default ones", theme)
+	}
+
+	if _, err := resolveTheme(ThemeColorblind, userThemes); err != nil {
+		t.Errorf("resolveTheme(%s) error = %v", ThemeColorblind, err)
+	}
+	if _, err := NewRenderer(FormatPretty, FormatOptions{Theme: "neon", Themes: userThemes}); err == nil || !strings.Contains(err.Error(), "mine") {
+		t.Errorf("unknown theme error = %v, want the valid themes", err)
+	}
+
+	got := renderStats(t, FormatPretty, FormatOptions{Color: config.ColorAlways, Theme: "mine", Themes: userThemes}, statusStats())
+	if !strings.Contains(got, "\x1b[") {
+		t.Errorf("themed output is not colored:\n%s", got)
+	}
+}
@@ -0,0 +1,114 @@
+package usage
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// metric is a gauge family of the Prometheus text format
+type metric struct {
+	name, help string
+	samples    []sample
+}
+
+type sample struct {
+	labels [][2]string // Label names and values, in order
+	value  float64
+}
+
+func (m *metric) add(value float64, labels ...string) {
+	s := sample{value: value}
+	for i := 0; i+1 < len(labels); i += 2 {
+		s.labels = append(s.labels, [2]string{labels[i], labels[i+1]})
+	}
+	m.samples = append(m.samples, s)
+}
+
+// renderPrometheus writes usage stats in the Prometheus text exposition format, for the
+// node exporter's textfile collector or a scrape through a file server
+func renderPrometheus(w io.Writer, stats *provider.UsageStats) error {
+	up := &metric{name: "llm_usage_up", help: "Whether the usage of the account was fetched."}
+	utilization := &metric{name: "llm_usage_utilization_percent", help: "Utilization of the usage window."}
+	used := &metric{name: "llm_usage_used", help: "Amount used in the usage window, in the provider's unit."}
+	limit := &metric{name: "llm_usage_limit", help: "Limit of the usage window, in the provider's unit."}
+	remaining := &metric{name: "llm_usage_remaining", help: "Amount remaining in the usage window, in the provider's unit."}
+	resets := &metric{name: "llm_usage_resets_at_seconds", help: "Unix time at which the usage window resets."}
+	pooled := &metric{name: "llm_usage_pooled_utilization_percent", help: "Utilization pooled across the accounts of the provider."}
+	available := &metric{name: "llm_usage_available_accounts", help: "Accounts of the provider below the utilization threshold."}
+	tagged := &metric{name: "llm_usage_tag_max_utilization_percent", help: "Highest utilization of the accounts with the tag."}
+
+	for i := range stats.Providers {
+		u := &stats.Providers[i]
+		account := usageAccount(u)
+		if u.Error != nil {
+			up.add(0, "provider", u.Provider, "account", account)
+			continue
+		}
+		up.add(1, "provider", u.Provider, "account", account)
+		for _, win := range u.Windows {
+			labels := []string{"provider", u.Provider, "account", account, "window", win.Label}
+			if !win.Unbounded {
+				utilization.add(win.Utilization, labels...)
+			}
+			addOptional(used, win.Used, labels)
+			addOptional(limit, win.Limit, labels)
+			addOptional(remaining, win.Remaining, labels)
+			if win.ResetsAt != nil {
+				resets.add(float64(win.ResetsAt.Unix()), labels...)
+			}
+		}
+	}
+	for _, a := range stats.Aggregates {
+		for _, win := range a.Windows {
+			if win.Unbounded {
+				continue
+			}
+			pooled.add(win.Utilization, "provider", a.Provider, "window", win.Label)
+			available.add(float64(win.Available), "provider", a.Provider, "window", win.Label)
+		}
+	}
+	for _, t := range stats.Tags {
+		tagged.add(t.MaxUtilization, "tag", t.Tag)
+	}
+
+	var b strings.Builder
+	for _, m := range []*metric{up, utilization, used, limit, remaining, resets, pooled, available, tagged} {
+		if len(m.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+		for _, s := range m.samples {
+			b.WriteString(m.name)
+			b.WriteByte('{')
+			for i, l := range s.labels {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				fmt.Fprintf(&b, "%s=\"%s\"", l[0], escapeLabel(l[1]))
+			}
+			b.WriteString("} ")
+			b.WriteString(strconv.FormatFloat(s.value, 'f', -1, 64))
+			b.WriteByte('\n')
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// addOptional adds a sample for an amount the provider may not report
+func addOptional(m *metric, v *float64, labels []string) {
+	if v != nil {
+		m.add(*v, labels...)
+	}
+}
+
+// labelEscaper escapes label values as required by the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -2,66 +2,56 @@
 package usage
 
 import (
-	"encoding/json"
-	"os"
+	"cmp"
+	"fmt"
+	"slices"
 	"strings"
 	"sync"
 
+	"github.com/denysvitali/llm-usage/internal/config"
 	"github.com/denysvitali/llm-usage/internal/credentials"
 	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/provider/anthropicapi"
 	"github.com/denysvitali/llm-usage/internal/provider/claude"
+	"github.com/denysvitali/llm-usage/internal/provider/exec"
+	"github.com/denysvitali/llm-usage/internal/provider/generic"
 	"github.com/denysvitali/llm-usage/internal/provider/kimi"
 	"github.com/denysvitali/llm-usage/internal/provider/minimax"
+	"github.com/denysvitali/llm-usage/internal/provider/openai"
+	"github.com/denysvitali/llm-usage/internal/provider/openrouter"
 	"github.com/denysvitali/llm-usage/internal/provider/zai"
 )
 
 const (
-	providerClaude  = "claude"
-	providerKimi    = "kimi"
-	providerZAi     = "zai"
-	providerMiniMax = "minimax"
+	providerClaude       = "claude"
+	providerKimi         = "kimi"
+	providerZAi          = "zai"
+	providerMiniMax      = "minimax"
+	providerOpenAI       = "openai"
+	providerAnthropicAPI = "anthropic-api"
+	providerOpenRouter   = "openrouter"
 )
 
 // ProviderInstance holds a provider instance along with its account info
 type ProviderInstance struct {
 	provider.Provider
 	AccountName string
+	Tags        []string // From the account's metadata in the credentials file
 }
 
-// LoadClaudeFromKeychain tries to load Claude credentials from the CLI keychain location
-func LoadClaudeFromKeychain() (*credentials.OAuthCredentials, string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, "", err
-	}
-
-	credPath := homeDir + "/.claude/.credentials.json"
-	data, err := os.ReadFile(credPath) //nolint:gosec // Path is constructed from home directory
-	if err != nil {
-		return nil, "", err
-	}
-
-	var result struct {
-		ClaudeAiOauth *credentials.OAuthCredentials `json:"claudeAiOauth"`
-	}
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, "", err
-	}
-
-	if result.ClaudeAiOauth == nil || result.ClaudeAiOauth.AccessToken == "" {
-		return nil, "", ErrNoValidCredentials
-	}
-
-	return result.ClaudeAiOauth, "default", nil
-}
-
-// GetProviders returns the list of providers to query based on the flags
-func GetProviders(providerFlag, accountFlag string, allAccounts bool, credsMgr *credentials.Manager) []ProviderInstance {
+// GetProviders returns the list of providers to query based on the flags.
+// Providers defined in cfg are queried alongside the built-in ones.
+func GetProviders(providerFlag, accountFlag string, allAccounts bool, credsMgr *credentials.Manager, cfg *config.Config) []ProviderInstance {
 	var providerIDs []string
 
 	if providerFlag == "all" || providerFlag == "" {
 		// Show all configured providers
 		providerIDs = credsMgr.ListAvailable()
+		for _, id := range cfg.ProviderIDs() {
+			if !slices.Contains(providerIDs, id) {
+				providerIDs = append(providerIDs, id)
+			}
+		}
 		// If no providers are configured, default to claude
 		if len(providerIDs) == 0 {
 			providerIDs = []string{providerClaude}
@@ -73,91 +63,174 @@ func GetProviders(providerFlag, accountFlag string, allAccounts bool, credsMgr *
 	var providers []ProviderInstance
 	for _, pid := range providerIDs {
 		pid = strings.TrimSpace(pid)
+		var instances []ProviderInstance
 		switch pid {
 		case providerClaude:
-			providers = append(providers, getClaudeProviders(accountFlag, credsMgr)...)
+			instances = getClaudeProviders(accountFlag, allAccounts, credsMgr, cfg.BaseURL(pid))
 		case providerKimi:
-			providers = append(providers, getKimiProviders(accountFlag, allAccounts, credsMgr)...)
+			instances = getKimiProviders(accountFlag, allAccounts, credsMgr, cfg.BaseURL(pid))
 		case providerZAi:
-			providers = append(providers, getZaiProviders(accountFlag, allAccounts, credsMgr)...)
+			instances = getZaiProviders(accountFlag, allAccounts, credsMgr)
 		case providerMiniMax:
-			providers = append(providers, getMiniMaxProviders(accountFlag, allAccounts, credsMgr)...)
+			instances = getMiniMaxProviders(accountFlag, allAccounts, credsMgr, cfg.BaseURL(pid))
+		case providerOpenAI:
+			instances = getOpenAIProviders(accountFlag, allAccounts, credsMgr, cfg.BaseURL(pid))
+		case providerAnthropicAPI:
+			instances = getAnthropicAPIProviders(accountFlag, allAccounts, credsMgr, cfg.BaseURL(pid))
+		case providerOpenRouter:
+			instances = getOpenRouterProviders(accountFlag, allAccounts, credsMgr, cfg.BaseURL(pid))
+		default:
+			if pc := cfg.Provider(pid); pc != nil {
+				instances = getConfiguredProviders(pc, accountFlag, allAccounts, credsMgr)
+			}
 		}
+		setTags(instances, pid, credsMgr)
+		providers = append(providers, instances...)
 	}
 
 	return providers
 }
 
-// getClaudeProviders returns Claude provider instances
-func getClaudeProviders(accountFlag string, credsMgr *credentials.Manager) []ProviderInstance {
+// getClaudeProviders returns Claude provider instances for the discovered accounts.
+// baseURL is the configured endpoint, used unless an account sets its own.
+func getClaudeProviders(accountFlag string, allAccounts bool, credsMgr *credentials.Manager, baseURL string) []ProviderInstance {
 	var providers []ProviderInstance
+	for _, d := range credsMgr.DiscoverClaude() {
+		if accountFlag != "" && !allAccounts && d.Name != accountFlag {
+			continue
+		}
+		if claude.IsExpired(d.Credentials.ExpiresAt) {
+			continue
+		}
+		providers = append(providers, ProviderInstance{
+			Provider:    claude.NewProvider(d.Credentials.AccessToken, cmp.Or(d.Credentials.BaseURL, baseURL)),
+			AccountName: d.Name,
+		})
+	}
+	return providers
+}
 
-	// Try loading from keychain first (Claude CLI location)
-	keychainCreds, keychainAccount, keychainErr := LoadClaudeFromKeychain()
-
-	// Also try loading from the new multi-account location
-	multiCreds, multiErr := credsMgr.LoadClaude()
+// getKimiProviders returns Kimi provider instances
+func getKimiProviders(accountFlag string, allAccounts bool, credsMgr *credentials.Manager, baseURL string) []ProviderInstance {
+	var providers []ProviderInstance
 
-	// Determine which source to use
-	if keychainErr != nil && multiErr != nil {
-		// Neither source available, skip
+	creds, err := credsMgr.LoadKimi()
+	if err != nil {
 		return providers
 	}
 
-	// If a specific account is requested, only use the multi-account location
-	if accountFlag != "" {
-		if multiErr != nil {
-			return providers
+	if allAccounts || accountFlag == "" {
+		// Add all accounts when --all-accounts is set or no specific account requested
+		for _, accName := range creds.ListAccounts() {
+			acc := creds.GetAccount(accName)
+			if acc == nil {
+				continue
+			}
+			providers = append(providers, ProviderInstance{
+				Provider:    kimi.NewProvider(acc.APIKey, cmp.Or(acc.BaseURL, baseURL)),
+				AccountName: accName,
+			})
 		}
-		oauth := multiCreds.GetAccount(accountFlag)
-		if oauth == nil || claude.IsExpired(oauth.ExpiresAt) {
+	} else {
+		// Use specified account
+		acc := creds.GetAccount(accountFlag)
+		if acc == nil {
 			return providers
 		}
 		providers = append(providers, ProviderInstance{
-			Provider:    claude.NewProvider(oauth.AccessToken),
+			Provider:    kimi.NewProvider(acc.APIKey, cmp.Or(acc.BaseURL, baseURL)),
 			AccountName: accountFlag,
 		})
+	}
+
+	return providers
+}
+
+// getZaiProviders returns Z.AI provider instances
+func getZaiProviders(accountFlag string, allAccounts bool, credsMgr *credentials.Manager) []ProviderInstance {
+	var providers []ProviderInstance
+
+	creds, err := credsMgr.LoadZAi()
+	if err != nil {
 		return providers
 	}
 
-	// No specific account requested - show all available
-	// Add from keychain if available
-	if keychainErr == nil && !claude.IsExpired(keychainCreds.ExpiresAt) {
+	if allAccounts || accountFlag == "" {
+		// Add all accounts when --all-accounts is set or no specific account requested
+		for _, accName := range creds.ListAccounts() {
+			acc := creds.GetAccount(accName)
+			if acc == nil {
+				continue
+			}
+			providers = append(providers, ProviderInstance{
+				Provider:    zai.NewProvider(acc.APIKey),
+				AccountName: accName,
+			})
+		}
+	} else {
+		// Use specified account
+		acc := creds.GetAccount(accountFlag)
+		if acc == nil {
+			return providers
+		}
 		providers = append(providers, ProviderInstance{
-			Provider:    claude.NewProvider(keychainCreds.AccessToken),
-			AccountName: keychainAccount,
+			Provider:    zai.NewProvider(acc.APIKey),
+			AccountName: accountFlag,
 		})
 	}
-	// Add from multi-account location if available
-	if multiErr == nil {
-		for _, accName := range multiCreds.ListAccounts() {
-			// Skip if this was already added from keychain
-			if keychainErr == nil && accName == "default" {
-				continue
-			}
-			oauth := multiCreds.GetAccount(accName)
-			if oauth == nil || claude.IsExpired(oauth.ExpiresAt) {
+
+	return providers
+}
+
+// getMiniMaxProviders returns MiniMax provider instances
+func getMiniMaxProviders(accountFlag string, allAccounts bool, credsMgr *credentials.Manager, baseURL string) []ProviderInstance {
+	var providers []ProviderInstance
+
+	creds, err := credsMgr.LoadMiniMax()
+	if err != nil {
+		return providers
+	}
+
+	if allAccounts || accountFlag == "" {
+		// Add all accounts when --all-accounts is set or no specific account requested
+		for _, accName := range creds.ListAccounts() {
+			acc := creds.GetAccount(accName)
+			if acc == nil {
 				continue
 			}
 			providers = append(providers, ProviderInstance{
-				Provider:    claude.NewProvider(oauth.AccessToken),
+				Provider:    minimax.NewProvider(acc.Cookie, acc.GroupID, cmp.Or(acc.BaseURL, baseURL)),
 				AccountName: accName,
 			})
 		}
+	} else {
+		// Use specified account
+		acc := creds.GetAccount(accountFlag)
+		if acc == nil {
+			return providers
+		}
+		providers = append(providers, ProviderInstance{
+			Provider:    minimax.NewProvider(acc.Cookie, acc.GroupID, cmp.Or(acc.BaseURL, baseURL)),
+			AccountName: accountFlag,
+		})
 	}
 
 	return providers
 }
 
-// getKimiProviders returns Kimi provider instances
-func getKimiProviders(accountFlag string, allAccounts bool, credsMgr *credentials.Manager) []ProviderInstance {
+// getOpenAIProviders returns OpenAI provider instances
+func getOpenAIProviders(accountFlag string, allAccounts bool, credsMgr *credentials.Manager, baseURL string) []ProviderInstance {
 	var providers []ProviderInstance
 
-	creds, err := credsMgr.LoadKimi()
+	creds, err := credsMgr.LoadOpenAI()
 	if err != nil {
 		return providers
 	}
 
+	newProvider := func(acc *credentials.OpenAIAccount) provider.Provider {
+		return newOpenAIProvider(acc, baseURL)
+	}
+
 	if allAccounts || accountFlag == "" {
 		// Add all accounts when --all-accounts is set or no specific account requested
 		for _, accName := range creds.ListAccounts() {
@@ -166,7 +239,7 @@ func getKimiProviders(accountFlag string, allAccounts bool, credsMgr *credential
 				continue
 			}
 			providers = append(providers, ProviderInstance{
-				Provider:    kimi.NewProvider(acc.APIKey),
+				Provider:    newProvider(acc),
 				AccountName: accName,
 			})
 		}
@@ -177,7 +250,7 @@ func getKimiProviders(accountFlag string, allAccounts bool, credsMgr *credential
 			return providers
 		}
 		providers = append(providers, ProviderInstance{
-			Provider:    kimi.NewProvider(acc.APIKey),
+			Provider:    newProvider(acc),
 			AccountName: accountFlag,
 		})
 	}
@@ -185,15 +258,19 @@ func getKimiProviders(accountFlag string, allAccounts bool, credsMgr *credential
 	return providers
 }
 
-// getZaiProviders returns Z.AI provider instances
-func getZaiProviders(accountFlag string, allAccounts bool, credsMgr *credentials.Manager) []ProviderInstance {
+// getAnthropicAPIProviders returns Anthropic Admin API provider instances
+func getAnthropicAPIProviders(accountFlag string, allAccounts bool, credsMgr *credentials.Manager, baseURL string) []ProviderInstance {
 	var providers []ProviderInstance
 
-	creds, err := credsMgr.LoadZAi()
+	creds, err := credsMgr.LoadAnthropicAPI()
 	if err != nil {
 		return providers
 	}
 
+	newProvider := func(acc *credentials.AnthropicAPIAccount) provider.Provider {
+		return newAnthropicAPIProvider(acc, baseURL)
+	}
+
 	if allAccounts || accountFlag == "" {
 		// Add all accounts when --all-accounts is set or no specific account requested
 		for _, accName := range creds.ListAccounts() {
@@ -202,7 +279,7 @@ func getZaiProviders(accountFlag string, allAccounts bool, credsMgr *credentials
 				continue
 			}
 			providers = append(providers, ProviderInstance{
-				Provider:    zai.NewProvider(acc.APIKey),
+				Provider:    newProvider(acc),
 				AccountName: accName,
 			})
 		}
@@ -213,7 +290,7 @@ func getZaiProviders(accountFlag string, allAccounts bool, credsMgr *credentials
 			return providers
 		}
 		providers = append(providers, ProviderInstance{
-			Provider:    zai.NewProvider(acc.APIKey),
+			Provider:    newProvider(acc),
 			AccountName: accountFlag,
 		})
 	}
@@ -221,11 +298,11 @@ func getZaiProviders(accountFlag string, allAccounts bool, credsMgr *credentials
 	return providers
 }
 
-// getMiniMaxProviders returns MiniMax provider instances
-func getMiniMaxProviders(accountFlag string, allAccounts bool, credsMgr *credentials.Manager) []ProviderInstance {
+// getOpenRouterProviders returns OpenRouter provider instances
+func getOpenRouterProviders(accountFlag string, allAccounts bool, credsMgr *credentials.Manager, baseURL string) []ProviderInstance {
 	var providers []ProviderInstance
 
-	creds, err := credsMgr.LoadMiniMax()
+	creds, err := credsMgr.LoadOpenRouter()
 	if err != nil {
 		return providers
 	}
@@ -238,7 +315,7 @@ func getMiniMaxProviders(accountFlag string, allAccounts bool, credsMgr *credent
 				continue
 			}
 			providers = append(providers, ProviderInstance{
-				Provider:    minimax.NewProvider(acc.Cookie, acc.GroupID),
+				Provider:    openrouter.NewProvider(acc.APIKey, cmp.Or(acc.BaseURL, baseURL)),
 				AccountName: accName,
 			})
 		}
@@ -249,7 +326,7 @@ func getMiniMaxProviders(accountFlag string, allAccounts bool, credsMgr *credent
 			return providers
 		}
 		providers = append(providers, ProviderInstance{
-			Provider:    minimax.NewProvider(acc.Cookie, acc.GroupID),
+			Provider:    openrouter.NewProvider(acc.APIKey, cmp.Or(acc.BaseURL, baseURL)),
 			AccountName: accountFlag,
 		})
 	}
@@ -257,6 +334,145 @@ func getMiniMaxProviders(accountFlag string, allAccounts bool, credsMgr *credent
 	return providers
 }
 
+// newOpenAIProvider creates an OpenAI provider, probing rate limits if the account has a probe key
+func newOpenAIProvider(acc *credentials.OpenAIAccount, baseURL string) provider.Provider {
+	baseURL = cmp.Or(acc.BaseURL, baseURL)
+	p := openai.NewProvider(acc.AdminKey, baseURL, provider.Budget{
+		Monthly: acc.MonthlyBudget,
+		Daily:   acc.DailyBudget,
+	})
+	if acc.ProbeKey == "" {
+		return p
+	}
+	return &provider.ProbingProvider{Provider: p, Probe: openai.NewRateLimitProbe(acc.ProbeKey, baseURL)}
+}
+
+// newAnthropicAPIProvider creates an Anthropic Admin API provider, probing rate limits if the
+// account has a probe key
+func newAnthropicAPIProvider(acc *credentials.AnthropicAPIAccount, baseURL string) provider.Provider {
+	baseURL = cmp.Or(acc.BaseURL, baseURL)
+	p := anthropicapi.NewProvider(acc.AdminKey, baseURL, provider.Budget{
+		Monthly: acc.MonthlyBudget,
+		Daily:   acc.DailyBudget,
+	})
+	if acc.ProbeKey == "" {
+		return p
+	}
+	return &provider.ProbingProvider{Provider: p, Probe: anthropicapi.NewRateLimitProbe(acc.ProbeKey, baseURL)}
+}
+
+// NewAccountProvider creates the provider of a single account that is not saved yet, such
+// as one being added. account is a pointer to the provider's account type, for example
+// *credentials.KimiAccount, or *credentials.GenericAccount for configured providers.
+func NewAccountProvider(providerID, accountName string, account any, cfg *config.Config) (provider.Provider, error) {
+	baseURL := cfg.BaseURL(providerID)
+	switch acc := account.(type) {
+	case *credentials.ClaudeAccount:
+		return claude.NewProvider(acc.AccessToken, cmp.Or(acc.BaseURL, baseURL)), nil
+	case *credentials.KimiAccount:
+		return kimi.NewProvider(acc.APIKey, cmp.Or(acc.BaseURL, baseURL)), nil
+	case *credentials.ZAiAccount:
+		return zai.NewProvider(acc.APIKey), nil
+	case *credentials.MiniMaxAccount:
+		return minimax.NewProvider(acc.Cookie, acc.GroupID, cmp.Or(acc.BaseURL, baseURL)), nil
+	case *credentials.OpenAIAccount:
+		return newOpenAIProvider(acc, baseURL), nil
+	case *credentials.AnthropicAPIAccount:
+		return newAnthropicAPIProvider(acc, baseURL), nil
+	case *credentials.OpenRouterAccount:
+		return openrouter.NewProvider(acc.APIKey, cmp.Or(acc.BaseURL, baseURL)), nil
+	case *credentials.GenericAccount:
+		pc := cfg.Provider(providerID)
+		if pc == nil {
+			return nil, fmt.Errorf("unknown provider: %s", providerID)
+		}
+		return newConfiguredProvider(pc, accountName, acc)
+	default:
+		return nil, fmt.Errorf("unsupported account type %T for provider %s", account, providerID)
+	}
+}
+
+// getConfiguredProviders returns instances of a provider defined in the configuration file.
+// Without a credentials file a single unauthenticated instance is returned, so that
+// endpoints relying only on templated environment variables still work.
+func getConfiguredProviders(pc *config.ProviderConfig, accountFlag string, allAccounts bool, credsMgr *credentials.Manager) []ProviderInstance {
+	var providers []ProviderInstance
+
+	accounts := map[string]*credentials.GenericAccount{}
+	if creds, err := credsMgr.LoadGeneric(pc.ID); err == nil {
+		if allAccounts || accountFlag == "" {
+			for _, accName := range creds.ListAccounts() {
+				if acc := creds.GetAccount(accName); acc != nil {
+					accounts[accName] = acc
+				}
+			}
+		} else if acc := creds.GetAccount(accountFlag); acc != nil {
+			accounts[accountFlag] = acc
+		}
+	} else if !credsMgr.ProviderExists(pc.ID) && (accountFlag == "" || allAccounts) {
+		accounts[""] = &credentials.GenericAccount{}
+	}
+
+	for _, accName := range sortedKeys(accounts) {
+		acc := accounts[accName]
+		p, err := newConfiguredProvider(pc, accName, acc)
+		if err != nil {
+			providers = append(providers, ProviderInstance{
+				Provider:    &failedProvider{id: pc.ID, name: pc.DisplayName(), err: err},
+				AccountName: accName,
+			})
+			continue
+		}
+		providers = append(providers, ProviderInstance{
+			Provider:    p,
+			AccountName: accName,
+		})
+	}
+
+	return providers
+}
+
+// newConfiguredProvider creates a provider instance for a configured provider type
+func newConfiguredProvider(pc *config.ProviderConfig, accName string, acc *credentials.GenericAccount) (provider.Provider, error) {
+	switch pc.Type {
+	case config.TypeExec:
+		return exec.NewProvider(pc, exec.Credentials{
+			Account: accName,
+			APIKey:  acc.APIKey,
+			Fields:  acc.Fields,
+		}), nil
+	default:
+		return generic.NewProvider(pc, generic.Credentials{
+			Account: accName,
+			APIKey:  acc.APIKey,
+			Fields:  acc.Fields,
+		})
+	}
+}
+
+// failedProvider reports a provider that could not be constructed
+type failedProvider struct {
+	id, name string
+	err      error
+}
+
+func (f *failedProvider) Name() string { return f.name }
+func (f *failedProvider) ID() string   { return f.id }
+
+func (f *failedProvider) GetUsage() (*provider.Usage, error) {
+	return nil, f.err
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
 // FetchAllUsage fetches usage from all providers concurrently
 func FetchAllUsage(providers []ProviderInstance) *provider.UsageStats {
 	var wg sync.WaitGroup
@@ -273,18 +489,21 @@ func FetchAllUsage(providers []ProviderInstance) *provider.UsageStats {
 
 			usage, err := prov.GetUsage()
 			if err != nil {
-				mu.Lock()
-				stats.Providers[idx] = *provider.NewUsageError(prov.ID(), prov.Name(), err)
-				mu.Unlock()
-				return
+				usage = provider.NewUsageError(prov.ID(), prov.Name(), err)
 			}
+			usage.Name = prov.Name()
 
-			// Add account name to usage if available
-			if prov.AccountName != "" {
+			// Add account name and tags to usage if available
+			if prov.AccountName != "" || len(prov.Tags) > 0 {
 				if usage.Extra == nil {
 					usage.Extra = make(map[string]any)
 				}
-				usage.Extra["account"] = prov.AccountName
+				if prov.AccountName != "" {
+					usage.Extra["account"] = prov.AccountName
+				}
+				if len(prov.Tags) > 0 {
+					usage.Extra["tags"] = prov.Tags
+				}
 			}
 
 			mu.Lock()
@@ -303,6 +522,7 @@ func FetchAllUsage(providers []ProviderInstance) *provider.UsageStats {
 		}
 	}
 	stats.Providers = filtered
+	stats.Tags = summarizeTags(stats.Providers)
 
 	return stats
 }
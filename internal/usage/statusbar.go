@@ -0,0 +1,518 @@
+package usage
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// classError is the class of status bar text reporting an error, next to the utilization
+// classes normal, warning and critical
+const classError = "error"
+
+// statusColors are the colors of the classes in status bars; normal text keeps the bar's color
+var statusColors = map[string]string{
+	"warning":  "#ffaf00",
+	"critical": "#ff0000",
+	classError: "#ff0000",
+}
+
+// Window selectors of FormatOptions.Windows, next to window labels
+const (
+	WindowFirst    = "first"    // The first window the provider reports
+	WindowTightest = "tightest" // The window with the highest utilization
+)
+
+// Default utilizations at which status bars switch class
+const (
+	DefaultWarning  = 75.0
+	DefaultCritical = 90.0
+)
+
+// statusClasses are the classes of status bar text that FormatOptions.Classes can rename
+var statusClasses = []string{"normal", "warning", "critical", classError}
+
+// statusPart is an entry of the compact status bar text
+type statusPart struct {
+	Provider    string  // Provider ID
+	Label       string  // Provider short name, with the number of accounts when pooled
+	Utilization float64 // Of the window chosen for the provider
+}
+
+func (p statusPart) String() string {
+	return fmt.Sprintf("%s:%.0f%%", p.Label, p.Utilization)
+}
+
+// statusLine is a line of the detailed status bar tooltip or menu
+type statusLine struct {
+	Text  string
+	Class string // Utilization class, or classError; empty for headings and blank lines
+}
+
+// thresholds classify utilizations as normal, warning or critical
+type thresholds struct {
+	warning, critical float64
+}
+
+func newThresholds(opts FormatOptions) (thresholds, error) {
+	t := thresholds{warning: cmp.Or(opts.Warning, DefaultWarning), critical: cmp.Or(opts.Critical, DefaultCritical)}
+	if t.warning <= 0 || t.warning >= t.critical || t.critical > 100 {
+		return thresholds{}, fmt.Errorf("thresholds must satisfy 0 < warning < critical <= 100, got %g and %g", t.warning, t.critical)
+	}
+	return t, nil
+}
+
+// class returns the class of a utilization
+func (t thresholds) class(util float64) string {
+	switch {
+	case util >= t.critical:
+		return "critical"
+	case util >= t.warning:
+		return "warning"
+	default:
+		return "normal"
+	}
+}
+
+// statusBar builds the text shared by the status bar formats
+type statusBar struct {
+	thresholds
+	layout  *template.Template // From FormatOptions.StatusText; nil joins the status parts
+	windows map[string]string
+	classes map[string]string
+	module  bool
+}
+
+func newStatusBar(opts FormatOptions) (statusBar, error) {
+	t, err := newThresholds(opts)
+	if err != nil {
+		return statusBar{}, err
+	}
+	b := statusBar{
+		thresholds: t,
+		windows:    opts.Windows,
+		classes:    opts.Classes,
+		module:     opts.Module,
+	}
+
+	if opts.StatusText != "" {
+		layout, err := template.New("status-text").Funcs(TemplateFuncs).
+			Funcs(template.FuncMap{"color": defaultColors(t).color}).
+			Parse(opts.StatusText)
+		if err != nil {
+			return statusBar{}, fmt.Errorf("invalid status text: %w", err)
+		}
+		b.layout = layout
+	}
+	return b, nil
+}
+
+// className returns the name of a class given with FormatOptions.Classes
+func (b statusBar) className(class string) string {
+	if name, ok := b.classes[class]; ok {
+		return name
+	}
+	return class
+}
+
+// window returns the index of the window chosen for a provider, or -1 when there is none
+func (b statusBar) window(providerID string, windows []provider.UsageWindow) int {
+	if len(windows) == 0 {
+		return -1
+	}
+	selector, ok := b.windows[providerID]
+	if !ok {
+		selector = b.windows[""]
+	}
+
+	// Windows without a limit have no percentage to show
+	first := slices.IndexFunc(windows, func(win provider.UsageWindow) bool { return !win.Unbounded })
+	if first < 0 {
+		return -1
+	}
+
+	switch selector {
+	case "", WindowFirst:
+		return first
+	case WindowTightest:
+		tightest := first
+		for i, win := range windows {
+			if !win.Unbounded && win.Utilization > windows[tightest].Utilization {
+				tightest = i
+			}
+		}
+		return tightest
+	default:
+		for i, win := range windows {
+			if !win.Unbounded && strings.EqualFold(win.Label, selector) {
+				return i
+			}
+		}
+		// Providers without the window show their first one
+		return first
+	}
+}
+
+// parts returns the entries of the compact status bar text. Providers aggregated with
+// --all-accounts are shown once, pooled.
+func (b statusBar) parts(stats *provider.UsageStats) []statusPart {
+	aggregated := make(map[string]bool)
+	for _, a := range stats.Aggregates {
+		aggregated[a.Provider] = true
+	}
+
+	var parts []statusPart
+	for _, p := range stats.Providers {
+		if p.Error != nil || aggregated[p.Provider] {
+			continue
+		}
+		if i := b.window(p.Provider, p.Windows); i >= 0 {
+			parts = append(parts, statusPart{Provider: p.Provider, Label: providerShortName(p.Provider), Utilization: p.Windows[i].Utilization})
+		}
+	}
+	for _, a := range stats.Aggregates {
+		windows := make([]provider.UsageWindow, len(a.Windows))
+		for i, win := range a.Windows {
+			windows[i] = win.UsageWindow
+		}
+		if i := b.window(a.Provider, windows); i >= 0 {
+			parts = append(parts, statusPart{Provider: a.Provider, Label: fmt.Sprintf("%s×%d", providerShortName(a.Provider), a.Accounts), Utilization: windows[i].Utilization})
+		}
+	}
+	return parts
+}
+
+// utilization returns the highest utilization of the parts, which sets the class of the
+// whole status bar text
+func utilization(parts []statusPart) float64 {
+	var maxUtil float64
+	for _, p := range parts {
+		maxUtil = max(maxUtil, p.Utilization)
+	}
+	return maxUtil
+}
+
+// tooltip returns the detailed lines shown in status bar tooltips and menus
+func (b statusBar) tooltip(stats *provider.UsageStats) []statusLine {
+	lines := []statusLine{{Text: "LLM Usage"}, {}}
+
+	for _, p := range stats.Providers {
+		if p.Error != nil {
+			lines = append(lines, statusLine{Text: fmt.Sprintf("%s: Error", displayName(&p)), Class: classError})
+			continue
+		}
+
+		// Get account name if available
+		accountSuffix := ""
+		if acc, ok := p.Extra["account"]; ok && acc != "" {
+			accountSuffix = fmt.Sprintf(" (%s)", acc)
+		}
+
+		for _, win := range p.Windows {
+			line := fmt.Sprintf("%s%s %s: %.1f%%", displayName(&p), accountSuffix, win.Label, win.Utilization)
+			class := b.class(win.Utilization)
+			if win.Unbounded {
+				line = fmt.Sprintf("%s%s %s: %s used", displayName(&p), accountSuffix, win.Label, formatAmount(*win.Used))
+				class = b.class(0)
+			}
+			if d := win.TimeUntilReset(); d != nil {
+				line += fmt.Sprintf(" (resets in %s)", FormatDuration(*d))
+			}
+			lines = append(lines, statusLine{Text: line, Class: class})
+		}
+	}
+
+	for _, a := range stats.Aggregates {
+		for _, win := range a.Windows {
+			line := fmt.Sprintf("%s (all %d) %s: %.1f%%, %d/%d available", a.Name, a.Accounts, win.Label, win.Utilization, win.Available, win.Accounts)
+			class := b.class(win.Utilization)
+			if win.Unbounded {
+				line = fmt.Sprintf("%s (all %d) %s: %s used", a.Name, a.Accounts, win.Label, formatAmount(*win.Used))
+				class = b.class(0)
+			}
+			if d := win.TimeUntilReset(); d != nil {
+				line += fmt.Sprintf(" (next reset in %s)", FormatDuration(*d))
+			}
+			lines = append(lines, statusLine{Text: line, Class: class})
+		}
+	}
+
+	if len(stats.Tags) > 0 {
+		lines = append(lines, statusLine{})
+		for _, t := range stats.Tags {
+			lines = append(lines, statusLine{Text: fmt.Sprintf("#%s: %.1f%% (%s)", t.Tag, t.MaxUtilization, tagDetails(t)), Class: b.class(t.MaxUtilization)})
+		}
+	}
+	return lines
+}
+
+// tooltipText joins the text of tooltip lines
+func tooltipText(lines []statusLine) string {
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// text renders the compact text of the parts. style, which may be nil, marks up text of a
+// class: each part with the default layout, or the whole text with a custom one. In module
+// mode a single part is shown without its label.
+func (b statusBar) text(stats *provider.UsageStats, parts []statusPart, style func(text, class string) string) (string, error) {
+	if style == nil {
+		style = func(text, _ string) string { return text }
+	}
+
+	if b.layout != nil {
+		var sb strings.Builder
+		if err := b.layout.Execute(&sb, stats); err != nil {
+			return "", err
+		}
+		return style(strings.TrimSpace(sb.String()), b.class(utilization(parts))), nil
+	}
+
+	if b.module && len(parts) == 1 {
+		return style(fmt.Sprintf("%.0f%%", parts[0].Utilization), b.class(parts[0].Utilization)), nil
+	}
+	texts := make([]string, len(parts))
+	for i, p := range parts {
+		texts[i] = style(p.String(), b.class(p.Utilization))
+	}
+	return strings.Join(texts, " "), nil
+}
+
+// shortText is the text of status bars with little room: the highest utilization
+func shortText(parts []statusPart) string {
+	return fmt.Sprintf("%.0f%%", utilization(parts))
+}
+
+// ParseWindows parses --window values, "selector" or "provider=selector", into
+// FormatOptions.Windows
+func ParseWindows(values []string) (map[string]string, error) {
+	windows := make(map[string]string, len(values))
+	for _, v := range values {
+		providerID, selector, ok := strings.Cut(v, "=")
+		if !ok {
+			providerID, selector = "", v
+		}
+		if selector = strings.TrimSpace(selector); selector == "" {
+			return nil, fmt.Errorf("invalid window %q: missing selector", v)
+		}
+		windows[strings.TrimSpace(providerID)] = selector
+	}
+	return windows, nil
+}
+
+// ParseClasses parses --class values, "class=name", into FormatOptions.Classes
+func ParseClasses(values []string) (map[string]string, error) {
+	classes := make(map[string]string, len(values))
+	for _, v := range values {
+		class, name, ok := strings.Cut(v, "=")
+		if !ok || !slices.Contains(statusClasses, class) || name == "" {
+			return nil, fmt.Errorf("invalid class %q: want class=name with class one of %s", v, strings.Join(statusClasses, ", "))
+		}
+		classes[class] = name
+	}
+	return classes, nil
+}
+
+// errorText is the compact text of status bars reporting an error
+const errorText = "LLM: Error"
+
+// WaybarOutput represents the JSON format expected by waybar custom modules
+type WaybarOutput struct {
+	Text       string `json:"text"`
+	Alt        string `json:"alt"` // Provider ID when one provider is shown, for format-icons
+	Tooltip    string `json:"tooltip"`
+	Class      string `json:"class"`
+	Percentage int    `json:"percentage"`
+}
+
+// waybarAltMixed is the alt of waybar text showing several providers
+const waybarAltMixed = "mixed"
+
+// waybarRenderer writes waybar custom module JSON
+type waybarRenderer struct{ statusBar }
+
+func (r waybarRenderer) Render(w io.Writer, stats *provider.UsageStats) error {
+	parts := r.parts(stats)
+	text, err := r.text(stats, parts, nil)
+	if err != nil {
+		return err
+	}
+
+	alt := waybarAltMixed
+	if len(parts) == 1 {
+		alt = parts[0].Provider
+	}
+	return json.NewEncoder(w).Encode(WaybarOutput{
+		Text:       text,
+		Alt:        alt,
+		Tooltip:    tooltipText(r.tooltip(stats)),
+		Class:      r.className(r.class(utilization(parts))),
+		Percentage: int(math.Round(utilization(parts))),
+	})
+}
+
+func (r waybarRenderer) RenderError(w io.Writer, msg string) error {
+	return json.NewEncoder(w).Encode(WaybarOutput{
+		Text:       errorText,
+		Alt:        classError,
+		Tooltip:    msg,
+		Class:      r.className(classError),
+		Percentage: 0,
+	})
+}
+
+// polybarRenderer writes a polybar custom/script module line with %{F} color tags
+type polybarRenderer struct{ statusBar }
+
+func polybarStyle(text, class string) string {
+	if c, ok := statusColors[class]; ok {
+		return "%{F" + c + "}" + text + "%{F-}"
+	}
+	return text
+}
+
+func (r polybarRenderer) Render(w io.Writer, stats *provider.UsageStats) error {
+	text, err := r.text(stats, r.parts(stats), polybarStyle)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, text)
+	return err
+}
+
+func (polybarRenderer) RenderError(w io.Writer, _ string) error {
+	_, err := fmt.Fprintln(w, polybarStyle(errorText, classError))
+	return err
+}
+
+// i3blocksRenderer writes the full text, short text and color lines of an i3blocks block
+type i3blocksRenderer struct{ statusBar }
+
+func (r i3blocksRenderer) Render(w io.Writer, stats *provider.UsageStats) error {
+	parts := r.parts(stats)
+	text, err := r.text(stats, parts, nil)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n%s\n%s\n", text, shortText(parts), statusColors[r.class(utilization(parts))])
+	return err
+}
+
+func (i3blocksRenderer) RenderError(w io.Writer, _ string) error {
+	_, err := fmt.Fprintf(w, "%s\n%s\n%s\n", errorText, "LLM!", statusColors[classError])
+	return err
+}
+
+// I3StatusOutput represents the JSON of an i3status-rust custom block with json = true
+type I3StatusOutput struct {
+	Text      string `json:"text"`
+	ShortText string `json:"short_text,omitempty"`
+	State     string `json:"state"` // Idle, Info, Good, Warning or Critical
+}
+
+// i3statusStates maps classes to i3status-rust block states
+var i3statusStates = map[string]string{
+	"normal":   "Idle",
+	"warning":  "Warning",
+	"critical": "Critical",
+	classError: "Critical",
+}
+
+// i3statusRenderer writes i3status-rust custom block JSON
+type i3statusRenderer struct{ statusBar }
+
+func (r i3statusRenderer) Render(w io.Writer, stats *provider.UsageStats) error {
+	parts := r.parts(stats)
+	text, err := r.text(stats, parts, nil)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(I3StatusOutput{
+		Text:      text,
+		ShortText: shortText(parts),
+		State:     i3statusStates[r.class(utilization(parts))],
+	})
+}
+
+func (i3statusRenderer) RenderError(w io.Writer, _ string) error {
+	return json.NewEncoder(w).Encode(I3StatusOutput{Text: errorText, State: i3statusStates[classError]})
+}
+
+// tmuxColors are the colors of the classes in tmux styles
+var tmuxColors = map[string]string{
+	"warning":  "colour214",
+	"critical": "colour196",
+	classError: "colour196",
+}
+
+// tmuxRenderer writes a tmux status string, for #(llm-usage --format tmux) in status-right
+type tmuxRenderer struct{ statusBar }
+
+func tmuxStyle(text, class string) string {
+	if c, ok := tmuxColors[class]; ok {
+		return "#[fg=" + c + "]" + text + "#[default]"
+	}
+	return text
+}
+
+func (r tmuxRenderer) Render(w io.Writer, stats *provider.UsageStats) error {
+	text, err := r.text(stats, r.parts(stats), tmuxStyle)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, text)
+	return err
+}
+
+func (tmuxRenderer) RenderError(w io.Writer, _ string) error {
+	_, err := fmt.Fprintln(w, tmuxStyle(errorText, classError))
+	return err
+}
+
+// xbarRenderer writes an xbar or SwiftBar plugin: the menu bar title, then the menu
+type xbarRenderer struct{ statusBar }
+
+// xbarLine formats a menu line; xbar separates parameters from the text with "|"
+func xbarLine(text, class string) string {
+	text = strings.ReplaceAll(text, "|", "¦")
+	if c, ok := statusColors[class]; ok {
+		return text + " | color=" + c
+	}
+	return text
+}
+
+func (r xbarRenderer) Render(w io.Writer, stats *provider.UsageStats) error {
+	parts := r.parts(stats)
+	text, err := r.text(stats, parts, nil)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(xbarLine(text, r.class(utilization(parts))) + "\n---\n")
+	for _, l := range r.tooltip(stats) {
+		if l.Text == "" {
+			b.WriteString("---\n")
+			continue
+		}
+		b.WriteString(xbarLine(l.Text, l.Class) + "\n")
+	}
+	b.WriteString("---\nRefresh | refresh=true\n")
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+func (xbarRenderer) RenderError(w io.Writer, msg string) error {
+	_, err := fmt.Fprintf(w, "%s\n---\n%s\n---\nRefresh | refresh=true\n", xbarLine(errorText, classError), xbarLine(msg, ""))
+	return err
+}
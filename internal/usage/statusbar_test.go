@@ -0,0 +1,221 @@
+package usage
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func statusStats() *provider.UsageStats {
+	return &provider.UsageStats{Providers: []provider.Usage{
+		{Provider: "kimi", Name: "Kimi", Windows: []provider.UsageWindow{{Label: "Weekly", Utilization: 80}}},
+		{Provider: "claude", Name: "Claude", Windows: []provider.UsageWindow{{Label: "5-Hour", Utilization: 95}}},
+		{Provider: "zai", Name: "Z.AI", Error: errors.New("zai: unauthorized")},
+		{Provider: "openai", Name: "OpenAI", Windows: []provider.UsageWindow{{Label: "Monthly", Utilization: 10}}},
+	}}
+}
+
+func TestStatusBarRenderers(t *testing.T) {
+	for _, tt := range []struct {
+		format string
+		want   string
+	}{
+		{FormatPolybar, "%{F#ffaf00}K:80%%{F-} %{F#ff0000}C:95%%{F-} O:10%\n"},
+		{FormatI3blocks, "K:80% C:95% O:10%\n95%\n#ff0000\n"},
+		{FormatI3status, `{"text":"K:80% C:95% O:10%","short_text":"95%","state":"Critical"}` + "\n"},
+		{FormatTmux, "#[fg=colour214]K:80%#[default] #[fg=colour196]C:95%#[default] O:10%\n"},
+		{FormatXbar, "K:80% C:95% O:10% | color=#ff0000\n---\nLLM Usage\n---\n" +
+			"Kimi Weekly: 80.0% | color=#ffaf00\n" +
+			"Claude (Pro/Max Subscription) 5-Hour: 95.0% | color=#ff0000\n" +
+			"Z.AI: Error | color=#ff0000\n" +
+			"OpenAI Monthly: 10.0%\n" +
+			"---\nRefresh | refresh=true\n"},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			r, err := NewRenderer(tt.format, FormatOptions{})
+			if err != nil {
+				t.Fatalf("NewRenderer() error = %v", err)
+			}
+			var buf bytes.Buffer
+			if err := r.Render(&buf, statusStats()); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Render() =\n%q\nwant\n%q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusBar_Aggregates(t *testing.T) {
+	stats := &provider.UsageStats{
+		Providers: []provider.Usage{
+			{Provider: "kimi", Name: "Kimi", Windows: []provider.UsageWindow{{Label: "Weekly", Utilization: 20}}},
+			{Provider: "kimi", Name: "Kimi", Windows: []provider.UsageWindow{{Label: "Weekly", Utilization: 40}}},
+		},
+		Aggregates: []provider.Aggregate{{Provider: "kimi", Name: "Kimi", Accounts: 2, Windows: []provider.AggregateWindow{
+			{UsageWindow: provider.UsageWindow{Label: "Weekly", Utilization: 30}, Accounts: 2, Available: 2},
+		}}},
+	}
+	b, err := newStatusBar(FormatOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := b.text(stats, b.parts(stats), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "K×2:30%" {
+		t.Errorf("text = %q, want the pooled usage only", text)
+	}
+}
+
+func TestStatusBar_StatusText(t *testing.T) {
+	r, err := NewRenderer(FormatTmux, FormatOptions{StatusText: "AI {{pct .MaxUtilization}}"})
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := r.Render(&buf, statusStats()); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "#[fg=colour196]AI 95.0%#[default]\n"; buf.String() != want {
+		t.Errorf("Render() = %q, want %q", buf.String(), want)
+	}
+
+	if _, err := NewRenderer(FormatPolybar, FormatOptions{StatusText: "{{.Nope"}); err == nil {
+		t.Error("NewRenderer() with an invalid status text succeeded")
+	}
+	if _, err := NewRenderer(FormatJSON, FormatOptions{StatusText: "AI"}); err == nil || !strings.Contains(err.Error(), FormatI3blocks) {
+		t.Errorf("NewRenderer(json) with a status text error = %v, want the status bar formats", err)
+	}
+}
+
+func TestStatusBar_RenderError(t *testing.T) {
+	for _, tt := range []struct {
+		format string
+		want   string
+	}{
+		{FormatWaybar, `"class":"error"`},
+		{FormatPolybar, "%{F#ff0000}LLM: Error%{F-}"},
+		{FormatI3blocks, "LLM: Error\nLLM!\n#ff0000\n"},
+		{FormatI3status, `"state":"Critical"`},
+		{FormatTmux, "#[fg=colour196]LLM: Error#[default]"},
+		{FormatSwiftBar, "---\nno providers ¦ configured\n"},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			r, err := NewRenderer(tt.format, FormatOptions{})
+			if err != nil {
+				t.Fatalf("NewRenderer() error = %v", err)
+			}
+			var buf bytes.Buffer
+			if err := ReportError(r, &buf, errors.New("no providers | configured")); err != nil {
+				t.Fatalf("ReportError() = %v, want the error reported in the output", err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("output does not contain %q:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestStatusBar_Windows(t *testing.T) {
+	stats := &provider.UsageStats{Providers: []provider.Usage{
+		{Provider: "claude", Name: "Claude", Windows: []provider.UsageWindow{
+			{Label: "5-Hour", Utilization: 12},
+			{Label: "7-Day", Utilization: 40},
+			{Label: "7-Day Opus", Utilization: 98},
+		}},
+		{Provider: "kimi", Name: "Kimi", Windows: []provider.UsageWindow{
+			{Label: "Weekly", Utilization: 30},
+			{Label: "5-Hour", Utilization: 50},
+		}},
+		// Windows without a limit are never shown, and providers with only those are left out
+		{Provider: "openai", Name: "OpenAI", Windows: []provider.UsageWindow{
+			{Label: "Month Tokens", Unbounded: true},
+			{Label: "Month Spend", Utilization: 20},
+		}},
+		{Provider: "anthropic-api", Name: "Anthropic API", Windows: []provider.UsageWindow{
+			{Label: "Month Spend", Unbounded: true},
+		}},
+	}}
+
+	for _, tt := range []struct {
+		windows []string
+		want    string
+	}{
+		{nil, "C:12% K:30% O:20%"},
+		{[]string{"tightest"}, "C:98% K:50% O:20%"},
+		{[]string{"7-day"}, "C:40% K:30% O:20%"},
+		{[]string{"claude=tightest"}, "C:98% K:30% O:20%"},
+		{[]string{"5-Hour", "claude=7-Day Opus"}, "C:98% K:50% O:20%"},
+		{[]string{"openai=Month Tokens"}, "C:12% K:30% O:20%"},
+	} {
+		windows, err := ParseWindows(tt.windows)
+		if err != nil {
+			t.Fatalf("ParseWindows(%q) error = %v", tt.windows, err)
+		}
+		b, err := newStatusBar(FormatOptions{Windows: windows})
+		if err != nil {
+			t.Fatal(err)
+		}
+		text, err := b.text(stats, b.parts(stats), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != tt.want {
+			t.Errorf("windows %q: text = %q, want %q", tt.windows, text, tt.want)
+		}
+	}
+
+	if _, err := ParseWindows([]string{"claude="}); err == nil {
+		t.Error("ParseWindows() without a selector succeeded")
+	}
+	if _, err := NewRenderer(FormatPretty, FormatOptions{Windows: map[string]string{"": WindowTightest}}); err == nil {
+		t.Error("NewRenderer(pretty) with windows succeeded")
+	}
+}
+
+func TestWaybar_Options(t *testing.T) {
+	classes, err := ParseClasses([]string{"warning=warn", "critical=urgent"})
+	if err != nil {
+		t.Fatalf("ParseClasses() error = %v", err)
+	}
+	r, err := NewRenderer(FormatWaybar, FormatOptions{Warning: 50, Critical: 60, Classes: classes, Module: true})
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+	stats := &provider.UsageStats{Providers: []provider.Usage{
+		{Provider: "kimi", Name: "Kimi", Windows: []provider.UsageWindow{{Label: "Weekly", Utilization: 55.6}}},
+	}}
+	var buf bytes.Buffer
+	if err := r.Render(&buf, stats); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	for _, want := range []string{`"text":"56%"`, `"alt":"kimi"`, `"class":"warn"`, `"percentage":56`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := ReportError(r, &buf, errors.New("unauthorized")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"alt":"error"`) || !strings.Contains(buf.String(), `"class":"error"`) {
+		t.Errorf("error output = %s, want the error alt and class", buf.String())
+	}
+
+	if _, err := ParseClasses([]string{"danger=red"}); err == nil {
+		t.Error("ParseClasses() with an unknown class succeeded")
+	}
+	if _, err := NewRenderer(FormatTmux, FormatOptions{Classes: classes}); err == nil {
+		t.Error("NewRenderer(tmux) with classes succeeded")
+	}
+	if _, err := NewRenderer(FormatWaybar, FormatOptions{Warning: 95, Critical: 90}); err == nil {
+		t.Error("NewRenderer() with a warning above the critical threshold succeeded")
+	}
+}
@@ -0,0 +1,76 @@
+package usage
+
+import (
+	"slices"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// setTags sets the tags of a provider's instances from the account metadata in its
+// credentials file. Accounts defined elsewhere, such as in the environment, have no tags.
+func setTags(instances []ProviderInstance, providerID string, credsMgr *credentials.Manager) {
+	if len(instances) == 0 || !credsMgr.ProviderExists(providerID) {
+		return
+	}
+	meta, err := credsMgr.ReadAccountsMeta(providerID)
+	if err != nil {
+		return
+	}
+	for i := range instances {
+		if m := meta.Meta(instances[i].AccountName); m != nil {
+			instances[i].Tags = m.Tags
+		}
+	}
+}
+
+// FilterByTags returns the instances whose account has one of the tags; no tags keeps all
+func FilterByTags(providers []ProviderInstance, tags []string) []ProviderInstance {
+	if len(tags) == 0 {
+		return providers
+	}
+	var filtered []ProviderInstance
+	for _, p := range providers {
+		if (&credentials.AccountMeta{Tags: p.Tags}).HasAnyTag(tags) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// usageTags returns the tags FetchAllUsage recorded for a result
+func usageTags(u *provider.Usage) []string {
+	tags, _ := u.Extra["tags"].([]string)
+	return tags
+}
+
+// summarizeTags aggregates the results of the accounts sharing each tag, sorted by tag
+func summarizeTags(results []provider.Usage) []provider.TagSummary {
+	byTag := make(map[string]*provider.TagSummary)
+	for i := range results {
+		u := &results[i]
+		for _, tag := range usageTags(u) {
+			summary := byTag[tag]
+			if summary == nil {
+				summary = &provider.TagSummary{Tag: tag}
+				byTag[tag] = summary
+			}
+			summary.Accounts++
+			if u.Error != nil {
+				summary.Errors++
+				continue
+			}
+			for _, w := range u.Windows {
+				summary.MaxUtilization = max(summary.MaxUtilization, w.Utilization)
+			}
+		}
+	}
+
+	summaries := make([]provider.TagSummary, 0, len(byTag))
+	for _, tag := range sortedKeys(byTag) {
+		summary := byTag[tag]
+		summary.Class = provider.UtilizationClass(summary.MaxUtilization)
+		summaries = append(summaries, *summary)
+	}
+	return slices.Clip(summaries)
+}
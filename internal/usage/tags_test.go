@@ -0,0 +1,60 @@
+package usage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// stubProvider returns fixed usage
+type stubProvider struct {
+	id   string
+	util float64
+	err  error
+}
+
+func (p *stubProvider) Name() string { return p.id }
+func (p *stubProvider) ID() string   { return p.id }
+
+func (p *stubProvider) GetUsage() (*provider.Usage, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &provider.Usage{Provider: p.id, Windows: []provider.UsageWindow{{Label: "Daily", Utilization: p.util}}}, nil
+}
+
+func TestFilterByTags_Summary(t *testing.T) {
+	providers := []ProviderInstance{
+		{Provider: &stubProvider{id: "kimi", util: 40}, AccountName: "acme", Tags: []string{"work"}},
+		{Provider: &stubProvider{id: "openai", util: 80}, AccountName: "acme", Tags: []string{"work", "team-a"}},
+		{Provider: &stubProvider{id: "zai", err: errors.New("unauthorized")}, AccountName: "side", Tags: []string{"team-a"}},
+		{Provider: &stubProvider{id: "kimi", util: 95}, AccountName: "home", Tags: []string{"personal"}},
+		{Provider: &stubProvider{id: "claude", util: 10}, AccountName: "default"},
+	}
+
+	if got := FilterByTags(providers, nil); len(got) != len(providers) {
+		t.Errorf("FilterByTags(nil) kept %d providers, want all %d", len(got), len(providers))
+	}
+	filtered := FilterByTags(providers, []string{"work", "team-a"})
+	if len(filtered) != 3 {
+		t.Fatalf("FilterByTags(work, team-a) kept %d providers, want 3", len(filtered))
+	}
+
+	stats := FetchAllUsage(filtered)
+	want := []provider.TagSummary{
+		{Tag: "team-a", Accounts: 2, Errors: 1, MaxUtilization: 80, Class: "warning"},
+		{Tag: "work", Accounts: 2, MaxUtilization: 80, Class: "warning"},
+	}
+	if len(stats.Tags) != len(want) {
+		t.Fatalf("Tags = %+v, want %+v", stats.Tags, want)
+	}
+	for i := range want {
+		if stats.Tags[i] != want[i] {
+			t.Errorf("Tags[%d] = %+v, want %+v", i, stats.Tags[i], want[i])
+		}
+	}
+	if acc := stats.Providers[2].Extra["account"]; acc != "side" {
+		t.Errorf("failed provider account = %v, want side", acc)
+	}
+}
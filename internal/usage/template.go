@@ -0,0 +1,130 @@
+package usage
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/denysvitali/llm-usage/internal/config"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// TemplateFuncs are the functions available to templates of the template format:
+//
+//   - bar renders a utilization as a progress bar
+//   - duration formats a time.Duration, or the time until a time.Time or *time.Time
+//   - pct formats a utilization, a float64 or *float64, as a percentage
+//   - color renders text in the color of a utilization, of a class (normal, warning or
+//     critical) or of a lipgloss color such as "212" or "#ff8800"
+//
+// color uses the default thresholds and theme; renderers replace it with one following their
+// options.
+var TemplateFuncs = template.FuncMap{
+	"bar":      RenderProgressBar,
+	"duration": templateDuration,
+	"pct":      templatePct,
+	"color":    defaultColors(thresholds{DefaultWarning, DefaultCritical}).color,
+}
+
+// templateRenderer executes a text/template with the usage stats as data
+type templateRenderer struct {
+	thresholds
+	tmpl  *template.Template
+	color string
+	theme config.Theme
+}
+
+func newTemplateRenderer(opts FormatOptions) (Renderer, error) {
+	if opts.Template == "" {
+		return nil, errNoTemplate
+	}
+	color, theme, err := resolveColors(opts)
+	if err != nil {
+		return nil, err
+	}
+	t, err := newThresholds(opts)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(FormatTemplate).Funcs(TemplateFuncs).Parse(opts.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return &templateRenderer{thresholds: t, tmpl: tmpl, color: color, theme: theme}, nil
+}
+
+// Render executes the template with color following the thresholds, color mode and theme,
+// and whether w is a color terminal
+func (r *templateRenderer) Render(w io.Writer, stats *provider.UsageStats) error {
+	tmpl, err := r.tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	colors := newTemplateColors(r.thresholds, colorRenderer(w, r.color), r.theme)
+	return tmpl.Funcs(template.FuncMap{"color": colors.color}).Execute(w, stats)
+}
+
+// templateColors implements the color template function
+type templateColors struct {
+	thresholds
+	lg      *lipgloss.Renderer
+	classes map[string]lipgloss.Style
+}
+
+func newTemplateColors(t thresholds, lg *lipgloss.Renderer, theme config.Theme) templateColors {
+	return templateColors{thresholds: t, lg: lg, classes: classStyles(lg, theme)}
+}
+
+// defaultColors returns the colors of the default theme, detecting whether stdout is a color
+// terminal
+func defaultColors(t thresholds) templateColors {
+	return newTemplateColors(t, lipgloss.DefaultRenderer(), themes[ThemeDefault])
+}
+
+func templateDuration(v any) (string, error) {
+	switch v := v.(type) {
+	case time.Duration:
+		return FormatDuration(v), nil
+	case time.Time:
+		return FormatDuration(time.Until(v)), nil
+	case *time.Time:
+		if v == nil {
+			return "N/A", nil
+		}
+		return FormatDuration(time.Until(*v)), nil
+	default:
+		return "", fmt.Errorf("duration: unsupported value %T", v)
+	}
+}
+
+func templatePct(v any) (string, error) {
+	switch v := v.(type) {
+	case float64:
+		return fmt.Sprintf("%.1f%%", v), nil
+	case *float64:
+		if v == nil {
+			return "N/A", nil
+		}
+		return fmt.Sprintf("%.1f%%", *v), nil
+	case int:
+		return fmt.Sprintf("%d%%", v), nil
+	default:
+		return "", fmt.Errorf("pct: unsupported value %T", v)
+	}
+}
+
+func (c templateColors) color(by any, text string) (string, error) {
+	switch by := by.(type) {
+	case float64:
+		return c.classes[c.class(by)].Render(text), nil
+	case string:
+		if style, ok := c.classes[by]; ok {
+			return style.Render(text), nil
+		}
+		return c.lg.NewStyle().Foreground(lipgloss.Color(by)).Render(text), nil
+	default:
+		return "", fmt.Errorf("color: unsupported value %T", by)
+	}
+}